@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"os"
+	// Schedule windows name IANA time zones, which the distroless image
+	// does not ship
+	_ "time/tzdata"
 
 	appsv1 "k8s.io/api/apps/v1"
 	autoscalingv2 "k8s.io/api/autoscaling/v2"
@@ -11,13 +15,17 @@ import (
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	ctrlwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
 	"github.com/example/endpoint-scaler/controller/pkg/controller"
+	"github.com/example/endpoint-scaler/controller/pkg/webhook"
 )
 
 var (
@@ -31,6 +39,7 @@ func init() {
 	utilruntime.Must(appsv1.AddToScheme(scheme))
 	utilruntime.Must(autoscalingv2.AddToScheme(scheme))
 	utilruntime.Must(gatewayv1.Install(scheme))
+	utilruntime.Must(gatewayv1beta1.Install(scheme))
 	utilruntime.Must(esv1alpha1.AddToScheme(scheme))
 }
 
@@ -38,10 +47,26 @@ func main() {
 	var metricsAddr string
 	var probeAddr string
 	var enableLeaderElection bool
+	var enableWebhooks bool
+	var webhookPort int
+	var webhookCertDir string
+	var webhookServiceName string
+	var webhookSecretName string
+	var webhookConfigName string
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false, "Enable leader election.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false, "Serve the EndpointPolicy admission webhooks.")
+	flag.IntVar(&webhookPort, "webhook-port", 9443, "The port the webhook server listens on.")
+	flag.StringVar(&webhookCertDir, "webhook-cert-dir", "/tmp/k8s-webhook-server/serving-certs",
+		"The directory the webhook serving certificate is written to.")
+	flag.StringVar(&webhookServiceName, "webhook-service-name", "endpoint-scaler-webhook",
+		"The Service in front of the webhook server.")
+	flag.StringVar(&webhookSecretName, "webhook-secret-name", "endpoint-scaler-webhook-cert",
+		"The Secret the webhook CA and serving certificate are stored in.")
+	flag.StringVar(&webhookConfigName, "webhook-config-name", "endpoint-scaler",
+		"The name of the mutating and validating webhook configurations to inject the CA into.")
 
 	opts := zap.Options{Development: true}
 	opts.BindFlags(flag.CommandLine)
@@ -49,11 +74,44 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	cfg := ctrl.GetConfigOrDie()
+
+	var certManager *webhook.CertManager
+	if enableWebhooks {
+		// The certificate must exist before the webhook server starts, so it
+		// is provisioned with a direct client ahead of the manager.
+		directClient, err := client.New(cfg, client.Options{Scheme: scheme})
+		if err != nil {
+			setupLog.Error(err, "unable to create client")
+			os.Exit(1)
+		}
+		certManager = &webhook.CertManager{
+			Client:            directClient,
+			Namespace:         os.Getenv("POD_NAMESPACE"),
+			SecretName:        webhookSecretName,
+			ServiceName:       webhookServiceName,
+			CertDir:           webhookCertDir,
+			WebhookConfigName: webhookConfigName,
+		}
+		if certManager.Namespace == "" {
+			setupLog.Error(nil, "POD_NAMESPACE must be set when webhooks are enabled")
+			os.Exit(1)
+		}
+		if err := certManager.Ensure(context.Background()); err != nil {
+			setupLog.Error(err, "unable to provision webhook certificates")
+			os.Exit(1)
+		}
+	}
+
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
 		Scheme: scheme,
 		Metrics: metricsserver.Options{
 			BindAddress: metricsAddr,
 		},
+		WebhookServer: ctrlwebhook.NewServer(ctrlwebhook.Options{
+			Port:    webhookPort,
+			CertDir: webhookCertDir,
+		}),
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "endpoint-scaler.io",
@@ -64,8 +122,9 @@ func main() {
 	}
 
 	controller := &controller.EndpointPolicyReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("endpoint-scaler"),
 	}
 
 	if err = controller.SetupWithManager(mgr); err != nil {
@@ -73,6 +132,19 @@ func main() {
 		os.Exit(1)
 	}
 
+	if enableWebhooks {
+		// The route claim index is registered by the controller above
+		policyWebhook := &webhook.EndpointPolicyWebhook{Client: mgr.GetClient()}
+		if err := policyWebhook.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "EndpointPolicy")
+			os.Exit(1)
+		}
+		if err := mgr.Add(certManager); err != nil {
+			setupLog.Error(err, "unable to add webhook certificate manager")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -81,6 +153,12 @@ func main() {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
+	if enableWebhooks {
+		if err := mgr.AddReadyzCheck("webhook", mgr.GetWebhookServer().StartedChecker()); err != nil {
+			setupLog.Error(err, "unable to set up webhook ready check")
+			os.Exit(1)
+		}
+	}
 
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
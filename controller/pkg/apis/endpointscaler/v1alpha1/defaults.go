@@ -0,0 +1,45 @@
+package v1alpha1
+
+// Default values assumed by the controller when a field is unset.
+const (
+	DefaultPort          int32 = 80
+	DefaultContainerPort int32 = 8080
+	DefaultCanaryWeight  int32 = 5
+
+	// DefaultGRPCPort is the Service port gRPC routes use when appRef.port
+	// is unset
+	DefaultGRPCPort int32 = 9090
+)
+
+// Default fills in the defaults the resource builders assume, so that the
+// stored object shows the values actually in effect.
+func (s *EndpointPolicySpec) Default() {
+	if s.AppRef.Port == 0 {
+		s.AppRef.Port = DefaultPort
+	}
+	if s.AppRef.ContainerPort == 0 {
+		s.AppRef.ContainerPort = DefaultContainerPort
+	}
+
+	for i := range s.Endpoints {
+		s.Endpoints[i].Default()
+	}
+}
+
+// Default fills in the endpoint type and strategy, the canary weight of
+// canary endpoints and the autoscaler kind.
+func (e *EndpointSpec) Default() {
+	if e.Type == "" {
+		e.Type = "http"
+	}
+	if e.Strategy == "" {
+		e.Strategy = "primary"
+	}
+	if e.Strategy == "canary" && e.CanaryWeight == nil {
+		weight := DefaultCanaryWeight
+		e.CanaryWeight = &weight
+	}
+	if e.Autoscaler != nil && e.Autoscaler.Kind == "" {
+		e.Autoscaler.Kind = "hpa"
+	}
+}
@@ -1,6 +1,7 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -29,6 +30,19 @@ type EndpointPolicySpec struct {
 	// GatewayRef references the Gateway for routing
 	GatewayRef GatewayReference `json:"gatewayRef"`
 
+	// Template is merged into every endpoint Deployment's pod template.
+	// The container named "app" customizes the endpoint container; other
+	// containers are added alongside it.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Template *corev1.PodTemplateSpec `json:"template,omitempty"`
+
+	// SDKProbes points the liveness and readiness probes of every endpoint
+	// container at the SDK's /endpointscaler/healthz and /endpointscaler/readyz
+	// handlers on the "http" port
+	// +optional
+	SDKProbes bool `json:"sdkProbes,omitempty"`
+
 	// Endpoints defines the list of endpoint configurations
 	// +kubebuilder:validation:MinItems=1
 	Endpoints []EndpointSpec `json:"endpoints"`
@@ -51,8 +65,28 @@ type AppReference struct {
 	// +kubebuilder:default=8080
 	ContainerPort int32 `json:"containerPort,omitempty"`
 
-	// Image for endpoint-specific deployments (required)
-	Image string `json:"image"`
+	// Image for endpoint-specific deployments. Required unless
+	// DeploymentRef is set, in which case it overrides the inherited image.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// DeploymentRef names the main application Deployment in the app
+	// namespace. Its pod template is cloned for every endpoint Deployment,
+	// so changes to it roll out to the endpoints.
+	// +optional
+	DeploymentRef *DeploymentReference `json:"deploymentRef,omitempty"`
+}
+
+// DeploymentReference identifies the main application Deployment
+type DeploymentReference struct {
+	// Name of the Deployment
+	Name string `json:"name"`
+
+	// Container is the application container in the pod template
+	// (defaults to the first container). Other containers are kept
+	// alongside the endpoint container.
+	// +optional
+	Container string `json:"container,omitempty"`
 }
 
 // GatewayReference identifies the Gateway for routing
@@ -81,12 +115,37 @@ type EndpointSpec struct {
 	Type string `json:"type,omitempty"`
 
 	// Match defines how traffic is routed to this endpoint
-	Match MatchSpec `json:"match"`
+	// +optional
+	Match MatchSpec `json:"match,omitempty"`
+
+	// Matches lists several HTTP match blocks, any of which routes traffic
+	// to this endpoint. Mutually exclusive with Match.
+	// +kubebuilder:validation:MaxItems=64
+	// +optional
+	Matches []MatchSpec `json:"matches,omitempty"`
+
+	// Filters modify requests and responses on the endpoint's HTTP route.
+	// They only apply to requests sent to the endpoint, never to the main
+	// service share or to rules falling back to the main service.
+	// +kubebuilder:validation:MaxItems=16
+	// +optional
+	Filters []HTTPFilter `json:"filters,omitempty"`
+
+	// Timeouts bound the requests on the endpoint's HTTP route
+	// +optional
+	Timeouts *TimeoutsSpec `json:"timeouts,omitempty"`
+
+	// Retry retries failed requests on the endpoint's HTTP route
+	// +optional
+	Retry *RetrySpec `json:"retry,omitempty"`
 
 	// Strategy defines routing strategy:
 	// - "canary": split traffic (canaryWeight% to endpoint, rest to main)
 	// - "primary": 100% to endpoint (endpoint exclusively handles this path)
-	// +kubebuilder:validation:Enum=canary;primary
+	// - "progressive": canary whose weight follows Progressive.Steps
+	// - "shadow": 100% to main, with a mirrored copy sent to endpoint
+	// - "abtest": requests selected by ABTest to endpoint, the rest to main
+	// +kubebuilder:validation:Enum=canary;primary;progressive;shadow;abtest
 	// +kubebuilder:default=primary
 	Strategy string `json:"strategy,omitempty"`
 
@@ -98,6 +157,21 @@ type EndpointSpec struct {
 	// +optional
 	CanaryWeight *int32 `json:"canaryWeight,omitempty"`
 
+	// Progressive defines the step schedule and analysis for the
+	// "progressive" strategy
+	// +optional
+	Progressive *ProgressiveSpec `json:"progressive,omitempty"`
+
+	// Shadow limits the share of requests mirrored by the "shadow"
+	// strategy; all requests are mirrored when unset
+	// +optional
+	Shadow *ShadowSpec `json:"shadow,omitempty"`
+
+	// ABTest selects the requests routed to the endpoint by the "abtest"
+	// strategy
+	// +optional
+	ABTest *ABTestSpec `json:"abTest,omitempty"`
+
 	// Resources defines compute resources for this endpoint's deployment
 	// +optional
 	Resources *ResourceSpec `json:"resources,omitempty"`
@@ -106,25 +180,488 @@ type EndpointSpec struct {
 	// +optional
 	HPA *HPASpec `json:"hpa,omitempty"`
 
+	// Autoscaler selects the autoscaling backend. Without it, HPA (when
+	// set) is rendered as a native HorizontalPodAutoscaler.
+	// +optional
+	Autoscaler *AutoscalerSpec `json:"autoscaler,omitempty"`
+
+	// Template overrides the policy-level template for this endpoint,
+	// merged with strategic merge patch semantics
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Template *corev1.PodTemplateSpec `json:"template,omitempty"`
+
 	// Replicas is the desired number of replicas (ignored if HPA is set)
 	// +kubebuilder:default=1
 	// +optional
 	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Schedule lists time windows that override Replicas, or the autoscaler
+	// bounds, while they are active. The first active window wins.
+	// +kubebuilder:validation:MaxItems=16
+	// +optional
+	Schedule []ScheduleWindow `json:"schedule,omitempty"`
+
+	// BlueGreen releases pod template changes as a second Deployment and
+	// Service, which receive the traffic once fully available. Without it,
+	// changes roll out in place.
+	// +optional
+	BlueGreen *BlueGreenSpec `json:"blueGreen,omitempty"`
+}
+
+// BlueGreenSpec configures blue/green releases of an endpoint
+type BlueGreenSpec struct {
+	// ScaleDownDelay is how long the previous color is kept after a
+	// cutover, during which reverting the pod template switches back to it
+	// instantly (defaults to 10m)
+	// +optional
+	ScaleDownDelay *metav1.Duration `json:"scaleDownDelay,omitempty"`
+
+	// RevisionHistoryLimit is the number of releases kept in status
+	// (defaults to 10)
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+}
+
+// ScheduleWindow is a recurring time window with replica overrides
+type ScheduleWindow struct {
+	// Name identifies the window in status
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// Start is a five-field cron expression for when the window opens
+	// (e.g., "0 8 * * 1-5")
+	Start string `json:"start"`
+
+	// End is a five-field cron expression for when the window closes
+	// (e.g., "0 18 * * 1-5")
+	End string `json:"end"`
+
+	// Timezone is the IANA time zone Start and End are evaluated in
+	// (e.g., "Europe/Berlin"), defaulting to UTC
+	// +optional
+	Timezone string `json:"timezone,omitempty"`
+
+	// Replicas is the fixed replica count of an endpoint without an
+	// autoscaler while the window is active
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Min overrides the autoscaler's minimum replicas while the window is
+	// active
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Min *int32 `json:"min,omitempty"`
+
+	// Max overrides the autoscaler's maximum replicas while the window is
+	// active
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Max *int32 `json:"max,omitempty"`
 }
 
 // MatchSpec defines traffic matching rules
 type MatchSpec struct {
-	// Path for HTTP endpoints (prefix match)
+	// Path for HTTP endpoints
 	// +optional
 	Path string `json:"path,omitempty"`
 
+	// PathType is how Path is matched for HTTP endpoints:
+	// "Exact", "PathPrefix" or "RegularExpression"
+	// +kubebuilder:validation:Enum=Exact;PathPrefix;RegularExpression
+	// +kubebuilder:default=PathPrefix
+	// +optional
+	PathType string `json:"pathType,omitempty"`
+
 	// Service for gRPC endpoints (e.g., "payments.Payments")
 	// +optional
 	Service string `json:"service,omitempty"`
 
-	// Method for gRPC endpoints (e.g., "Authorize")
+	// Method for gRPC endpoints (e.g., "Authorize"), or the HTTP method
+	// for HTTP endpoints (e.g., "POST")
 	// +optional
 	Method string `json:"method,omitempty"`
+
+	// Headers that must all match for HTTP endpoints
+	// +kubebuilder:validation:MaxItems=16
+	// +optional
+	Headers []HeaderMatch `json:"headers,omitempty"`
+
+	// QueryParams that must all match for HTTP endpoints
+	// +kubebuilder:validation:MaxItems=16
+	// +optional
+	QueryParams []QueryParamMatch `json:"queryParams,omitempty"`
+}
+
+// HeaderMatch matches an HTTP request header
+type HeaderMatch struct {
+	// Type is "Exact" or "RegularExpression"
+	// +kubebuilder:validation:Enum=Exact;RegularExpression
+	// +kubebuilder:default=Exact
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// Name of the header (case-insensitive)
+	Name string `json:"name"`
+
+	// Value to match
+	Value string `json:"value"`
+}
+
+// QueryParamMatch matches an HTTP query parameter
+type QueryParamMatch struct {
+	// Type is "Exact" or "RegularExpression"
+	// +kubebuilder:validation:Enum=Exact;RegularExpression
+	// +kubebuilder:default=Exact
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// Name of the query parameter (case-sensitive)
+	Name string `json:"name"`
+
+	// Value to match
+	Value string `json:"value"`
+}
+
+// ShadowSpec sets the share of requests mirrored to a "shadow" endpoint
+type ShadowSpec struct {
+	// Percent of requests to mirror (0-100). Mutually exclusive with
+	// Fraction.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	Percent *int32 `json:"percent,omitempty"`
+
+	// Fraction of requests to mirror. Mutually exclusive with Percent.
+	// +optional
+	Fraction *MirrorFraction `json:"fraction,omitempty"`
+}
+
+// ABTestSpec selects the requests an "abtest" endpoint serves. A request
+// matching the endpoint's match is routed to the endpoint when it carries any
+// of the headers or cookies, and to the main service otherwise.
+type ABTestSpec struct {
+	// Headers are HTTP headers, or gRPC metadata, each of which selects the
+	// endpoint (e.g., X-Endpointscaler-Target: compute)
+	// +kubebuilder:validation:MaxItems=16
+	// +optional
+	Headers []HeaderMatch `json:"headers,omitempty"`
+
+	// Cookies are HTTP cookies, each of which selects the endpoint
+	// +kubebuilder:validation:MaxItems=16
+	// +optional
+	Cookies []CookieMatch `json:"cookies,omitempty"`
+}
+
+// CookieMatch matches a cookie by exact name and value
+type CookieMatch struct {
+	// Name of the cookie
+	Name string `json:"name"`
+
+	// Value of the cookie
+	Value string `json:"value"`
+}
+
+// TimeoutsSpec sets the Gateway API HTTPRoute timeouts of an endpoint. Both
+// are whole milliseconds; zero disables the timeout.
+type TimeoutsSpec struct {
+	// Request is the time the gateway waits to answer a client request,
+	// retries included (e.g., "10s")
+	// +optional
+	Request *metav1.Duration `json:"request,omitempty"`
+
+	// BackendRequest is the time the gateway waits for each request to a
+	// backend. It must not exceed Request.
+	// +optional
+	BackendRequest *metav1.Duration `json:"backendRequest,omitempty"`
+}
+
+// RetrySpec sets the Gateway API HTTPRoute retry policy of an endpoint
+type RetrySpec struct {
+	// Attempts is the maximum number of retries of a request (0 disables
+	// retries)
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Attempts *int32 `json:"attempts,omitempty"`
+
+	// Backoff is the minimum time between retries, in whole milliseconds
+	// (e.g., "100ms")
+	// +optional
+	Backoff *metav1.Duration `json:"backoff,omitempty"`
+
+	// Codes are the HTTP response status codes (400-599) that are retried
+	// +kubebuilder:validation:MaxItems=16
+	// +optional
+	Codes []int32 `json:"codes,omitempty"`
+}
+
+// HTTPFilter is a Gateway API HTTPRoute filter. Exactly the field named by
+// Type must be set.
+type HTTPFilter struct {
+	// Type is "URLRewrite", "RequestRedirect", "RequestHeaderModifier",
+	// "ResponseHeaderModifier", "RequestMirror" or "CORS"
+	// +kubebuilder:validation:Enum=URLRewrite;RequestRedirect;RequestHeaderModifier;ResponseHeaderModifier;RequestMirror;CORS
+	Type string `json:"type"`
+
+	// URLRewrite rewrites the hostname or path before forwarding
+	// +optional
+	URLRewrite *URLRewriteFilter `json:"urlRewrite,omitempty"`
+
+	// RequestRedirect answers with a redirect instead of forwarding
+	// +optional
+	RequestRedirect *RequestRedirectFilter `json:"requestRedirect,omitempty"`
+
+	// RequestHeaderModifier modifies request headers
+	// +optional
+	RequestHeaderModifier *HeaderModifierFilter `json:"requestHeaderModifier,omitempty"`
+
+	// ResponseHeaderModifier modifies response headers
+	// +optional
+	ResponseHeaderModifier *HeaderModifierFilter `json:"responseHeaderModifier,omitempty"`
+
+	// RequestMirror sends a copy of each request to another Service
+	// +optional
+	RequestMirror *RequestMirrorFilter `json:"requestMirror,omitempty"`
+
+	// CORS answers CORS preflight requests and adds CORS response headers
+	// +optional
+	CORS *CORSFilter `json:"cors,omitempty"`
+}
+
+// URLRewriteFilter rewrites the request before it is forwarded
+type URLRewriteFilter struct {
+	// Hostname replaces the Host header
+	// +optional
+	Hostname string `json:"hostname,omitempty"`
+
+	// Path replaces the request path
+	// +optional
+	Path *PathModifier `json:"path,omitempty"`
+}
+
+// RequestRedirectFilter redirects the request. Unset fields keep the value
+// of the original request.
+type RequestRedirectFilter struct {
+	// Scheme is "http" or "https"
+	// +kubebuilder:validation:Enum=http;https
+	// +optional
+	Scheme string `json:"scheme,omitempty"`
+
+	// Hostname of the redirect location
+	// +optional
+	Hostname string `json:"hostname,omitempty"`
+
+	// Path of the redirect location
+	// +optional
+	Path *PathModifier `json:"path,omitempty"`
+
+	// Port of the redirect location
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port *int32 `json:"port,omitempty"`
+
+	// StatusCode is 301 or 302
+	// +kubebuilder:validation:Enum=301;302
+	// +kubebuilder:default=302
+	// +optional
+	StatusCode *int32 `json:"statusCode,omitempty"`
+}
+
+// PathModifier replaces the request path
+type PathModifier struct {
+	// Type is "ReplacePrefixMatch" or "ReplaceFullPath"
+	// +kubebuilder:validation:Enum=ReplacePrefixMatch;ReplaceFullPath
+	Type string `json:"type"`
+
+	// ReplacePrefixMatch replaces the matched path prefix (e.g., "/api/v1/compute"
+	// with "/compute"); the match must be a single PathPrefix match
+	// +optional
+	ReplacePrefixMatch string `json:"replacePrefixMatch,omitempty"`
+
+	// ReplaceFullPath replaces the whole path
+	// +optional
+	ReplaceFullPath string `json:"replaceFullPath,omitempty"`
+}
+
+// HeaderModifierFilter sets, adds and removes HTTP headers
+type HeaderModifierFilter struct {
+	// Set overwrites headers
+	// +kubebuilder:validation:MaxItems=16
+	// +optional
+	Set []HTTPHeader `json:"set,omitempty"`
+
+	// Add appends to headers
+	// +kubebuilder:validation:MaxItems=16
+	// +optional
+	Add []HTTPHeader `json:"add,omitempty"`
+
+	// Remove deletes headers by name
+	// +kubebuilder:validation:MaxItems=16
+	// +optional
+	Remove []string `json:"remove,omitempty"`
+}
+
+// HTTPHeader is an HTTP header name and value
+type HTTPHeader struct {
+	// Name of the header (case-insensitive)
+	Name string `json:"name"`
+
+	// Value of the header
+	Value string `json:"value"`
+}
+
+// RequestMirrorFilter mirrors requests to a Service in the application
+// namespace. Responses from the mirror are discarded.
+type RequestMirrorFilter struct {
+	// ServiceName is the Service receiving the copies
+	ServiceName string `json:"serviceName"`
+
+	// Port of the Service (defaults to appRef.port)
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port *int32 `json:"port,omitempty"`
+
+	// Percent of requests to mirror (0-100, default all). Mutually
+	// exclusive with Fraction.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	Percent *int32 `json:"percent,omitempty"`
+
+	// Fraction of requests to mirror. Mutually exclusive with Percent.
+	// +optional
+	Fraction *MirrorFraction `json:"fraction,omitempty"`
+}
+
+// MirrorFraction is the share Numerator/Denominator of requests to mirror
+type MirrorFraction struct {
+	// +kubebuilder:validation:Minimum=0
+	Numerator int32 `json:"numerator"`
+
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=100
+	// +optional
+	Denominator *int32 `json:"denominator,omitempty"`
+}
+
+// CORSFilter configures Cross-Origin Resource Sharing for the route
+type CORSFilter struct {
+	// AllowOrigins lists the allowed origins (e.g., "https://app.example.com"),
+	// or "*" for any
+	// +kubebuilder:validation:MaxItems=64
+	// +optional
+	AllowOrigins []string `json:"allowOrigins,omitempty"`
+
+	// AllowCredentials allows requests with credentials
+	// +optional
+	AllowCredentials *bool `json:"allowCredentials,omitempty"`
+
+	// AllowMethods lists the allowed methods, or "*" for any
+	// +kubebuilder:validation:MaxItems=9
+	// +optional
+	AllowMethods []string `json:"allowMethods,omitempty"`
+
+	// AllowHeaders lists the allowed request headers, or "*" for any
+	// +kubebuilder:validation:MaxItems=64
+	// +optional
+	AllowHeaders []string `json:"allowHeaders,omitempty"`
+
+	// ExposeHeaders lists the response headers exposed to scripts
+	// +kubebuilder:validation:MaxItems=64
+	// +optional
+	ExposeHeaders []string `json:"exposeHeaders,omitempty"`
+
+	// MaxAge is how many seconds a preflight response may be cached
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxAge int32 `json:"maxAge,omitempty"`
+}
+
+// HTTPMatches returns the match blocks of an HTTP endpoint: Matches when
+// set, otherwise the single Match.
+func (e *EndpointSpec) HTTPMatches() []MatchSpec {
+	if len(e.Matches) > 0 {
+		return e.Matches
+	}
+	return []MatchSpec{e.Match}
+}
+
+// UsesKEDA reports whether a KEDA ScaledObject scales the endpoint.
+func (e *EndpointSpec) UsesKEDA() bool {
+	return e.Autoscaler != nil && e.Autoscaler.Kind == "keda"
+}
+
+// Autoscaled reports whether an HPA or a ScaledObject, rather than Replicas,
+// sets the endpoint's replica count.
+func (e *EndpointSpec) Autoscaled() bool {
+	return e.UsesKEDA() || e.HPA != nil
+}
+
+// ProgressiveSpec defines an automated canary rollout
+type ProgressiveSpec struct {
+	// Steps is the weight schedule, e.g. 5, 25, 50, 100
+	// +kubebuilder:validation:MinItems=1
+	Steps []CanaryStep `json:"steps"`
+
+	// Analysis defines the checks run while the rollout progresses
+	// +optional
+	Analysis *AnalysisSpec `json:"analysis,omitempty"`
+}
+
+// CanaryStep is a single step of a progressive rollout
+type CanaryStep struct {
+	// Weight is the percentage of traffic to endpoint (1-100)
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	Weight int32 `json:"weight"`
+
+	// Pause is how long to hold this step before moving to the next one
+	// +optional
+	Pause *metav1.Duration `json:"pause,omitempty"`
+}
+
+// AnalysisSpec defines metric checks against a Prometheus server
+type AnalysisSpec struct {
+	// PrometheusURL is the base URL of the Prometheus HTTP API
+	// (e.g., "http://prometheus.monitoring:9090")
+	PrometheusURL string `json:"prometheusURL"`
+
+	// Interval between analysis runs (defaults to 1m)
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// InconclusiveLimit is how many consecutive runs may be inconclusive,
+	// e.g. while the endpoint gets no traffic yet, before the rollout is
+	// rolled back (defaults to 5)
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	InconclusiveLimit *int32 `json:"inconclusiveLimit,omitempty"`
+
+	// Checks are evaluated on every run; any failure rolls back to 0%
+	// +kubebuilder:validation:MinItems=1
+	Checks []AnalysisCheck `json:"checks"`
+}
+
+// AnalysisCheck is a PromQL query whose single result must stay within bounds
+type AnalysisCheck struct {
+	// Name identifies the check in status messages
+	Name string `json:"name"`
+
+	// Query is a PromQL expression returning a scalar or single-sample vector
+	Query string `json:"query"`
+
+	// Min is the lowest acceptable value (e.g., "0.99")
+	// +optional
+	Min string `json:"min,omitempty"`
+
+	// Max is the highest acceptable value (e.g., "0.01")
+	// +optional
+	Max string `json:"max,omitempty"`
 }
 
 // ResourceSpec defines compute resource limits
@@ -168,6 +705,199 @@ type HPASpec struct {
 	// +kubebuilder:validation:Maximum=100
 	// +optional
 	MemoryTarget *int32 `json:"memoryTarget,omitempty"`
+
+	// Metrics are additional metrics to scale on, such as the endpoint's
+	// request rate or a queue depth
+	// +kubebuilder:validation:MaxItems=16
+	// +optional
+	Metrics []HPAMetric `json:"metrics,omitempty"`
+
+	// Behavior configures the scale-up and scale-down rates; Kubernetes
+	// defaults apply to any direction left unset
+	// +optional
+	Behavior *HPABehavior `json:"behavior,omitempty"`
+}
+
+// HPABehavior configures the scaling rates of an HPA
+type HPABehavior struct {
+	// ScaleUp are the rules for scaling up
+	// +optional
+	ScaleUp *HPAScalingRules `json:"scaleUp,omitempty"`
+
+	// ScaleDown are the rules for scaling down
+	// +optional
+	ScaleDown *HPAScalingRules `json:"scaleDown,omitempty"`
+}
+
+// HPAScalingRules configures scaling in one direction
+type HPAScalingRules struct {
+	// StabilizationWindowSeconds is how far back recommendations are
+	// considered, so that a brief dip or spike does not rescale (0-3600)
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=3600
+	// +optional
+	StabilizationWindowSeconds *int32 `json:"stabilizationWindowSeconds,omitempty"`
+
+	// SelectPolicy picks among Policies: "Max" (default) allows the largest
+	// change, "Min" the smallest, and "Disabled" turns this direction off
+	// +kubebuilder:validation:Enum=Max;Min;Disabled
+	// +optional
+	SelectPolicy string `json:"selectPolicy,omitempty"`
+
+	// Policies limit how much the replica count may change per period
+	// +kubebuilder:validation:MaxItems=10
+	// +optional
+	Policies []HPAScalingPolicy `json:"policies,omitempty"`
+}
+
+// HPAScalingPolicy limits the replica change over a period
+type HPAScalingPolicy struct {
+	// Type is "Pods" (an absolute number of pods) or "Percent" (a
+	// percentage of the current replicas)
+	// +kubebuilder:validation:Enum=Pods;Percent
+	Type string `json:"type"`
+
+	// Value is the number of pods or the percentage
+	// +kubebuilder:validation:Minimum=1
+	Value int32 `json:"value"`
+
+	// PeriodSeconds is the window the policy applies to (1-1800)
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=1800
+	PeriodSeconds int32 `json:"periodSeconds"`
+}
+
+// HPAMetric is a Pods, Object, External or ContainerResource metric. Exactly
+// one of AverageValue, Value or AverageUtilization sets the target.
+type HPAMetric struct {
+	// Type is "Pods", "Object", "External" or "ContainerResource"
+	// +kubebuilder:validation:Enum=Pods;Object;External;ContainerResource
+	Type string `json:"type"`
+
+	// Name is the metric name, or the resource name ("cpu" or "memory") for
+	// ContainerResource metrics
+	Name string `json:"name"`
+
+	// Selector narrows the metric series by label for Pods, Object and
+	// External metrics
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// DescribedObject is the object in the app namespace that an Object
+	// metric describes (e.g., an Ingress or a queue custom resource)
+	// +optional
+	DescribedObject *MetricObjectReference `json:"describedObject,omitempty"`
+
+	// Container is the container of a ContainerResource metric, defaulting
+	// to the endpoint container
+	// +optional
+	Container string `json:"container,omitempty"`
+
+	// AverageValue is the target value averaged across pods (e.g., "100")
+	// +optional
+	AverageValue string `json:"averageValue,omitempty"`
+
+	// Value is the target value of an Object or External metric
+	// +optional
+	Value string `json:"value,omitempty"`
+
+	// AverageUtilization is the target utilization percentage of a
+	// ContainerResource metric
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	AverageUtilization *int32 `json:"averageUtilization,omitempty"`
+}
+
+// AutoscalerSpec selects and configures the autoscaling backend
+type AutoscalerSpec struct {
+	// Kind is "hpa" (a native HPA configured by the endpoint's hpa field)
+	// or "keda" (a KEDA ScaledObject configured by Keda)
+	// +kubebuilder:validation:Enum=hpa;keda
+	// +kubebuilder:default=hpa
+	Kind string `json:"kind,omitempty"`
+
+	// Keda configures the ScaledObject when Kind is "keda"
+	// +optional
+	Keda *KEDASpec `json:"keda,omitempty"`
+}
+
+// KEDASpec configures a keda.sh/v1alpha1 ScaledObject for an endpoint
+type KEDASpec struct {
+	// MinReplicaCount is the minimum number of replicas; 0 lets the
+	// endpoint scale to zero while its triggers are idle
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=0
+	// +optional
+	MinReplicaCount *int32 `json:"minReplicaCount,omitempty"`
+
+	// MaxReplicaCount is the maximum number of replicas
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicaCount int32 `json:"maxReplicaCount"`
+
+	// PollingInterval is how often KEDA checks the triggers, in seconds
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	PollingInterval *int32 `json:"pollingInterval,omitempty"`
+
+	// CooldownPeriod is how long the triggers must be idle before scaling
+	// to zero, in seconds
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	CooldownPeriod *int32 `json:"cooldownPeriod,omitempty"`
+
+	// Triggers are the KEDA scalers that drive the endpoint
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=16
+	Triggers []KEDATrigger `json:"triggers"`
+}
+
+// KEDATrigger is a KEDA scaler, passed through to the ScaledObject
+type KEDATrigger struct {
+	// Type is the scaler type (e.g., "prometheus", "rabbitmq", "cron")
+	Type string `json:"type"`
+
+	// Name identifies the trigger in KEDA's status and metrics
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Metadata is the scaler configuration
+	// +optional
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// MetricType is "AverageValue" (default), "Value" or "Utilization"
+	// +kubebuilder:validation:Enum=AverageValue;Value;Utilization
+	// +optional
+	MetricType string `json:"metricType,omitempty"`
+
+	// AuthenticationRef names a TriggerAuthentication in the app namespace,
+	// or a ClusterTriggerAuthentication
+	// +optional
+	AuthenticationRef *KEDAAuthenticationRef `json:"authenticationRef,omitempty"`
+}
+
+// KEDAAuthenticationRef references KEDA trigger credentials
+type KEDAAuthenticationRef struct {
+	// Name of the TriggerAuthentication
+	Name string `json:"name"`
+
+	// Kind is "TriggerAuthentication" (default) or
+	// "ClusterTriggerAuthentication"
+	// +kubebuilder:validation:Enum=TriggerAuthentication;ClusterTriggerAuthentication
+	// +optional
+	Kind string `json:"kind,omitempty"`
+}
+
+// MetricObjectReference identifies the object an Object metric describes
+type MetricObjectReference struct {
+	// APIVersion of the object (e.g., "networking.k8s.io/v1")
+	// +optional
+	APIVersion string `json:"apiVersion,omitempty"`
+
+	// Kind of the object
+	Kind string `json:"kind"`
+
+	// Name of the object
+	Name string `json:"name"`
 }
 
 // EndpointPolicyStatus defines the observed state
@@ -201,8 +931,161 @@ type EndpointStatus struct {
 	// RouteName is the name of the created HTTPRoute/GRPCRoute
 	RouteName string `json:"routeName,omitempty"`
 
+	// AvailableReplicas is the number of available pods of the endpoint
+	// Deployment
+	// +optional
+	AvailableReplicas int32 `json:"availableReplicas,omitempty"`
+
+	// ActiveSchedule is the name of the schedule window currently
+	// overriding the endpoint's replicas
+	// +optional
+	ActiveSchedule string `json:"activeSchedule,omitempty"`
+
+	// ScaledToZero is true while the endpoint Deployment has no ready
+	// replicas and the route sends all of its traffic to the main service
+	// +optional
+	ScaledToZero bool `json:"scaledToZero,omitempty"`
+
+	// Reason is a machine-readable explanation for why the endpoint is not
+	// ready (e.g., "RouteConflict", "DeploymentUnavailable")
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
 	// Message contains additional status information
 	Message string `json:"message,omitempty"`
+
+	// RouteRejections lists the route conditions the gateway reported as
+	// not met
+	// +optional
+	RouteRejections []RouteRejection `json:"routeRejections,omitempty"`
+
+	// Conditions report the endpoint Deployment's availability
+	// ("DeploymentAvailable"), the gateway's acceptance of the endpoint
+	// route ("RouteAccepted"), the scale-to-zero fallback ("ScaledToZero")
+	// and the gateway's support for the route timeouts and retries
+	// ("RouteFeaturesSupported")
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Progressive tracks the rollout of a "progressive" endpoint
+	// +optional
+	Progressive *ProgressiveStatus `json:"progressive,omitempty"`
+
+	// BlueGreen tracks the colors and releases of a blue/green endpoint
+	// +optional
+	BlueGreen *BlueGreenStatus `json:"blueGreen,omitempty"`
+}
+
+// RouteRejection is a route condition of the policy's gateway that is not
+// True
+type RouteRejection struct {
+	// Type is the route condition type ("Accepted" or "ResolvedRefs")
+	Type string `json:"type"`
+
+	// Reason is the gateway's reason (e.g., "NotAllowedByListeners")
+	Reason string `json:"reason,omitempty"`
+
+	// Message is the gateway's message
+	Message string `json:"message,omitempty"`
+}
+
+// Progressive rollout phases
+const (
+	ProgressivePhaseProgressing = "Progressing"
+	ProgressivePhaseSucceeded   = "Succeeded"
+	ProgressivePhaseRolledBack  = "RolledBack"
+)
+
+// ProgressiveStatus is the persisted state of a progressive rollout
+type ProgressiveStatus struct {
+	// Revision identifies the endpoint pod template being rolled out.
+	// A new revision restarts the rollout from the first step.
+	Revision string `json:"revision,omitempty"`
+
+	// Phase is Progressing, Succeeded or RolledBack
+	Phase string `json:"phase"`
+
+	// Step is the index of the current step
+	Step int32 `json:"step"`
+
+	// Weight is the percentage of traffic currently sent to the endpoint
+	Weight int32 `json:"weight"`
+
+	// StepStartedAt is when the current step began
+	// +optional
+	StepStartedAt *metav1.Time `json:"stepStartedAt,omitempty"`
+
+	// LastAnalysisAt is when the analysis last ran. It runs again once
+	// Analysis.Interval has elapsed.
+	// +optional
+	LastAnalysisAt *metav1.Time `json:"lastAnalysisAt,omitempty"`
+
+	// InconclusiveRuns counts the consecutive inconclusive analysis runs
+	// +optional
+	InconclusiveRuns int32 `json:"inconclusiveRuns,omitempty"`
+
+	// Message describes the last analysis result
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// Blue/green colors
+const (
+	ColorBlue  = "blue"
+	ColorGreen = "green"
+)
+
+// BlueGreenStatus is the persisted state of a blue/green endpoint
+type BlueGreenStatus struct {
+	// ActiveColor is the color the route sends traffic to
+	ActiveColor string `json:"activeColor"`
+
+	// ActiveRevision identifies the pod template of the active color
+	ActiveRevision string `json:"activeRevision"`
+
+	// PreviewColor runs PreviewRevision until it is fully available and
+	// the route is switched to it
+	// +optional
+	PreviewColor string `json:"previewColor,omitempty"`
+
+	// PreviewRevision identifies the pod template being released
+	// +optional
+	PreviewRevision string `json:"previewRevision,omitempty"`
+
+	// PreviousColor is the color replaced by the last cutover, kept until
+	// ScaleDownAt for instant rollback
+	// +optional
+	PreviousColor string `json:"previousColor,omitempty"`
+
+	// PreviousRevision identifies the pod template of the previous color
+	// +optional
+	PreviousRevision string `json:"previousRevision,omitempty"`
+
+	// ScaleDownAt is when the previous color is removed
+	// +optional
+	ScaleDownAt *metav1.Time `json:"scaleDownAt,omitempty"`
+
+	// History lists the releases that received traffic, most recent first
+	// +optional
+	History []BlueGreenRevision `json:"history,omitempty"`
+}
+
+// BlueGreenRevision is a release of a blue/green endpoint
+type BlueGreenRevision struct {
+	// Revision identifies the released pod template
+	Revision string `json:"revision"`
+
+	// Color is the color the revision ran as
+	Color string `json:"color"`
+
+	// Image is the image of the endpoint container
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// PromotedAt is when the route was switched to the revision
+	PromotedAt metav1.Time `json:"promotedAt"`
 }
 
 // +kubebuilder:object:root=true
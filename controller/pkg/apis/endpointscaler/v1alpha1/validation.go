@@ -1,15 +1,34 @@
 package v1alpha1
 
 import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	utilvalidation "k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/example/endpoint-scaler/controller/pkg/cron"
 )
 
 // Validate validates the EndpointPolicySpec and returns nil if valid,
 // or an aggregate error containing all validation failures.
 func (s *EndpointPolicySpec) Validate() error {
-	allErrs := s.validate(field.NewPath("spec"))
-	return allErrs.ToAggregate()
+	return s.ValidateFields().ToAggregate()
+}
+
+// ValidateFields returns every validation failure of the spec, with paths
+// rooted at "spec".
+func (s *EndpointPolicySpec) ValidateFields() field.ErrorList {
+	return s.validate(field.NewPath("spec"))
 }
 
 func (s *EndpointPolicySpec) validate(fldPath *field.Path) field.ErrorList {
@@ -17,6 +36,7 @@ func (s *EndpointPolicySpec) validate(fldPath *field.Path) field.ErrorList {
 
 	allErrs = append(allErrs, s.AppRef.validate(fldPath.Child("appRef"))...)
 	allErrs = append(allErrs, s.GatewayRef.validate(fldPath.Child("gatewayRef"))...)
+	allErrs = append(allErrs, validatePodTemplate(s.Template, fldPath.Child("template"))...)
 	allErrs = append(allErrs, validateEndpoints(s.Endpoints, fldPath.Child("endpoints"))...)
 
 	return allErrs
@@ -28,8 +48,12 @@ func (a *AppReference) validate(fldPath *field.Path) field.ErrorList {
 	if a.Name == "" {
 		allErrs = append(allErrs, field.Required(fldPath.Child("name"), "application name is required"))
 	}
-	if a.Image == "" {
-		allErrs = append(allErrs, field.Required(fldPath.Child("image"), "container image is required"))
+	if a.DeploymentRef != nil {
+		if a.DeploymentRef.Name == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("deploymentRef", "name"), "deployment name is required"))
+		}
+	} else if a.Image == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("image"), "container image is required unless deploymentRef is set"))
 	}
 	if a.Port < 0 {
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("port"), a.Port, "must be a positive integer"))
@@ -81,7 +105,17 @@ func (e *EndpointSpec) validate(fldPath *field.Path) field.ErrorList {
 		allErrs = append(allErrs, field.Required(fldPath.Child("id"), "endpoint id is required"))
 	}
 
-	allErrs = append(allErrs, e.validateMatch(fldPath.Child("match"))...)
+	allErrs = append(allErrs, e.validateMatch(fldPath)...)
+	allErrs = append(allErrs, e.validateFilters(fldPath)...)
+	if e.Timeouts != nil {
+		allErrs = append(allErrs, e.validateTimeouts(fldPath.Child("timeouts"))...)
+	}
+	if e.Retry != nil {
+		allErrs = append(allErrs, e.validateRetry(fldPath.Child("retry"))...)
+	}
+	allErrs = append(allErrs, e.validateStrategy(fldPath)...)
+
+	allErrs = append(allErrs, validatePodTemplate(e.Template, fldPath.Child("template"))...)
 
 	if e.Replicas != nil && *e.Replicas < 1 {
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("replicas"), *e.Replicas, "must be at least 1"))
@@ -95,11 +129,262 @@ func (e *EndpointSpec) validate(fldPath *field.Path) field.ErrorList {
 		allErrs = append(allErrs, e.HPA.validate(fldPath.Child("hpa"))...)
 	}
 
+	if e.Autoscaler != nil {
+		allErrs = append(allErrs, e.validateAutoscaler(fldPath)...)
+	}
+
+	allErrs = append(allErrs, e.validateSchedule(fldPath.Child("schedule"))...)
+
+	if e.BlueGreen != nil {
+		allErrs = append(allErrs, e.validateBlueGreen(fldPath.Child("blueGreen"))...)
+	}
+
+	return allErrs
+}
+
+func (e *EndpointSpec) validateBlueGreen(fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	b := e.BlueGreen
+
+	// A progressive rollout already gates new pod templates on the canary
+	// weight, and restarts whenever the template changes
+	if e.Strategy == "progressive" {
+		allErrs = append(allErrs, field.Forbidden(fldPath, "not allowed with the progressive strategy"))
+	}
+	if b.ScaleDownDelay != nil && b.ScaleDownDelay.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("scaleDownDelay"), b.ScaleDownDelay.Duration.String(), "must not be negative"))
+	}
+	if b.RevisionHistoryLimit != nil && (*b.RevisionHistoryLimit < 1 || *b.RevisionHistoryLimit > 100) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("revisionHistoryLimit"), *b.RevisionHistoryLimit, "must be between 1 and 100"))
+	}
+
+	return allErrs
+}
+
+// validateTimeouts enforces the HTTPRoute rules on timeouts: GRPCRoute has
+// none, and a backend request must fit in the request timeout unless that is
+// disabled.
+func (e *EndpointSpec) validateTimeouts(fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	t := e.Timeouts
+
+	if e.Type == "grpc" {
+		return append(allErrs, field.Forbidden(fldPath, "only allowed for HTTP endpoints"))
+	}
+	allErrs = append(allErrs, validateRouteDuration(t.Request, fldPath.Child("request"))...)
+	allErrs = append(allErrs, validateRouteDuration(t.BackendRequest, fldPath.Child("backendRequest"))...)
+	if t.Request != nil && t.BackendRequest != nil && t.Request.Duration > 0 && t.BackendRequest.Duration > t.Request.Duration {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("backendRequest"), t.BackendRequest.Duration.String(), "must not exceed timeouts.request"))
+	}
+
+	return allErrs
+}
+
+func (e *EndpointSpec) validateRetry(fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	r := e.Retry
+
+	if e.Type == "grpc" {
+		return append(allErrs, field.Forbidden(fldPath, "only allowed for HTTP endpoints"))
+	}
+	if r.Attempts != nil && *r.Attempts < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("attempts"), *r.Attempts, "must not be negative"))
+	}
+	allErrs = append(allErrs, validateRouteDuration(r.Backoff, fldPath.Child("backoff"))...)
+
+	codes := sets.New[int32]()
+	for i, code := range r.Codes {
+		codePath := fldPath.Child("codes").Index(i)
+		if code < 400 || code > 599 {
+			allErrs = append(allErrs, field.Invalid(codePath, code, "must be between 400 and 599"))
+		} else if codes.Has(code) {
+			allErrs = append(allErrs, field.Duplicate(codePath, code))
+		}
+		codes.Insert(code)
+	}
+
+	return allErrs
+}
+
+// validateRouteDuration checks that d can be written as a Gateway API
+// duration, which has millisecond precision.
+func validateRouteDuration(d *metav1.Duration, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	switch {
+	case d == nil:
+	case d.Duration < 0:
+		allErrs = append(allErrs, field.Invalid(fldPath, d.Duration.String(), "must not be negative"))
+	case d.Duration%time.Millisecond != 0:
+		allErrs = append(allErrs, field.Invalid(fldPath, d.Duration.String(), "must be a whole number of milliseconds"))
+	}
+	return allErrs
+}
+
+func (e *EndpointSpec) validateSchedule(fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	names := sets.New[string]()
+	for i := range e.Schedule {
+		w := &e.Schedule[i]
+		windowPath := fldPath.Index(i)
+
+		if w.Name == "" {
+			allErrs = append(allErrs, field.Required(windowPath.Child("name"), "window name is required"))
+		} else if names.Has(w.Name) {
+			allErrs = append(allErrs, field.Duplicate(windowPath.Child("name"), w.Name))
+		}
+		names.Insert(w.Name)
+
+		if _, err := cron.Parse(w.Start); err != nil {
+			allErrs = append(allErrs, field.Invalid(windowPath.Child("start"), w.Start, err.Error()))
+		}
+		if _, err := cron.Parse(w.End); err != nil {
+			allErrs = append(allErrs, field.Invalid(windowPath.Child("end"), w.End, err.Error()))
+		}
+		if w.Timezone != "" {
+			if _, err := time.LoadLocation(w.Timezone); err != nil {
+				allErrs = append(allErrs, field.Invalid(windowPath.Child("timezone"), w.Timezone, "unknown time zone"))
+			}
+		}
+
+		if !e.Autoscaled() {
+			if w.Replicas == nil {
+				allErrs = append(allErrs, field.Required(windowPath.Child("replicas"), "required for endpoints without an autoscaler"))
+			} else if *w.Replicas < 0 {
+				allErrs = append(allErrs, field.Invalid(windowPath.Child("replicas"), *w.Replicas, "must not be negative"))
+			}
+			if w.Min != nil || w.Max != nil {
+				allErrs = append(allErrs, field.Forbidden(windowPath, "min and max are only allowed for endpoints with an autoscaler"))
+			}
+			continue
+		}
+
+		if w.Replicas != nil {
+			allErrs = append(allErrs, field.Forbidden(windowPath.Child("replicas"), "not allowed for endpoints with an autoscaler, use min and max"))
+		}
+		if w.Min == nil && w.Max == nil {
+			allErrs = append(allErrs, field.Required(windowPath, "at least one of min or max is required"))
+			continue
+		}
+
+		// The window bounds must be valid together with the bounds they
+		// leave unchanged
+		minReplicas, maxReplicas, lowest := int32(0), int32(0), int32(0)
+		if e.UsesKEDA() {
+			if k := e.Autoscaler.Keda; k != nil {
+				if k.MinReplicaCount != nil {
+					minReplicas = *k.MinReplicaCount
+				}
+				maxReplicas = k.MaxReplicaCount
+			}
+		} else {
+			minReplicas, maxReplicas, lowest = e.HPA.Min, e.HPA.Max, 1
+		}
+		if w.Min != nil {
+			minReplicas = *w.Min
+			if minReplicas < lowest {
+				allErrs = append(allErrs, field.Invalid(windowPath.Child("min"), minReplicas, fmt.Sprintf("must be at least %d", lowest)))
+			}
+		}
+		if w.Max != nil {
+			maxReplicas = *w.Max
+			if maxReplicas < 1 {
+				allErrs = append(allErrs, field.Invalid(windowPath.Child("max"), maxReplicas, "must be at least 1"))
+			}
+		}
+		if maxReplicas < minReplicas {
+			allErrs = append(allErrs, field.Invalid(windowPath, fmt.Sprintf("min %d, max %d", minReplicas, maxReplicas),
+				"max must be greater than or equal to min while the window is active"))
+		}
+	}
+
+	return allErrs
+}
+
+func (e *EndpointSpec) validateAutoscaler(endpointPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	fldPath := endpointPath.Child("autoscaler")
+
+	switch e.Autoscaler.Kind {
+	case "", "hpa":
+		if e.HPA == nil {
+			allErrs = append(allErrs, field.Required(endpointPath.Child("hpa"), "required when autoscaler.kind is hpa"))
+		}
+		if e.Autoscaler.Keda != nil {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("keda"), "only allowed when kind is keda"))
+		}
+	case "keda":
+		if e.HPA != nil {
+			allErrs = append(allErrs, field.Forbidden(endpointPath.Child("hpa"), "not allowed when autoscaler.kind is keda"))
+		}
+		if e.Autoscaler.Keda == nil {
+			allErrs = append(allErrs, field.Required(fldPath.Child("keda"), "required when kind is keda"))
+		} else {
+			allErrs = append(allErrs, e.Autoscaler.Keda.validate(fldPath.Child("keda"))...)
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("kind"), e.Autoscaler.Kind, []string{"hpa", "keda"}))
+	}
+
+	return allErrs
+}
+
+func (k *KEDASpec) validate(fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	minReplicas := int32(0)
+	if k.MinReplicaCount != nil {
+		minReplicas = *k.MinReplicaCount
+	}
+	if minReplicas < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("minReplicaCount"), minReplicas, "must not be negative"))
+	}
+	if k.MaxReplicaCount < 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxReplicaCount"), k.MaxReplicaCount, "must be at least 1"))
+	} else if k.MaxReplicaCount < minReplicas {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxReplicaCount"), k.MaxReplicaCount, "must be greater than or equal to minReplicaCount"))
+	}
+	if k.PollingInterval != nil && *k.PollingInterval < 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("pollingInterval"), *k.PollingInterval, "must be at least 1"))
+	}
+	if k.CooldownPeriod != nil && *k.CooldownPeriod < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("cooldownPeriod"), *k.CooldownPeriod, "must not be negative"))
+	}
+
+	if len(k.Triggers) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("triggers"), "at least one trigger is required"))
+	}
+	for i, t := range k.Triggers {
+		triggerPath := fldPath.Child("triggers").Index(i)
+		if t.Type == "" {
+			allErrs = append(allErrs, field.Required(triggerPath.Child("type"), "trigger type is required"))
+		}
+		switch t.MetricType {
+		case "", "AverageValue", "Value", "Utilization":
+		default:
+			allErrs = append(allErrs, field.NotSupported(triggerPath.Child("metricType"), t.MetricType,
+				[]string{"AverageValue", "Value", "Utilization"}))
+		}
+		if ref := t.AuthenticationRef; ref != nil {
+			if ref.Name == "" {
+				allErrs = append(allErrs, field.Required(triggerPath.Child("authenticationRef", "name"), "name is required"))
+			}
+			switch ref.Kind {
+			case "", "TriggerAuthentication", "ClusterTriggerAuthentication":
+			default:
+				allErrs = append(allErrs, field.NotSupported(triggerPath.Child("authenticationRef", "kind"), ref.Kind,
+					[]string{"TriggerAuthentication", "ClusterTriggerAuthentication"}))
+			}
+		}
+	}
+
 	return allErrs
 }
 
-func (e *EndpointSpec) validateMatch(fldPath *field.Path) field.ErrorList {
+func (e *EndpointSpec) validateMatch(endpointPath *field.Path) field.ErrorList {
 	var allErrs field.ErrorList
+	fldPath := endpointPath.Child("match")
+	matchesPath := endpointPath.Child("matches")
 
 	epType := e.Type
 	if epType == "" {
@@ -108,8 +393,18 @@ func (e *EndpointSpec) validateMatch(fldPath *field.Path) field.ErrorList {
 
 	switch epType {
 	case "http":
-		if e.Match.Path == "" {
-			allErrs = append(allErrs, field.Required(fldPath.Child("path"), "path is required for HTTP endpoints"))
+		if len(e.Matches) == 0 {
+			allErrs = append(allErrs, e.Match.validateHTTP(fldPath)...)
+			break
+		}
+		if !e.Match.isEmpty() {
+			allErrs = append(allErrs, field.Forbidden(fldPath, "match and matches are mutually exclusive"))
+		}
+		if len(e.Matches) > maxHTTPMatches {
+			allErrs = append(allErrs, field.TooMany(matchesPath, len(e.Matches), maxHTTPMatches))
+		}
+		for i := range e.Matches {
+			allErrs = append(allErrs, e.Matches[i].validateHTTP(matchesPath.Index(i))...)
 		}
 	case "grpc":
 		if e.Match.Service == "" {
@@ -118,6 +413,682 @@ func (e *EndpointSpec) validateMatch(fldPath *field.Path) field.ErrorList {
 		if e.Match.Method == "" {
 			allErrs = append(allErrs, field.Required(fldPath.Child("method"), "method is required for gRPC endpoints"))
 		}
+		if e.Match.PathType != "" {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("pathType"), "only allowed for HTTP endpoints"))
+		}
+		if len(e.Match.Headers) > 0 {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("headers"), "only allowed for HTTP endpoints"))
+		}
+		if len(e.Match.QueryParams) > 0 {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("queryParams"), "only allowed for HTTP endpoints"))
+		}
+		if len(e.Matches) > 0 {
+			allErrs = append(allErrs, field.Forbidden(matchesPath, "only allowed for HTTP endpoints"))
+		}
+	}
+
+	return allErrs
+}
+
+// Limits and formats enforced by the Gateway API HTTPRoute schema.
+const (
+	maxHTTPMatches     = 64
+	maxMatchConditions = 16
+	maxPathLength      = 1024
+	maxNameLength      = 256
+	maxValueLength     = 4096
+)
+
+var (
+	httpMethods = sets.New("GET", "HEAD", "POST", "PUT", "DELETE", "CONNECT", "OPTIONS", "TRACE", "PATCH")
+
+	// httpTokenRegexp matches header and query parameter names (RFC 7230 tokens).
+	httpTokenRegexp = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+\\-.^_`|~]+$")
+
+	// invalidPathSequences may not appear in Exact or PathPrefix paths.
+	invalidPathSequences = []string{"//", "/./", "/../", "%2f", "%2F", "#"}
+)
+
+func (m *MatchSpec) isEmpty() bool {
+	return m.Path == "" && m.PathType == "" && m.Service == "" && m.Method == "" &&
+		len(m.Headers) == 0 && len(m.QueryParams) == 0
+}
+
+func (m *MatchSpec) validateHTTP(fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if m.Path == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("path"), "path is required for HTTP endpoints"))
+	} else {
+		allErrs = append(allErrs, validateHTTPPath(m.Path, m.PathType, fldPath)...)
+	}
+
+	if m.Service != "" {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("service"), "only allowed for gRPC endpoints"))
+	}
+	if m.Method != "" && !httpMethods.Has(m.Method) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("method"), m.Method, sets.List(httpMethods)))
+	}
+
+	if len(m.Headers) > maxMatchConditions {
+		allErrs = append(allErrs, field.TooMany(fldPath.Child("headers"), len(m.Headers), maxMatchConditions))
+	}
+	seenHeaders := make(map[string]bool)
+	for i, h := range m.Headers {
+		hPath := fldPath.Child("headers").Index(i)
+		allErrs = append(allErrs, validateValueMatch(h.Type, h.Name, h.Value, hPath)...)
+		// Header names are case-insensitive
+		name := strings.ToLower(h.Name)
+		if seenHeaders[name] {
+			allErrs = append(allErrs, field.Duplicate(hPath.Child("name"), h.Name))
+		}
+		seenHeaders[name] = true
+	}
+
+	if len(m.QueryParams) > maxMatchConditions {
+		allErrs = append(allErrs, field.TooMany(fldPath.Child("queryParams"), len(m.QueryParams), maxMatchConditions))
+	}
+	seenParams := make(map[string]bool)
+	for i, q := range m.QueryParams {
+		qPath := fldPath.Child("queryParams").Index(i)
+		allErrs = append(allErrs, validateValueMatch(q.Type, q.Name, q.Value, qPath)...)
+		if seenParams[q.Name] {
+			allErrs = append(allErrs, field.Duplicate(qPath.Child("name"), q.Name))
+		}
+		seenParams[q.Name] = true
+	}
+
+	return allErrs
+}
+
+func validateHTTPPath(path, pathType string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if len(path) > maxPathLength {
+		allErrs = append(allErrs, field.TooLong(fldPath.Child("path"), path, maxPathLength))
+	}
+
+	switch pathType {
+	case "", "Exact", "PathPrefix":
+		if !strings.HasPrefix(path, "/") {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("path"), path, "must be an absolute path"))
+		}
+		for _, seq := range invalidPathSequences {
+			if strings.Contains(path, seq) {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("path"), path, fmt.Sprintf("must not contain %q", seq)))
+			}
+		}
+		if strings.HasSuffix(path, "/..") || strings.HasSuffix(path, "/.") {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("path"), path, "must not end with \"/..\" or \"/.\""))
+		}
+	case "RegularExpression":
+		if _, err := regexp.Compile(path); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("path"), path, "invalid regular expression: "+err.Error()))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("pathType"), pathType, []string{"Exact", "PathPrefix", "RegularExpression"}))
+	}
+
+	return allErrs
+}
+
+// validateValueMatch validates a header or query parameter match.
+func validateValueMatch(matchType, name, value string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), "name is required"))
+	} else if len(name) > maxNameLength {
+		allErrs = append(allErrs, field.TooLong(fldPath.Child("name"), name, maxNameLength))
+	} else if !httpTokenRegexp.MatchString(name) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("name"), name, "must be a valid HTTP token"))
+	}
+
+	if value == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("value"), "value is required"))
+	} else if len(value) > maxValueLength {
+		allErrs = append(allErrs, field.TooLong(fldPath.Child("value"), value, maxValueLength))
+	}
+
+	switch matchType {
+	case "", "Exact":
+	case "RegularExpression":
+		if _, err := regexp.Compile(value); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("value"), value, "invalid regular expression: "+err.Error()))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("type"), matchType, []string{"Exact", "RegularExpression"}))
+	}
+
+	return allErrs
+}
+
+var (
+	filterTypes = []string{"URLRewrite", "RequestRedirect", "RequestHeaderModifier", "ResponseHeaderModifier", "RequestMirror", "CORS"}
+
+	pathModifierTypes = []string{"ReplacePrefixMatch", "ReplaceFullPath"}
+)
+
+// validateFilters enforces the HTTPRoute rules on filters: each filter
+// carries exactly the configuration named by its type, only RequestMirror
+// may be repeated, and RequestRedirect excludes URLRewrite and backends.
+func (e *EndpointSpec) validateFilters(endpointPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	fldPath := endpointPath.Child("filters")
+
+	if len(e.Filters) == 0 {
+		return allErrs
+	}
+	if e.Type == "grpc" {
+		return append(allErrs, field.Forbidden(fldPath, "only allowed for HTTP endpoints"))
+	}
+
+	seen := sets.New[string]()
+	for i := range e.Filters {
+		f := &e.Filters[i]
+		filterPath := fldPath.Index(i)
+
+		configs := map[string]bool{
+			"URLRewrite":             f.URLRewrite != nil,
+			"RequestRedirect":        f.RequestRedirect != nil,
+			"RequestHeaderModifier":  f.RequestHeaderModifier != nil,
+			"ResponseHeaderModifier": f.ResponseHeaderModifier != nil,
+			"RequestMirror":          f.RequestMirror != nil,
+			"CORS":                   f.CORS != nil,
+		}
+		if _, ok := configs[f.Type]; !ok {
+			allErrs = append(allErrs, field.NotSupported(filterPath.Child("type"), f.Type, filterTypes))
+			continue
+		}
+		for _, t := range filterTypes {
+			childPath := filterPath.Child(filterField(t))
+			if t == f.Type && !configs[t] {
+				allErrs = append(allErrs, field.Required(childPath, fmt.Sprintf("required for a %s filter", t)))
+			} else if t != f.Type && configs[t] {
+				allErrs = append(allErrs, field.Forbidden(childPath, fmt.Sprintf("only allowed for a %s filter", t)))
+			}
+		}
+
+		if f.Type != "RequestMirror" && seen.Has(f.Type) {
+			allErrs = append(allErrs, field.Duplicate(filterPath.Child("type"), f.Type))
+		}
+		seen.Insert(f.Type)
+
+		switch {
+		case f.Type == "URLRewrite" && f.URLRewrite != nil:
+			allErrs = append(allErrs, validateFilterHostname(f.URLRewrite.Hostname, filterPath.Child("urlRewrite", "hostname"))...)
+			allErrs = append(allErrs, e.validatePathModifier(f.URLRewrite.Path, filterPath.Child("urlRewrite", "path"))...)
+		case f.Type == "RequestRedirect" && f.RequestRedirect != nil:
+			allErrs = append(allErrs, e.validateRedirect(f.RequestRedirect, filterPath.Child("requestRedirect"))...)
+		case f.Type == "RequestHeaderModifier" && f.RequestHeaderModifier != nil:
+			allErrs = append(allErrs, f.RequestHeaderModifier.validate(filterPath.Child("requestHeaderModifier"))...)
+		case f.Type == "ResponseHeaderModifier" && f.ResponseHeaderModifier != nil:
+			allErrs = append(allErrs, f.ResponseHeaderModifier.validate(filterPath.Child("responseHeaderModifier"))...)
+		case f.Type == "RequestMirror" && f.RequestMirror != nil:
+			allErrs = append(allErrs, f.RequestMirror.validate(filterPath.Child("requestMirror"))...)
+		case f.Type == "CORS" && f.CORS != nil:
+			allErrs = append(allErrs, f.CORS.validate(filterPath.Child("cors"))...)
+		}
+	}
+
+	if seen.Has("RequestRedirect") {
+		if seen.Has("URLRewrite") {
+			allErrs = append(allErrs, field.Forbidden(fldPath, "RequestRedirect and URLRewrite filters are mutually exclusive"))
+		}
+		// A redirecting route has no backends to split traffic between
+		if e.Strategy != "" && e.Strategy != "primary" {
+			allErrs = append(allErrs, field.Forbidden(fldPath, "a RequestRedirect filter is only allowed with the primary strategy"))
+		}
+	}
+
+	return allErrs
+}
+
+// filterField returns the JSON field holding the configuration of a filter
+// type.
+func filterField(filterType string) string {
+	if filterType == "CORS" {
+		return "cors"
+	}
+	if filterType == "URLRewrite" {
+		return "urlRewrite"
+	}
+	return strings.ToLower(filterType[:1]) + filterType[1:]
+}
+
+func validateFilterHostname(hostname string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if hostname == "" {
+		return allErrs
+	}
+	for _, msg := range utilvalidation.IsDNS1123Subdomain(hostname) {
+		allErrs = append(allErrs, field.Invalid(fldPath, hostname, msg))
+	}
+	return allErrs
+}
+
+func (e *EndpointSpec) validatePathModifier(m *PathModifier, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if m == nil {
+		return allErrs
+	}
+
+	var value string
+	switch m.Type {
+	case "ReplacePrefixMatch":
+		value = m.ReplacePrefixMatch
+		if m.ReplaceFullPath != "" {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("replaceFullPath"), "only allowed for type ReplaceFullPath"))
+		}
+		if value == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("replacePrefixMatch"), "required for type ReplacePrefixMatch"))
+		}
+		// The prefix being replaced is the match path
+		matches := e.HTTPMatches()
+		if len(matches) != 1 || (matches[0].PathType != "" && matches[0].PathType != "PathPrefix") {
+			allErrs = append(allErrs, field.Forbidden(fldPath, "ReplacePrefixMatch requires exactly one PathPrefix match"))
+		}
+	case "ReplaceFullPath":
+		value = m.ReplaceFullPath
+		if m.ReplacePrefixMatch != "" {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("replacePrefixMatch"), "only allowed for type ReplacePrefixMatch"))
+		}
+		if value == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("replaceFullPath"), "required for type ReplaceFullPath"))
+		}
+	default:
+		return append(allErrs, field.NotSupported(fldPath.Child("type"), m.Type, pathModifierTypes))
+	}
+
+	if value != "" {
+		valuePath := fldPath.Child(filterField(m.Type))
+		if !strings.HasPrefix(value, "/") {
+			allErrs = append(allErrs, field.Invalid(valuePath, value, "must be an absolute path"))
+		}
+		if len(value) > maxPathLength {
+			allErrs = append(allErrs, field.TooLong(valuePath, value, maxPathLength))
+		}
+	}
+
+	return allErrs
+}
+
+func (e *EndpointSpec) validateRedirect(r *RequestRedirectFilter, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if r.Scheme != "" && r.Scheme != "http" && r.Scheme != "https" {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("scheme"), r.Scheme, []string{"http", "https"}))
+	}
+	allErrs = append(allErrs, validateFilterHostname(r.Hostname, fldPath.Child("hostname"))...)
+	allErrs = append(allErrs, e.validatePathModifier(r.Path, fldPath.Child("path"))...)
+	if r.Port != nil && (*r.Port < 1 || *r.Port > 65535) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("port"), *r.Port, "must be between 1 and 65535"))
+	}
+	if r.StatusCode != nil && *r.StatusCode != 301 && *r.StatusCode != 302 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("statusCode"), *r.StatusCode, "must be 301 or 302"))
+	}
+
+	return allErrs
+}
+
+func (h *HeaderModifierFilter) validate(fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if len(h.Set) == 0 && len(h.Add) == 0 && len(h.Remove) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath, "at least one of set, add or remove is required"))
+	}
+
+	for _, list := range []struct {
+		name    string
+		headers []HTTPHeader
+	}{{"set", h.Set}, {"add", h.Add}} {
+		listPath := fldPath.Child(list.name)
+		if len(list.headers) > maxMatchConditions {
+			allErrs = append(allErrs, field.TooMany(listPath, len(list.headers), maxMatchConditions))
+		}
+		seen := sets.New[string]()
+		for i, header := range list.headers {
+			hPath := listPath.Index(i)
+			allErrs = append(allErrs, validateHeaderName(header.Name, hPath.Child("name"))...)
+			if len(header.Value) > maxValueLength {
+				allErrs = append(allErrs, field.TooLong(hPath.Child("value"), header.Value, maxValueLength))
+			}
+			// Header names are case-insensitive
+			name := strings.ToLower(header.Name)
+			if seen.Has(name) {
+				allErrs = append(allErrs, field.Duplicate(hPath.Child("name"), header.Name))
+			}
+			seen.Insert(name)
+		}
+	}
+
+	removePath := fldPath.Child("remove")
+	if len(h.Remove) > maxMatchConditions {
+		allErrs = append(allErrs, field.TooMany(removePath, len(h.Remove), maxMatchConditions))
+	}
+	removed := sets.New[string]()
+	for i, name := range h.Remove {
+		allErrs = append(allErrs, validateHeaderName(name, removePath.Index(i))...)
+		if removed.Has(strings.ToLower(name)) {
+			allErrs = append(allErrs, field.Duplicate(removePath.Index(i), name))
+		}
+		removed.Insert(strings.ToLower(name))
+	}
+
+	return allErrs
+}
+
+func validateHeaderName(name string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if name == "" {
+		allErrs = append(allErrs, field.Required(fldPath, "name is required"))
+	} else if len(name) > maxNameLength {
+		allErrs = append(allErrs, field.TooLong(fldPath, name, maxNameLength))
+	} else if !httpTokenRegexp.MatchString(name) {
+		allErrs = append(allErrs, field.Invalid(fldPath, name, "must be a valid HTTP token"))
+	}
+	return allErrs
+}
+
+func (m *RequestMirrorFilter) validate(fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if m.ServiceName == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("serviceName"), "serviceName is required"))
+	} else {
+		for _, msg := range utilvalidation.IsDNS1035Label(m.ServiceName) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("serviceName"), m.ServiceName, msg))
+		}
+	}
+	if m.Port != nil && (*m.Port < 1 || *m.Port > 65535) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("port"), *m.Port, "must be between 1 and 65535"))
+	}
+	allErrs = append(allErrs, validateMirrorShare(m.Percent, m.Fraction, fldPath)...)
+
+	return allErrs
+}
+
+// validateMirrorShare validates the percentage or fraction of requests to
+// mirror.
+func validateMirrorShare(percent *int32, fraction *MirrorFraction, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if percent != nil && fraction != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath, "percent and fraction are mutually exclusive"))
+	}
+	if percent != nil && (*percent < 0 || *percent > 100) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("percent"), *percent, "must be between 0 and 100"))
+	}
+	if fraction != nil {
+		denominator := int32(100)
+		if fraction.Denominator != nil {
+			denominator = *fraction.Denominator
+		}
+		if denominator < 1 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("fraction", "denominator"), denominator, "must be at least 1"))
+		}
+		if fraction.Numerator < 0 || fraction.Numerator > denominator {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("fraction", "numerator"), fraction.Numerator,
+				"must be between 0 and the denominator"))
+		}
+	}
+
+	return allErrs
+}
+
+func (c *CORSFilter) validate(fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	originsPath := fldPath.Child("allowOrigins")
+	allErrs = append(allErrs, validateWildcardList(c.AllowOrigins, originsPath)...)
+	for i, origin := range c.AllowOrigins {
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			allErrs = append(allErrs, field.Invalid(originsPath.Index(i), origin, "must be \"*\" or a scheme and host, e.g. \"https://app.example.com\""))
+		}
+	}
+
+	methodsPath := fldPath.Child("allowMethods")
+	allErrs = append(allErrs, validateWildcardList(c.AllowMethods, methodsPath)...)
+	for i, method := range c.AllowMethods {
+		if method != "*" && !httpMethods.Has(method) {
+			allErrs = append(allErrs, field.NotSupported(methodsPath.Index(i), method, append(sets.List(httpMethods), "*")))
+		}
+	}
+
+	headersPath := fldPath.Child("allowHeaders")
+	allErrs = append(allErrs, validateWildcardList(c.AllowHeaders, headersPath)...)
+	for i, name := range c.AllowHeaders {
+		if name != "*" {
+			allErrs = append(allErrs, validateHeaderName(name, headersPath.Index(i))...)
+		}
+	}
+	for i, name := range c.ExposeHeaders {
+		allErrs = append(allErrs, validateHeaderName(name, fldPath.Child("exposeHeaders").Index(i))...)
+	}
+
+	if c.MaxAge < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxAge"), c.MaxAge, "must be at least 1"))
+	}
+
+	return allErrs
+}
+
+// validateWildcardList rejects "*" alongside other values.
+func validateWildcardList(values []string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if len(values) > 1 && slices.Contains(values, "*") {
+		allErrs = append(allErrs, field.Invalid(fldPath, strings.Join(values, ", "), "\"*\" must not be combined with other values"))
+	}
+	return allErrs
+}
+
+func (e *EndpointSpec) validateStrategy(fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if e.Strategy == "progressive" {
+		if e.Progressive == nil {
+			allErrs = append(allErrs, field.Required(fldPath.Child("progressive"), "progressive is required for the progressive strategy"))
+		} else {
+			allErrs = append(allErrs, e.Progressive.validate(fldPath.Child("progressive"))...)
+		}
+	} else if e.Progressive != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("progressive"), "only allowed with the progressive strategy"))
+	}
+
+	if e.Shadow != nil {
+		if e.Strategy != "shadow" {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("shadow"), "only allowed with the shadow strategy"))
+		} else {
+			allErrs = append(allErrs, validateMirrorShare(e.Shadow.Percent, e.Shadow.Fraction, fldPath.Child("shadow"))...)
+		}
+	}
+
+	if e.Strategy == "abtest" {
+		if e.ABTest == nil {
+			allErrs = append(allErrs, field.Required(fldPath.Child("abTest"), "abTest is required for the abtest strategy"))
+		} else {
+			allErrs = append(allErrs, e.validateABTest(fldPath.Child("abTest"))...)
+		}
+	} else if e.ABTest != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("abTest"), "only allowed with the abtest strategy"))
+	}
+
+	return allErrs
+}
+
+// maxRouteMatches is the Gateway API limit on matches across all rules of a
+// route.
+const maxRouteMatches = 128
+
+func (e *EndpointSpec) validateABTest(fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	a := e.ABTest
+
+	conditions := len(a.Headers) + len(a.Cookies)
+	if conditions == 0 {
+		allErrs = append(allErrs, field.Required(fldPath, "at least one header or cookie is required"))
+	}
+
+	headersPath := fldPath.Child("headers")
+	if len(a.Headers) > maxMatchConditions {
+		allErrs = append(allErrs, field.TooMany(headersPath, len(a.Headers), maxMatchConditions))
+	}
+	for i, h := range a.Headers {
+		allErrs = append(allErrs, validateValueMatch(h.Type, h.Name, h.Value, headersPath.Index(i))...)
+	}
+
+	cookiesPath := fldPath.Child("cookies")
+	if len(a.Cookies) > 0 && e.Type == "grpc" {
+		allErrs = append(allErrs, field.Forbidden(cookiesPath, "only allowed for HTTP endpoints"))
+	}
+	if len(a.Cookies) > maxMatchConditions {
+		allErrs = append(allErrs, field.TooMany(cookiesPath, len(a.Cookies), maxMatchConditions))
+	}
+	for i, c := range a.Cookies {
+		cookiePath := cookiesPath.Index(i)
+		if c.Name == "" {
+			allErrs = append(allErrs, field.Required(cookiePath.Child("name"), "name is required"))
+		} else if !httpTokenRegexp.MatchString(c.Name) {
+			allErrs = append(allErrs, field.Invalid(cookiePath.Child("name"), c.Name, "must be a valid HTTP token"))
+		}
+		if c.Value == "" {
+			allErrs = append(allErrs, field.Required(cookiePath.Child("value"), "value is required"))
+		} else if strings.ContainsAny(c.Value, " \t\";,\\") {
+			allErrs = append(allErrs, field.Invalid(cookiePath.Child("value"), c.Value, "must not contain whitespace, quotes, commas, semicolons or backslashes"))
+		}
+	}
+
+	// Every match is repeated once per condition in the endpoint rule and
+	// once more in the main service rule
+	if e.Type != "grpc" {
+		matches := e.HTTPMatches()
+		if total := len(matches) * (conditions + 1); total > maxRouteMatches {
+			allErrs = append(allErrs, field.Invalid(fldPath, total,
+				fmt.Sprintf("the matches times the headers and cookies, plus the matches, must not exceed %d route matches", maxRouteMatches)))
+		}
+		for i := range matches {
+			if len(matches[i].Headers) >= maxMatchConditions {
+				allErrs = append(allErrs, field.Forbidden(fldPath,
+					fmt.Sprintf("match %d already has %d headers, leaving no room for the abtest condition", i, len(matches[i].Headers))))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+func (p *ProgressiveSpec) validate(fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if len(p.Steps) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("steps"), "at least one step is required"))
+	}
+
+	var prev int32
+	for i, step := range p.Steps {
+		stepPath := fldPath.Child("steps").Index(i)
+		if step.Weight < 1 || step.Weight > 100 {
+			allErrs = append(allErrs, field.Invalid(stepPath.Child("weight"), step.Weight, "must be between 1 and 100"))
+		} else if step.Weight < prev {
+			allErrs = append(allErrs, field.Invalid(stepPath.Child("weight"), step.Weight, "must not be lower than the previous step"))
+		}
+		prev = step.Weight
+		if step.Pause != nil && step.Pause.Duration < 0 {
+			allErrs = append(allErrs, field.Invalid(stepPath.Child("pause"), step.Pause.Duration.String(), "must not be negative"))
+		}
+	}
+
+	if p.Analysis != nil {
+		allErrs = append(allErrs, p.Analysis.validate(fldPath.Child("analysis"))...)
+	}
+
+	return allErrs
+}
+
+func (a *AnalysisSpec) validate(fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if a.PrometheusURL == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("prometheusURL"), "prometheus URL is required"))
+	} else if u, err := url.Parse(a.PrometheusURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("prometheusURL"), a.PrometheusURL, "must be an absolute http or https URL"))
+	}
+
+	if a.Interval != nil && a.Interval.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("interval"), a.Interval.Duration.String(), "must be positive"))
+	}
+
+	if a.InconclusiveLimit != nil && *a.InconclusiveLimit < 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("inconclusiveLimit"), *a.InconclusiveLimit, "must be at least 1"))
+	}
+
+	if len(a.Checks) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("checks"), "at least one check is required"))
+	}
+
+	seen := make(map[string]bool)
+	for i, check := range a.Checks {
+		checkPath := fldPath.Child("checks").Index(i)
+		if check.Name == "" {
+			allErrs = append(allErrs, field.Required(checkPath.Child("name"), "check name is required"))
+		} else if seen[check.Name] {
+			allErrs = append(allErrs, field.Duplicate(checkPath.Child("name"), check.Name))
+		}
+		seen[check.Name] = true
+		if check.Query == "" {
+			allErrs = append(allErrs, field.Required(checkPath.Child("query"), "query is required"))
+		}
+		if check.Min == "" && check.Max == "" {
+			allErrs = append(allErrs, field.Required(checkPath, "at least one of min or max is required"))
+		}
+		minVal, minErr := parseThreshold(check.Min)
+		if minErr != nil {
+			allErrs = append(allErrs, field.Invalid(checkPath.Child("min"), check.Min, "must be a number"))
+		}
+		maxVal, maxErr := parseThreshold(check.Max)
+		if maxErr != nil {
+			allErrs = append(allErrs, field.Invalid(checkPath.Child("max"), check.Max, "must be a number"))
+		}
+		if minErr == nil && maxErr == nil && check.Min != "" && check.Max != "" && minVal > maxVal {
+			allErrs = append(allErrs, field.Invalid(checkPath.Child("max"), check.Max, "must be greater than or equal to min"))
+		}
+	}
+
+	return allErrs
+}
+
+func parseThreshold(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// validatePodTemplate checks what the strategic merge relies on: containers
+// are merged by name, so every container needs a unique one. The rest of the
+// template is validated by the API server when the Deployment is applied.
+func validatePodTemplate(template *corev1.PodTemplateSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if template == nil {
+		return allErrs
+	}
+
+	containersPath := fldPath.Child("spec", "containers")
+	seen := make(map[string]bool)
+	for i, container := range template.Spec.Containers {
+		if container.Name == "" {
+			allErrs = append(allErrs, field.Required(containersPath.Index(i).Child("name"), "container name is required"))
+			continue
+		}
+		if seen[container.Name] {
+			allErrs = append(allErrs, field.Duplicate(containersPath.Index(i).Child("name"), container.Name))
+		}
+		seen[container.Name] = true
 	}
 
 	return allErrs
@@ -163,8 +1134,117 @@ func (h *HPASpec) validate(fldPath *field.Path) field.ErrorList {
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("max"), h.Max, "must be greater than or equal to min"))
 	}
 
-	if h.CPUTarget == nil && h.MemoryTarget == nil {
-		allErrs = append(allErrs, field.Required(fldPath, "at least one of cpuTarget or memoryTarget is required"))
+	if h.CPUTarget == nil && h.MemoryTarget == nil && len(h.Metrics) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath, "at least one of cpuTarget, memoryTarget or metrics is required"))
+	}
+
+	for i := range h.Metrics {
+		allErrs = append(allErrs, h.Metrics[i].validate(fldPath.Child("metrics").Index(i))...)
+	}
+
+	if h.Behavior != nil {
+		allErrs = append(allErrs, h.Behavior.ScaleUp.validate(fldPath.Child("behavior", "scaleUp"))...)
+		allErrs = append(allErrs, h.Behavior.ScaleDown.validate(fldPath.Child("behavior", "scaleDown"))...)
+	}
+
+	return allErrs
+}
+
+func (r *HPAScalingRules) validate(fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if r == nil {
+		return allErrs
+	}
+
+	if w := r.StabilizationWindowSeconds; w != nil && (*w < 0 || *w > 3600) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("stabilizationWindowSeconds"), *w, "must be between 0 and 3600"))
+	}
+
+	switch r.SelectPolicy {
+	case "", "Max", "Min", "Disabled":
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("selectPolicy"), r.SelectPolicy, []string{"Max", "Min", "Disabled"}))
+	}
+
+	for i, p := range r.Policies {
+		policyPath := fldPath.Child("policies").Index(i)
+		if p.Type != "Pods" && p.Type != "Percent" {
+			allErrs = append(allErrs, field.NotSupported(policyPath.Child("type"), p.Type, []string{"Pods", "Percent"}))
+		}
+		if p.Value < 1 {
+			allErrs = append(allErrs, field.Invalid(policyPath.Child("value"), p.Value, "must be at least 1"))
+		}
+		if p.PeriodSeconds < 1 || p.PeriodSeconds > 1800 {
+			allErrs = append(allErrs, field.Invalid(policyPath.Child("periodSeconds"), p.PeriodSeconds, "must be between 1 and 1800"))
+		}
+	}
+
+	return allErrs
+}
+
+func (m *HPAMetric) validate(fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if m.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), "metric name is required"))
+	}
+
+	allErrs = append(allErrs, validateResourceQuantity(m.AverageValue, fldPath.Child("averageValue"))...)
+	allErrs = append(allErrs, validateResourceQuantity(m.Value, fldPath.Child("value"))...)
+
+	targets := 0
+	for _, set := range []bool{m.AverageValue != "", m.Value != "", m.AverageUtilization != nil} {
+		if set {
+			targets++
+		}
+	}
+	if targets != 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath, targets,
+			"exactly one of averageValue, value or averageUtilization is required"))
+	}
+
+	if m.Type != "Object" && m.DescribedObject != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("describedObject"), "only allowed for Object metrics"))
+	}
+	if m.Type != "ContainerResource" && m.Container != "" {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("container"), "only allowed for ContainerResource metrics"))
+	}
+	if m.Type != "ContainerResource" && m.AverageUtilization != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("averageUtilization"), "only allowed for ContainerResource metrics"))
+	}
+
+	switch m.Type {
+	case "Pods":
+		if m.Value != "" {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("value"), "Pods metrics only support averageValue"))
+		}
+	case "Object":
+		if m.DescribedObject == nil {
+			allErrs = append(allErrs, field.Required(fldPath.Child("describedObject"), "required for Object metrics"))
+		} else {
+			if m.DescribedObject.Kind == "" {
+				allErrs = append(allErrs, field.Required(fldPath.Child("describedObject", "kind"), "kind is required"))
+			}
+			if m.DescribedObject.Name == "" {
+				allErrs = append(allErrs, field.Required(fldPath.Child("describedObject", "name"), "name is required"))
+			}
+		}
+	case "External":
+		// Either value or averageValue, checked above
+	case "ContainerResource":
+		if m.Name != "" && m.Name != string(corev1.ResourceCPU) && m.Name != string(corev1.ResourceMemory) {
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("name"), m.Name, []string{"cpu", "memory"}))
+		}
+		if m.Value != "" {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("value"), "ContainerResource metrics only support averageValue or averageUtilization"))
+		}
+		if m.Selector != nil {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("selector"), "not allowed for ContainerResource metrics"))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("type"), m.Type,
+			[]string{"Pods", "Object", "External", "ContainerResource"}))
 	}
 
 	return allErrs
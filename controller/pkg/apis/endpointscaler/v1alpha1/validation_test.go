@@ -1,8 +1,13 @@
 package v1alpha1
 
 import (
+	"fmt"
 	"strings"
 	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestValidate_ValidSpec(t *testing.T) {
@@ -373,6 +378,247 @@ func TestValidate_HPAValid(t *testing.T) {
 	}
 }
 
+func TestValidate_HPACustomMetricsOnly(t *testing.T) {
+	spec := &EndpointPolicySpec{
+		AppRef:     AppReference{Name: "my-app", Image: "img:v1"},
+		GatewayRef: GatewayReference{Name: "gw"},
+		Endpoints: []EndpointSpec{
+			{
+				ID:    "ep1",
+				Type:  "http",
+				Match: MatchSpec{Path: "/api"},
+				HPA: &HPASpec{
+					Min: 1,
+					Max: 10,
+					Metrics: []HPAMetric{
+						{Type: "Pods", Name: "http_requests_per_second", AverageValue: "100"},
+						{Type: "External", Name: "queue_depth", Value: "30"},
+					},
+				},
+			},
+		},
+	}
+
+	if err := spec.Validate(); err != nil {
+		t.Errorf("expected HPA with only custom metrics to be valid, got error: %v", err)
+	}
+}
+
+func TestValidate_HPAInvalidMetrics(t *testing.T) {
+	utilization := int32(50)
+	tests := []struct {
+		name   string
+		metric HPAMetric
+		want   string
+	}{
+		{"missing name", HPAMetric{Type: "Pods", AverageValue: "1"}, "metrics[0].name"},
+		{"unknown type", HPAMetric{Type: "Resource", Name: "cpu", AverageValue: "1"}, "metrics[0].type"},
+		{"no target", HPAMetric{Type: "External", Name: "queue_depth"}, "exactly one of"},
+		{"two targets", HPAMetric{Type: "External", Name: "queue_depth", Value: "1", AverageValue: "1"}, "exactly one of"},
+		{"invalid quantity", HPAMetric{Type: "Pods", Name: "rps", AverageValue: "lots"}, "metrics[0].averageValue"},
+		{"pods value", HPAMetric{Type: "Pods", Name: "rps", Value: "1"}, "metrics[0].value"},
+		{"object without describedObject", HPAMetric{Type: "Object", Name: "rps", Value: "1"}, "metrics[0].describedObject"},
+		{"utilization on external", HPAMetric{Type: "External", Name: "lag", AverageUtilization: &utilization}, "metrics[0].averageUtilization"},
+		{"container resource name", HPAMetric{Type: "ContainerResource", Name: "gpu", AverageUtilization: &utilization}, "metrics[0].name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &EndpointPolicySpec{
+				AppRef:     AppReference{Name: "my-app", Image: "img:v1"},
+				GatewayRef: GatewayReference{Name: "gw"},
+				Endpoints: []EndpointSpec{
+					{
+						ID:    "ep1",
+						Type:  "http",
+						Match: MatchSpec{Path: "/api"},
+						HPA:   &HPASpec{Min: 1, Max: 10, Metrics: []HPAMetric{tt.metric}},
+					},
+				},
+			}
+
+			err := spec.Validate()
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestValidate_HPABehavior(t *testing.T) {
+	cpu := int32(80)
+	window := int32(300)
+	tooLong := int32(7200)
+	tests := []struct {
+		name     string
+		behavior *HPABehavior
+		want     string
+	}{
+		{
+			name: "valid",
+			behavior: &HPABehavior{
+				ScaleDown: &HPAScalingRules{
+					StabilizationWindowSeconds: &window,
+					SelectPolicy:               "Min",
+					Policies:                   []HPAScalingPolicy{{Type: "Percent", Value: 50, PeriodSeconds: 60}},
+				},
+				ScaleUp: &HPAScalingRules{SelectPolicy: "Disabled"},
+			},
+		},
+		{
+			name:     "window too long",
+			behavior: &HPABehavior{ScaleDown: &HPAScalingRules{StabilizationWindowSeconds: &tooLong}},
+			want:     "behavior.scaleDown.stabilizationWindowSeconds",
+		},
+		{
+			name:     "unknown select policy",
+			behavior: &HPABehavior{ScaleUp: &HPAScalingRules{SelectPolicy: "Average"}},
+			want:     "behavior.scaleUp.selectPolicy",
+		},
+		{
+			name:     "unknown policy type",
+			behavior: &HPABehavior{ScaleUp: &HPAScalingRules{Policies: []HPAScalingPolicy{{Type: "Replicas", Value: 1, PeriodSeconds: 60}}}},
+			want:     "behavior.scaleUp.policies[0].type",
+		},
+		{
+			name:     "zero value",
+			behavior: &HPABehavior{ScaleUp: &HPAScalingRules{Policies: []HPAScalingPolicy{{Type: "Pods", PeriodSeconds: 60}}}},
+			want:     "behavior.scaleUp.policies[0].value",
+		},
+		{
+			name:     "period too long",
+			behavior: &HPABehavior{ScaleDown: &HPAScalingRules{Policies: []HPAScalingPolicy{{Type: "Pods", Value: 1, PeriodSeconds: 3600}}}},
+			want:     "behavior.scaleDown.policies[0].periodSeconds",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &EndpointPolicySpec{
+				AppRef:     AppReference{Name: "my-app", Image: "img:v1"},
+				GatewayRef: GatewayReference{Name: "gw"},
+				Endpoints: []EndpointSpec{
+					{
+						ID:    "ep1",
+						Type:  "http",
+						Match: MatchSpec{Path: "/api"},
+						HPA:   &HPASpec{Min: 1, Max: 10, CPUTarget: &cpu, Behavior: tt.behavior},
+					},
+				},
+			}
+
+			err := spec.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("expected valid behavior, got error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestValidate_Autoscaler(t *testing.T) {
+	cpu := int32(80)
+	zero := int32(0)
+	three := int32(3)
+	keda := func(k KEDASpec) *AutoscalerSpec { return &AutoscalerSpec{Kind: "keda", Keda: &k} }
+	cron := []KEDATrigger{{Type: "cron", Metadata: map[string]string{"timezone": "UTC"}}}
+	tests := []struct {
+		name       string
+		autoscaler *AutoscalerSpec
+		hpa        *HPASpec
+		want       string
+	}{
+		{
+			name:       "keda scale to zero",
+			autoscaler: keda(KEDASpec{MinReplicaCount: &zero, MaxReplicaCount: 3, Triggers: cron}),
+		},
+		{
+			name:       "hpa kind",
+			autoscaler: &AutoscalerSpec{Kind: "hpa"},
+			hpa:        &HPASpec{Min: 1, Max: 3, CPUTarget: &cpu},
+		},
+		{
+			name:       "hpa kind without hpa",
+			autoscaler: &AutoscalerSpec{Kind: "hpa"},
+			want:       "hpa: Required",
+		},
+		{
+			name:       "keda with hpa",
+			autoscaler: keda(KEDASpec{MaxReplicaCount: 3, Triggers: cron}),
+			hpa:        &HPASpec{Min: 1, Max: 3, CPUTarget: &cpu},
+			want:       "spec.endpoints[0].hpa",
+		},
+		{
+			name:       "keda without config",
+			autoscaler: &AutoscalerSpec{Kind: "keda"},
+			want:       "autoscaler.keda",
+		},
+		{
+			name:       "keda without triggers",
+			autoscaler: keda(KEDASpec{MaxReplicaCount: 3}),
+			want:       "autoscaler.keda.triggers",
+		},
+		{
+			name:       "keda max below min",
+			autoscaler: keda(KEDASpec{MinReplicaCount: &three, MaxReplicaCount: 2, Triggers: cron}),
+			want:       "autoscaler.keda.maxReplicaCount",
+		},
+		{
+			name:       "keda trigger without type",
+			autoscaler: keda(KEDASpec{MaxReplicaCount: 3, Triggers: []KEDATrigger{{Metadata: map[string]string{"a": "b"}}}}),
+			want:       "autoscaler.keda.triggers[0].type",
+		},
+		{
+			name:       "unknown kind",
+			autoscaler: &AutoscalerSpec{Kind: "vpa"},
+			want:       "autoscaler.kind",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &EndpointPolicySpec{
+				AppRef:     AppReference{Name: "my-app", Image: "img:v1"},
+				GatewayRef: GatewayReference{Name: "gw"},
+				Endpoints: []EndpointSpec{
+					{
+						ID:         "ep1",
+						Type:       "http",
+						Match:      MatchSpec{Path: "/api"},
+						HPA:        tt.hpa,
+						Autoscaler: tt.autoscaler,
+					},
+				},
+			}
+
+			err := spec.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("expected valid autoscaler, got error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
 func TestValidate_DefaultTypeIsHTTP(t *testing.T) {
 	spec := &EndpointPolicySpec{
 		AppRef:     AppReference{Name: "my-app", Image: "img:v1"},
@@ -427,3 +673,914 @@ func TestValidate_MultipleEndpoints(t *testing.T) {
 		t.Errorf("expected valid multi-endpoint spec, got error: %v", err)
 	}
 }
+
+func TestValidate_Progressive(t *testing.T) {
+	zero := int32(0)
+	validAnalysis := func() *AnalysisSpec {
+		return &AnalysisSpec{
+			PrometheusURL: "http://prometheus:9090",
+			Checks:        []AnalysisCheck{{Name: "errors", Query: "vector(0)", Max: "0.05"}},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		strategy    string
+		progressive *ProgressiveSpec
+		wantErr     string
+	}{
+		{
+			name:        "valid",
+			strategy:    "progressive",
+			progressive: &ProgressiveSpec{Steps: []CanaryStep{{Weight: 5}, {Weight: 50}, {Weight: 100}}, Analysis: validAnalysis()},
+		},
+		{
+			name:     "missing progressive",
+			strategy: "progressive",
+			wantErr:  "progressive",
+		},
+		{
+			name:        "no steps",
+			strategy:    "progressive",
+			progressive: &ProgressiveSpec{},
+			wantErr:     "progressive.steps",
+		},
+		{
+			name:        "decreasing weights",
+			strategy:    "progressive",
+			progressive: &ProgressiveSpec{Steps: []CanaryStep{{Weight: 50}, {Weight: 10}}},
+			wantErr:     "steps[1].weight",
+		},
+		{
+			name:        "weight out of range",
+			strategy:    "progressive",
+			progressive: &ProgressiveSpec{Steps: []CanaryStep{{Weight: 0}}},
+			wantErr:     "steps[0].weight",
+		},
+		{
+			name:     "relative prometheus URL",
+			strategy: "progressive",
+			progressive: &ProgressiveSpec{
+				Steps: []CanaryStep{{Weight: 10}},
+				Analysis: &AnalysisSpec{
+					PrometheusURL: "prometheus:9090",
+					Checks:        []AnalysisCheck{{Name: "errors", Query: "vector(0)", Max: "1"}},
+				},
+			},
+			wantErr: "prometheusURL",
+		},
+		{
+			name:     "check without bounds",
+			strategy: "progressive",
+			progressive: &ProgressiveSpec{
+				Steps: []CanaryStep{{Weight: 10}},
+				Analysis: &AnalysisSpec{
+					PrometheusURL: "http://prometheus:9090",
+					Checks:        []AnalysisCheck{{Name: "errors", Query: "vector(0)"}},
+				},
+			},
+			wantErr: "min or max",
+		},
+		{
+			name:     "non-numeric threshold",
+			strategy: "progressive",
+			progressive: &ProgressiveSpec{
+				Steps: []CanaryStep{{Weight: 10}},
+				Analysis: &AnalysisSpec{
+					PrometheusURL: "http://prometheus:9090",
+					Checks:        []AnalysisCheck{{Name: "errors", Query: "vector(0)", Max: "five"}},
+				},
+			},
+			wantErr: "checks[0].max",
+		},
+		{
+			name:     "zero inconclusive limit",
+			strategy: "progressive",
+			progressive: &ProgressiveSpec{
+				Steps: []CanaryStep{{Weight: 10}},
+				Analysis: &AnalysisSpec{
+					PrometheusURL:     "http://prometheus:9090",
+					InconclusiveLimit: &zero,
+					Checks:            []AnalysisCheck{{Name: "errors", Query: "vector(0)", Max: "1"}},
+				},
+			},
+			wantErr: "inconclusiveLimit",
+		},
+		{
+			name:        "progressive without strategy",
+			strategy:    "canary",
+			progressive: &ProgressiveSpec{Steps: []CanaryStep{{Weight: 10}}},
+			wantErr:     "only allowed with the progressive strategy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &EndpointPolicySpec{
+				AppRef:     AppReference{Name: "my-app", Image: "img:v1"},
+				GatewayRef: GatewayReference{Name: "gw"},
+				Endpoints: []EndpointSpec{{
+					ID:          "ep1",
+					Type:        "http",
+					Match:       MatchSpec{Path: "/api"},
+					Strategy:    tt.strategy,
+					Progressive: tt.progressive,
+				}},
+			}
+			err := spec.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("expected valid spec, got error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Error("expected error, got nil")
+				return
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidate_HTTPMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint EndpointSpec
+		wantErr  string
+	}{
+		{
+			name: "exact path with method and headers",
+			endpoint: EndpointSpec{ID: "ep1", Match: MatchSpec{
+				Path:     "/api/v1/orders",
+				PathType: "Exact",
+				Method:   "POST",
+				Headers:  []HeaderMatch{{Name: "X-Tenant", Value: "acme"}},
+				QueryParams: []QueryParamMatch{
+					{Type: "RegularExpression", Name: "version", Value: "^v[0-9]+$"},
+				},
+			}},
+		},
+		{
+			name: "several match blocks",
+			endpoint: EndpointSpec{ID: "ep1", Matches: []MatchSpec{
+				{Path: "/api/v1/orders", Method: "POST"},
+				{Path: "^/api/v[0-9]+/orders/[0-9]+$", PathType: "RegularExpression", Method: "PUT"},
+			}},
+		},
+		{
+			name: "match and matches",
+			endpoint: EndpointSpec{ID: "ep1",
+				Match:   MatchSpec{Path: "/api"},
+				Matches: []MatchSpec{{Path: "/api"}},
+			},
+			wantErr: "mutually exclusive",
+		},
+		{
+			name:     "path in matches required",
+			endpoint: EndpointSpec{ID: "ep1", Matches: []MatchSpec{{Method: "GET"}}},
+			wantErr:  "matches[0].path",
+		},
+		{
+			name:     "relative path",
+			endpoint: EndpointSpec{ID: "ep1", Match: MatchSpec{Path: "api/orders"}},
+			wantErr:  "must be an absolute path",
+		},
+		{
+			name:     "dot segment in prefix path",
+			endpoint: EndpointSpec{ID: "ep1", Match: MatchSpec{Path: "/api/../admin"}},
+			wantErr:  "must not contain",
+		},
+		{
+			name:     "invalid path regex",
+			endpoint: EndpointSpec{ID: "ep1", Match: MatchSpec{Path: "/api/(", PathType: "RegularExpression"}},
+			wantErr:  "invalid regular expression",
+		},
+		{
+			name:     "unsupported method",
+			endpoint: EndpointSpec{ID: "ep1", Match: MatchSpec{Path: "/api", Method: "FETCH"}},
+			wantErr:  "match.method",
+		},
+		{
+			name: "duplicate header names",
+			endpoint: EndpointSpec{ID: "ep1", Match: MatchSpec{Path: "/api", Headers: []HeaderMatch{
+				{Name: "X-Tenant", Value: "a"},
+				{Name: "x-tenant", Value: "b"},
+			}}},
+			wantErr: "headers[1].name",
+		},
+		{
+			name: "invalid header name",
+			endpoint: EndpointSpec{ID: "ep1", Match: MatchSpec{Path: "/api", Headers: []HeaderMatch{
+				{Name: "X Tenant", Value: "a"},
+			}}},
+			wantErr: "must be a valid HTTP token",
+		},
+		{
+			name: "missing query param value",
+			endpoint: EndpointSpec{ID: "ep1", Match: MatchSpec{Path: "/api", QueryParams: []QueryParamMatch{
+				{Name: "tenant"},
+			}}},
+			wantErr: "queryParams[0].value",
+		},
+		{
+			name: "headers on gRPC endpoint",
+			endpoint: EndpointSpec{ID: "ep1", Type: "grpc", Match: MatchSpec{
+				Service: "foo.Bar",
+				Method:  "Do",
+				Headers: []HeaderMatch{{Name: "X-Tenant", Value: "a"}},
+			}},
+			wantErr: "match.headers",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &EndpointPolicySpec{
+				AppRef:     AppReference{Name: "my-app", Image: "img:v1"},
+				GatewayRef: GatewayReference{Name: "gw"},
+				Endpoints:  []EndpointSpec{tt.endpoint},
+			}
+			err := spec.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("expected valid spec, got error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Error("expected error, got nil")
+				return
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidate_TemplateContainerNames(t *testing.T) {
+	spec := &EndpointPolicySpec{
+		AppRef:     AppReference{Name: "my-app", Image: "img:v1"},
+		GatewayRef: GatewayReference{Name: "gw"},
+		Template: &corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}, {Image: "envoy:v1"}}},
+		},
+		Endpoints: []EndpointSpec{
+			{
+				ID:    "ep1",
+				Match: MatchSpec{Path: "/api"},
+				Template: &corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}, {Name: "app"}}},
+				},
+			},
+		},
+	}
+
+	err := spec.Validate()
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	for _, want := range []string{"spec.template.spec.containers[1].name", "spec.endpoints[0].template.spec.containers[1].name"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error containing %q, got %v", want, err)
+		}
+	}
+}
+
+func TestValidate_DeploymentRef(t *testing.T) {
+	endpoints := []EndpointSpec{{ID: "ep1", Match: MatchSpec{Path: "/api"}}}
+
+	spec := &EndpointPolicySpec{
+		AppRef:     AppReference{Name: "my-app", DeploymentRef: &DeploymentReference{Name: "my-app"}},
+		GatewayRef: GatewayReference{Name: "gw"},
+		Endpoints:  endpoints,
+	}
+	if err := spec.Validate(); err != nil {
+		t.Errorf("expected image to be optional with deploymentRef, got %v", err)
+	}
+
+	spec.AppRef.DeploymentRef.Name = ""
+	err := spec.Validate()
+	if err == nil || !strings.Contains(err.Error(), "appRef.deploymentRef.name") {
+		t.Errorf("expected error about appRef.deploymentRef.name, got %v", err)
+	}
+}
+
+func TestValidate_Schedule(t *testing.T) {
+	zero, two, three, ten := int32(0), int32(2), int32(3), int32(10)
+	cpu := int32(80)
+	hpa := &HPASpec{Min: 2, Max: 5, CPUTarget: &cpu}
+	window := func(w ScheduleWindow) ScheduleWindow {
+		if w.Name == "" {
+			w.Name = "business-hours"
+		}
+		if w.Start == "" {
+			w.Start = "0 8 * * 1-5"
+		}
+		if w.End == "" {
+			w.End = "0 18 * * 1-5"
+		}
+		return w
+	}
+	tests := []struct {
+		name     string
+		hpa      *HPASpec
+		schedule []ScheduleWindow
+		want     string
+	}{
+		{
+			name:     "fixed replicas",
+			schedule: []ScheduleWindow{window(ScheduleWindow{Timezone: "Europe/Berlin", Replicas: &three})},
+		},
+		{
+			name:     "scale to zero off hours",
+			schedule: []ScheduleWindow{window(ScheduleWindow{Start: "0 18 * * *", End: "0 8 * * *", Replicas: &zero})},
+		},
+		{
+			name:     "hpa bounds",
+			hpa:      hpa,
+			schedule: []ScheduleWindow{window(ScheduleWindow{Min: &three, Max: &ten})},
+		},
+		{
+			name:     "hpa min only",
+			hpa:      hpa,
+			schedule: []ScheduleWindow{window(ScheduleWindow{Min: &three})},
+		},
+		{
+			name:     "missing name",
+			schedule: []ScheduleWindow{{Start: "0 8 * * *", End: "0 18 * * *", Replicas: &three}},
+			want:     "schedule[0].name: Required",
+		},
+		{
+			name: "duplicate name",
+			schedule: []ScheduleWindow{
+				window(ScheduleWindow{Replicas: &three}),
+				window(ScheduleWindow{Start: "0 20 * * *", Replicas: &two}),
+			},
+			want: "schedule[1].name: Duplicate",
+		},
+		{
+			name:     "invalid start",
+			schedule: []ScheduleWindow{window(ScheduleWindow{Start: "0 25 * * *", Replicas: &three})},
+			want:     "schedule[0].start",
+		},
+		{
+			name:     "invalid end",
+			schedule: []ScheduleWindow{window(ScheduleWindow{End: "@daily", Replicas: &three})},
+			want:     "schedule[0].end",
+		},
+		{
+			name:     "unknown timezone",
+			schedule: []ScheduleWindow{window(ScheduleWindow{Timezone: "Mars/Olympus", Replicas: &three})},
+			want:     "schedule[0].timezone",
+		},
+		{
+			name:     "replicas required without autoscaler",
+			schedule: []ScheduleWindow{window(ScheduleWindow{})},
+			want:     "schedule[0].replicas: Required",
+		},
+		{
+			name:     "bounds without autoscaler",
+			schedule: []ScheduleWindow{window(ScheduleWindow{Replicas: &three, Max: &ten})},
+			want:     "only allowed for endpoints with an autoscaler",
+		},
+		{
+			name:     "replicas with hpa",
+			hpa:      hpa,
+			schedule: []ScheduleWindow{window(ScheduleWindow{Replicas: &three})},
+			want:     "schedule[0].replicas: Forbidden",
+		},
+		{
+			name:     "hpa min zero",
+			hpa:      hpa,
+			schedule: []ScheduleWindow{window(ScheduleWindow{Min: &zero})},
+			want:     "schedule[0].min",
+		},
+		{
+			name:     "max below min",
+			hpa:      hpa,
+			schedule: []ScheduleWindow{window(ScheduleWindow{Max: &two, Min: &three})},
+			want:     "max must be greater than or equal to min",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &EndpointPolicySpec{
+				AppRef:     AppReference{Name: "my-app", Image: "img:v1"},
+				GatewayRef: GatewayReference{Name: "gw"},
+				Endpoints: []EndpointSpec{
+					{
+						ID:       "ep1",
+						Type:     "http",
+						Match:    MatchSpec{Path: "/api"},
+						HPA:      tt.hpa,
+						Schedule: tt.schedule,
+					},
+				},
+			}
+
+			err := spec.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("expected valid schedule, got error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestValidate_Filters(t *testing.T) {
+	prefix := func(value string) *PathModifier {
+		return &PathModifier{Type: "ReplacePrefixMatch", ReplacePrefixMatch: value}
+	}
+	rewrite := HTTPFilter{Type: "URLRewrite", URLRewrite: &URLRewriteFilter{Path: prefix("/compute")}}
+	redirect := HTTPFilter{Type: "RequestRedirect", RequestRedirect: &RequestRedirectFilter{Scheme: "https"}}
+	headers := HTTPFilter{Type: "RequestHeaderModifier", RequestHeaderModifier: &HeaderModifierFilter{
+		Set: []HTTPHeader{{Name: "X-Endpoint", Value: "compute"}},
+	}}
+	mirror := func(m RequestMirrorFilter) HTTPFilter { return HTTPFilter{Type: "RequestMirror", RequestMirror: &m} }
+	percent, code := int32(50), int32(307)
+
+	tests := []struct {
+		name     string
+		epType   string
+		strategy string
+		match    MatchSpec
+		matches  []MatchSpec
+		filters  []HTTPFilter
+		want     string
+	}{
+		{
+			name:     "rewrite, headers and mirrors on a canary",
+			strategy: "canary",
+			filters: []HTTPFilter{
+				rewrite,
+				headers,
+				mirror(RequestMirrorFilter{ServiceName: "recorder", Percent: &percent}),
+				mirror(RequestMirrorFilter{ServiceName: "replay", Fraction: &MirrorFraction{Numerator: 1}}),
+			},
+		},
+		{
+			name:    "redirect",
+			filters: []HTTPFilter{redirect},
+		},
+		{
+			name: "cors",
+			filters: []HTTPFilter{{Type: "CORS", CORS: &CORSFilter{
+				AllowOrigins: []string{"https://app.example.com", "http://localhost:3000"},
+				AllowMethods: []string{"*"},
+				AllowHeaders: []string{"Authorization"},
+			}}},
+		},
+		{
+			name:    "grpc endpoint",
+			epType:  "grpc",
+			match:   MatchSpec{Service: "svc.Compute", Method: "Run"},
+			filters: []HTTPFilter{headers},
+			want:    "filters: Forbidden: only allowed for HTTP endpoints",
+		},
+		{
+			name:    "unknown type",
+			filters: []HTTPFilter{{Type: "ExtensionRef"}},
+			want:    "filters[0].type: Unsupported value",
+		},
+		{
+			name:    "missing configuration",
+			filters: []HTTPFilter{{Type: "URLRewrite"}},
+			want:    "filters[0].urlRewrite: Required",
+		},
+		{
+			name:    "configuration of another type",
+			filters: []HTTPFilter{{Type: "URLRewrite", URLRewrite: rewrite.URLRewrite, CORS: &CORSFilter{}}},
+			want:    "filters[0].cors: Forbidden",
+		},
+		{
+			name:    "repeated filter",
+			filters: []HTTPFilter{headers, headers},
+			want:    "filters[1].type: Duplicate",
+		},
+		{
+			name:    "redirect with rewrite",
+			filters: []HTTPFilter{redirect, rewrite},
+			want:    "RequestRedirect and URLRewrite filters are mutually exclusive",
+		},
+		{
+			name:     "redirect on a canary",
+			strategy: "canary",
+			filters:  []HTTPFilter{redirect},
+			want:     "only allowed with the primary strategy",
+		},
+		{
+			name:    "prefix rewrite with several matches",
+			matches: []MatchSpec{{Path: "/a"}, {Path: "/b"}},
+			filters: []HTTPFilter{rewrite},
+			want:    "ReplacePrefixMatch requires exactly one PathPrefix match",
+		},
+		{
+			name:    "prefix rewrite of an exact match",
+			match:   MatchSpec{Path: "/api", PathType: "Exact"},
+			filters: []HTTPFilter{rewrite},
+			want:    "ReplacePrefixMatch requires exactly one PathPrefix match",
+		},
+		{
+			name:    "relative rewrite path",
+			filters: []HTTPFilter{{Type: "URLRewrite", URLRewrite: &URLRewriteFilter{Path: prefix("compute")}}},
+			want:    "urlRewrite.path.replacePrefixMatch: Invalid value",
+		},
+		{
+			name:    "invalid hostname",
+			filters: []HTTPFilter{{Type: "URLRewrite", URLRewrite: &URLRewriteFilter{Hostname: "Compute_Svc"}}},
+			want:    "urlRewrite.hostname",
+		},
+		{
+			name:    "redirect status code",
+			filters: []HTTPFilter{{Type: "RequestRedirect", RequestRedirect: &RequestRedirectFilter{StatusCode: &code}}},
+			want:    "requestRedirect.statusCode",
+		},
+		{
+			name:    "empty header modifier",
+			filters: []HTTPFilter{{Type: "ResponseHeaderModifier", ResponseHeaderModifier: &HeaderModifierFilter{}}},
+			want:    "at least one of set, add or remove is required",
+		},
+		{
+			name: "duplicate header",
+			filters: []HTTPFilter{{Type: "RequestHeaderModifier", RequestHeaderModifier: &HeaderModifierFilter{
+				Add: []HTTPHeader{{Name: "X-A", Value: "1"}, {Name: "x-a", Value: "2"}},
+			}}},
+			want: "requestHeaderModifier.add[1].name: Duplicate",
+		},
+		{
+			name:    "mirror without service",
+			filters: []HTTPFilter{mirror(RequestMirrorFilter{})},
+			want:    "requestMirror.serviceName: Required",
+		},
+		{
+			name:    "mirror percent and fraction",
+			filters: []HTTPFilter{mirror(RequestMirrorFilter{ServiceName: "recorder", Percent: &percent, Fraction: &MirrorFraction{Numerator: 1}})},
+			want:    "percent and fraction are mutually exclusive",
+		},
+		{
+			name:    "mirror fraction above one",
+			filters: []HTTPFilter{mirror(RequestMirrorFilter{ServiceName: "recorder", Fraction: &MirrorFraction{Numerator: 101}})},
+			want:    "requestMirror.fraction.numerator",
+		},
+		{
+			name:    "cors wildcard with other origins",
+			filters: []HTTPFilter{{Type: "CORS", CORS: &CORSFilter{AllowOrigins: []string{"*", "https://app.example.com"}}}},
+			want:    "must not be combined with other values",
+		},
+		{
+			name:    "cors origin with path",
+			filters: []HTTPFilter{{Type: "CORS", CORS: &CORSFilter{AllowOrigins: []string{"https://app.example.com/login"}}}},
+			want:    "cors.allowOrigins[0]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match := tt.match
+			if match.isEmpty() && len(tt.matches) == 0 {
+				match = MatchSpec{Path: "/api/v1/compute"}
+			}
+			spec := &EndpointPolicySpec{
+				AppRef:     AppReference{Name: "my-app", Image: "img:v1"},
+				GatewayRef: GatewayReference{Name: "gw"},
+				Endpoints: []EndpointSpec{
+					{
+						ID:       "ep1",
+						Type:     tt.epType,
+						Match:    match,
+						Matches:  tt.matches,
+						Strategy: tt.strategy,
+						Filters:  tt.filters,
+					},
+				},
+			}
+
+			err := spec.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("expected valid filters, got error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestValidate_Shadow(t *testing.T) {
+	ten, tooMany := int32(10), int32(150)
+	tests := []struct {
+		name     string
+		epType   string
+		strategy string
+		shadow   *ShadowSpec
+		want     string
+	}{
+		{name: "mirror everything", strategy: "shadow"},
+		{name: "mirror a percentage", strategy: "shadow", shadow: &ShadowSpec{Percent: &ten}},
+		{name: "mirror a fraction", strategy: "shadow", shadow: &ShadowSpec{Fraction: &MirrorFraction{Numerator: 1, Denominator: &ten}}},
+		{name: "grpc", epType: "grpc", strategy: "shadow", shadow: &ShadowSpec{Percent: &ten}},
+		{
+			name:     "shadow without shadow strategy",
+			strategy: "canary",
+			shadow:   &ShadowSpec{Percent: &ten},
+			want:     "shadow: Forbidden: only allowed with the shadow strategy",
+		},
+		{
+			name:     "percent out of range",
+			strategy: "shadow",
+			shadow:   &ShadowSpec{Percent: &tooMany},
+			want:     "shadow.percent",
+		},
+		{
+			name:     "percent and fraction",
+			strategy: "shadow",
+			shadow:   &ShadowSpec{Percent: &ten, Fraction: &MirrorFraction{Numerator: 1}},
+			want:     "percent and fraction are mutually exclusive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := EndpointSpec{ID: "ep1", Type: tt.epType, Strategy: tt.strategy, Shadow: tt.shadow}
+			if tt.epType == "grpc" {
+				endpoint.Match = MatchSpec{Service: "svc.Compute", Method: "Run"}
+			} else {
+				endpoint.Match = MatchSpec{Path: "/api"}
+			}
+			spec := &EndpointPolicySpec{
+				AppRef:     AppReference{Name: "my-app", Image: "img:v1"},
+				GatewayRef: GatewayReference{Name: "gw"},
+				Endpoints:  []EndpointSpec{endpoint},
+			}
+
+			err := spec.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("expected valid shadow, got error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestValidate_ABTest(t *testing.T) {
+	beta := []HeaderMatch{{Name: "X-Beta", Value: "true"}}
+	tests := []struct {
+		name     string
+		epType   string
+		strategy string
+		abTest   *ABTestSpec
+		headers  int
+		want     string
+	}{
+		{name: "header", strategy: "abtest", abTest: &ABTestSpec{Headers: beta}},
+		{name: "cookie", strategy: "abtest", abTest: &ABTestSpec{Cookies: []CookieMatch{{Name: "beta", Value: "1"}}}},
+		{name: "grpc metadata", epType: "grpc", strategy: "abtest", abTest: &ABTestSpec{Headers: []HeaderMatch{{Name: "x-beta", Value: "true"}}}},
+		{name: "missing abTest", strategy: "abtest", want: "abTest: Required value"},
+		{name: "abTest without abtest strategy", strategy: "primary", abTest: &ABTestSpec{Headers: beta}, want: "abTest: Forbidden: only allowed with the abtest strategy"},
+		{name: "no conditions", strategy: "abtest", abTest: &ABTestSpec{}, want: "at least one header or cookie is required"},
+		{
+			name:     "invalid header regex",
+			strategy: "abtest",
+			abTest:   &ABTestSpec{Headers: []HeaderMatch{{Type: "RegularExpression", Name: "X-Beta", Value: "("}}},
+			want:     "abTest.headers[0].value",
+		},
+		{
+			name:     "cookies on grpc",
+			epType:   "grpc",
+			strategy: "abtest",
+			abTest:   &ABTestSpec{Cookies: []CookieMatch{{Name: "beta", Value: "1"}}},
+			want:     "abTest.cookies: Forbidden: only allowed for HTTP endpoints",
+		},
+		{
+			name:     "invalid cookie name",
+			strategy: "abtest",
+			abTest:   &ABTestSpec{Cookies: []CookieMatch{{Name: "beta cohort", Value: "1"}}},
+			want:     "abTest.cookies[0].name",
+		},
+		{
+			name:     "invalid cookie value",
+			strategy: "abtest",
+			abTest:   &ABTestSpec{Cookies: []CookieMatch{{Name: "beta", Value: "a;b"}}},
+			want:     "must not contain whitespace, quotes, commas, semicolons or backslashes",
+		},
+		{
+			name:     "no room for the condition",
+			strategy: "abtest",
+			abTest:   &ABTestSpec{Headers: beta},
+			headers:  16,
+			want:     "leaving no room for the abtest condition",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := EndpointSpec{ID: "ep1", Type: tt.epType, Strategy: tt.strategy, ABTest: tt.abTest}
+			if tt.epType == "grpc" {
+				endpoint.Match = MatchSpec{Service: "svc.Compute", Method: "Run"}
+			} else {
+				endpoint.Match = MatchSpec{Path: "/api"}
+				for i := range tt.headers {
+					endpoint.Match.Headers = append(endpoint.Match.Headers, HeaderMatch{Name: fmt.Sprintf("X-H%d", i), Value: "v"})
+				}
+			}
+			spec := &EndpointPolicySpec{
+				AppRef:     AppReference{Name: "my-app", Image: "img:v1"},
+				GatewayRef: GatewayReference{Name: "gw"},
+				Endpoints:  []EndpointSpec{endpoint},
+			}
+
+			err := spec.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("expected valid abTest, got error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestValidate_BlueGreen(t *testing.T) {
+	limit := func(v int32) *int32 { return &v }
+	tests := []struct {
+		name      string
+		strategy  string
+		blueGreen *BlueGreenSpec
+		want      string
+	}{
+		{name: "defaults", strategy: "primary", blueGreen: &BlueGreenSpec{}},
+		{
+			name:      "with canary",
+			strategy:  "canary",
+			blueGreen: &BlueGreenSpec{ScaleDownDelay: &metav1.Duration{Duration: time.Hour}, RevisionHistoryLimit: limit(5)},
+		},
+		{name: "with progressive", strategy: "progressive", blueGreen: &BlueGreenSpec{}, want: "blueGreen: Forbidden: not allowed with the progressive strategy"},
+		{
+			name:      "negative scale-down delay",
+			strategy:  "primary",
+			blueGreen: &BlueGreenSpec{ScaleDownDelay: &metav1.Duration{Duration: -time.Minute}},
+			want:      "blueGreen.scaleDownDelay",
+		},
+		{name: "history limit too low", strategy: "primary", blueGreen: &BlueGreenSpec{RevisionHistoryLimit: limit(0)}, want: "must be between 1 and 100"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := EndpointSpec{ID: "ep1", Match: MatchSpec{Path: "/api"}, Strategy: tt.strategy, BlueGreen: tt.blueGreen}
+			if tt.strategy == "progressive" {
+				endpoint.Progressive = &ProgressiveSpec{Steps: []CanaryStep{{Weight: 50}}}
+			}
+			spec := &EndpointPolicySpec{
+				AppRef:     AppReference{Name: "my-app", Image: "img:v1"},
+				GatewayRef: GatewayReference{Name: "gw"},
+				Endpoints:  []EndpointSpec{endpoint},
+			}
+
+			err := spec.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("expected valid blueGreen, got error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestValidate_Timeouts(t *testing.T) {
+	d := func(v time.Duration) *metav1.Duration { return &metav1.Duration{Duration: v} }
+	tests := []struct {
+		name     string
+		typ      string
+		timeouts *TimeoutsSpec
+		want     string
+	}{
+		{name: "request and backend request", timeouts: &TimeoutsSpec{Request: d(10 * time.Second), BackendRequest: d(2 * time.Second)}},
+		{name: "backend request only", timeouts: &TimeoutsSpec{BackendRequest: d(time.Minute)}},
+		{name: "request disabled", timeouts: &TimeoutsSpec{Request: d(0), BackendRequest: d(time.Minute)}},
+		{
+			name:     "backend request exceeds request",
+			timeouts: &TimeoutsSpec{Request: d(time.Second), BackendRequest: d(2 * time.Second)},
+			want:     "timeouts.backendRequest: Invalid value: \"2s\": must not exceed timeouts.request",
+		},
+		{name: "negative request", timeouts: &TimeoutsSpec{Request: d(-time.Second)}, want: "timeouts.request: Invalid value: \"-1s\": must not be negative"},
+		{name: "sub-millisecond", timeouts: &TimeoutsSpec{Request: d(1500 * time.Microsecond)}, want: "must be a whole number of milliseconds"},
+		{name: "grpc", typ: "grpc", timeouts: &TimeoutsSpec{Request: d(time.Second)}, want: "timeouts: Forbidden: only allowed for HTTP endpoints"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := EndpointSpec{ID: "ep1", Type: tt.typ, Match: MatchSpec{Path: "/api"}, Timeouts: tt.timeouts}
+			if tt.typ == "grpc" {
+				endpoint.Match = MatchSpec{Service: "pkg.Service"}
+			}
+			spec := &EndpointPolicySpec{
+				AppRef:     AppReference{Name: "my-app", Image: "img:v1"},
+				GatewayRef: GatewayReference{Name: "gw"},
+				Endpoints:  []EndpointSpec{endpoint},
+			}
+
+			err := spec.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("expected valid timeouts, got error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestValidate_Retry(t *testing.T) {
+	attempts := func(v int32) *int32 { return &v }
+	tests := []struct {
+		name  string
+		typ   string
+		retry *RetrySpec
+		want  string
+	}{
+		{
+			name:  "full",
+			retry: &RetrySpec{Attempts: attempts(3), Backoff: &metav1.Duration{Duration: 100 * time.Millisecond}, Codes: []int32{502, 503, 504}},
+		},
+		{name: "disabled", retry: &RetrySpec{Attempts: attempts(0)}},
+		{name: "negative attempts", retry: &RetrySpec{Attempts: attempts(-1)}, want: "retry.attempts: Invalid value: -1: must not be negative"},
+		{name: "success code", retry: &RetrySpec{Codes: []int32{200}}, want: "retry.codes[0]: Invalid value: 200: must be between 400 and 599"},
+		{name: "duplicate code", retry: &RetrySpec{Codes: []int32{503, 503}}, want: "retry.codes[1]: Duplicate value: 503"},
+		{name: "negative backoff", retry: &RetrySpec{Backoff: &metav1.Duration{Duration: -time.Second}}, want: "retry.backoff"},
+		{name: "grpc", typ: "grpc", retry: &RetrySpec{Attempts: attempts(2)}, want: "retry: Forbidden: only allowed for HTTP endpoints"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := EndpointSpec{ID: "ep1", Type: tt.typ, Match: MatchSpec{Path: "/api"}, Retry: tt.retry}
+			if tt.typ == "grpc" {
+				endpoint.Match = MatchSpec{Service: "pkg.Service"}
+			}
+			spec := &EndpointPolicySpec{
+				AppRef:     AppReference{Name: "my-app", Image: "img:v1"},
+				GatewayRef: GatewayReference{Name: "gw"},
+				Endpoints:  []EndpointSpec{endpoint},
+			}
+
+			err := spec.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("expected valid retry, got error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
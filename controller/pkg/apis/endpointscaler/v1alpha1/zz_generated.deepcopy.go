@@ -3,6 +3,7 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
@@ -56,8 +57,13 @@ func (in *EndpointPolicyList) DeepCopyObject() runtime.Object {
 
 func (in *EndpointPolicySpec) DeepCopyInto(out *EndpointPolicySpec) {
 	*out = *in
-	out.AppRef = in.AppRef
+	in.AppRef.DeepCopyInto(&out.AppRef)
 	out.GatewayRef = in.GatewayRef
+	if in.Template != nil {
+		in, out := &in.Template, &out.Template
+		*out = new(corev1.PodTemplateSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Endpoints != nil {
 		in, out := &in.Endpoints, &out.Endpoints
 		*out = make([]EndpointSpec, len(*in))
@@ -88,7 +94,9 @@ func (in *EndpointPolicyStatus) DeepCopyInto(out *EndpointPolicyStatus) {
 	if in.EndpointStatuses != nil {
 		in, out := &in.EndpointStatuses, &out.EndpointStatuses
 		*out = make([]EndpointStatus, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
@@ -103,6 +111,11 @@ func (in *EndpointPolicyStatus) DeepCopy() *EndpointPolicyStatus {
 
 func (in *AppReference) DeepCopyInto(out *AppReference) {
 	*out = *in
+	if in.DeploymentRef != nil {
+		in, out := &in.DeploymentRef, &out.DeploymentRef
+		*out = new(DeploymentReference)
+		**out = **in
+	}
 }
 
 func (in *AppReference) DeepCopy() *AppReference {
@@ -114,6 +127,19 @@ func (in *AppReference) DeepCopy() *AppReference {
 	return out
 }
 
+func (in *DeploymentReference) DeepCopyInto(out *DeploymentReference) {
+	*out = *in
+}
+
+func (in *DeploymentReference) DeepCopy() *DeploymentReference {
+	if in == nil {
+		return nil
+	}
+	out := new(DeploymentReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 func (in *GatewayReference) DeepCopyInto(out *GatewayReference) {
 	*out = *in
 }
@@ -129,12 +155,51 @@ func (in *GatewayReference) DeepCopy() *GatewayReference {
 
 func (in *EndpointSpec) DeepCopyInto(out *EndpointSpec) {
 	*out = *in
-	out.Match = in.Match
+	in.Match.DeepCopyInto(&out.Match)
+	if in.Matches != nil {
+		in, out := &in.Matches, &out.Matches
+		*out = make([]MatchSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Filters != nil {
+		in, out := &in.Filters, &out.Filters
+		*out = make([]HTTPFilter, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Timeouts != nil {
+		in, out := &in.Timeouts, &out.Timeouts
+		*out = new(TimeoutsSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Retry != nil {
+		in, out := &in.Retry, &out.Retry
+		*out = new(RetrySpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.CanaryWeight != nil {
 		in, out := &in.CanaryWeight, &out.CanaryWeight
 		*out = new(int32)
 		**out = **in
 	}
+	if in.Progressive != nil {
+		in, out := &in.Progressive, &out.Progressive
+		*out = new(ProgressiveSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Shadow != nil {
+		in, out := &in.Shadow, &out.Shadow
+		*out = new(ShadowSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ABTest != nil {
+		in, out := &in.ABTest, &out.ABTest
+		*out = new(ABTestSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Resources != nil {
 		in, out := &in.Resources, &out.Resources
 		*out = new(ResourceSpec)
@@ -145,11 +210,33 @@ func (in *EndpointSpec) DeepCopyInto(out *EndpointSpec) {
 		*out = new(HPASpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Autoscaler != nil {
+		in, out := &in.Autoscaler, &out.Autoscaler
+		*out = new(AutoscalerSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Template != nil {
+		in, out := &in.Template, &out.Template
+		*out = new(corev1.PodTemplateSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Replicas != nil {
 		in, out := &in.Replicas, &out.Replicas
 		*out = new(int32)
 		**out = **in
 	}
+	if in.Schedule != nil {
+		in, out := &in.Schedule, &out.Schedule
+		*out = make([]ScheduleWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.BlueGreen != nil {
+		in, out := &in.BlueGreen, &out.BlueGreen
+		*out = new(BlueGreenSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 func (in *EndpointSpec) DeepCopy() *EndpointSpec {
@@ -163,6 +250,16 @@ func (in *EndpointSpec) DeepCopy() *EndpointSpec {
 
 func (in *MatchSpec) DeepCopyInto(out *MatchSpec) {
 	*out = *in
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make([]HeaderMatch, len(*in))
+		copy(*out, *in)
+	}
+	if in.QueryParams != nil {
+		in, out := &in.QueryParams, &out.QueryParams
+		*out = make([]QueryParamMatch, len(*in))
+		copy(*out, *in)
+	}
 }
 
 func (in *MatchSpec) DeepCopy() *MatchSpec {
@@ -174,51 +271,820 @@ func (in *MatchSpec) DeepCopy() *MatchSpec {
 	return out
 }
 
-func (in *ResourceSpec) DeepCopyInto(out *ResourceSpec) {
+func (in *HeaderMatch) DeepCopyInto(out *HeaderMatch) {
 	*out = *in
 }
 
-func (in *ResourceSpec) DeepCopy() *ResourceSpec {
+func (in *HeaderMatch) DeepCopy() *HeaderMatch {
 	if in == nil {
 		return nil
 	}
-	out := new(ResourceSpec)
+	out := new(HeaderMatch)
 	in.DeepCopyInto(out)
 	return out
 }
 
-func (in *HPASpec) DeepCopyInto(out *HPASpec) {
+func (in *QueryParamMatch) DeepCopyInto(out *QueryParamMatch) {
 	*out = *in
-	if in.CPUTarget != nil {
-		in, out := &in.CPUTarget, &out.CPUTarget
+}
+
+func (in *QueryParamMatch) DeepCopy() *QueryParamMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(QueryParamMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *TimeoutsSpec) DeepCopyInto(out *TimeoutsSpec) {
+	*out = *in
+	if in.Request != nil {
+		in, out := &in.Request, &out.Request
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.BackendRequest != nil {
+		in, out := &in.BackendRequest, &out.BackendRequest
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+func (in *TimeoutsSpec) DeepCopy() *TimeoutsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TimeoutsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *RetrySpec) DeepCopyInto(out *RetrySpec) {
+	*out = *in
+	if in.Attempts != nil {
+		in, out := &in.Attempts, &out.Attempts
 		*out = new(int32)
 		**out = **in
 	}
-	if in.MemoryTarget != nil {
-		in, out := &in.MemoryTarget, &out.MemoryTarget
+	if in.Backoff != nil {
+		in, out := &in.Backoff, &out.Backoff
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Codes != nil {
+		in, out := &in.Codes, &out.Codes
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+}
+
+func (in *RetrySpec) DeepCopy() *RetrySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RetrySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ShadowSpec) DeepCopyInto(out *ShadowSpec) {
+	*out = *in
+	if in.Percent != nil {
+		in, out := &in.Percent, &out.Percent
 		*out = new(int32)
 		**out = **in
 	}
+	if in.Fraction != nil {
+		in, out := &in.Fraction, &out.Fraction
+		*out = new(MirrorFraction)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
-func (in *HPASpec) DeepCopy() *HPASpec {
+func (in *ShadowSpec) DeepCopy() *ShadowSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(HPASpec)
+	out := new(ShadowSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
-func (in *EndpointStatus) DeepCopyInto(out *EndpointStatus) {
+func (in *ABTestSpec) DeepCopyInto(out *ABTestSpec) {
 	*out = *in
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make([]HeaderMatch, len(*in))
+		copy(*out, *in)
+	}
+	if in.Cookies != nil {
+		in, out := &in.Cookies, &out.Cookies
+		*out = make([]CookieMatch, len(*in))
+		copy(*out, *in)
+	}
 }
 
-func (in *EndpointStatus) DeepCopy() *EndpointStatus {
+func (in *ABTestSpec) DeepCopy() *ABTestSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(EndpointStatus)
+	out := new(ABTestSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *CookieMatch) DeepCopyInto(out *CookieMatch) {
+	*out = *in
+}
+
+func (in *CookieMatch) DeepCopy() *CookieMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(CookieMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *HTTPFilter) DeepCopyInto(out *HTTPFilter) {
+	*out = *in
+	if in.URLRewrite != nil {
+		in, out := &in.URLRewrite, &out.URLRewrite
+		*out = new(URLRewriteFilter)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RequestRedirect != nil {
+		in, out := &in.RequestRedirect, &out.RequestRedirect
+		*out = new(RequestRedirectFilter)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RequestHeaderModifier != nil {
+		in, out := &in.RequestHeaderModifier, &out.RequestHeaderModifier
+		*out = new(HeaderModifierFilter)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ResponseHeaderModifier != nil {
+		in, out := &in.ResponseHeaderModifier, &out.ResponseHeaderModifier
+		*out = new(HeaderModifierFilter)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RequestMirror != nil {
+		in, out := &in.RequestMirror, &out.RequestMirror
+		*out = new(RequestMirrorFilter)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CORS != nil {
+		in, out := &in.CORS, &out.CORS
+		*out = new(CORSFilter)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+func (in *HTTPFilter) DeepCopy() *HTTPFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *URLRewriteFilter) DeepCopyInto(out *URLRewriteFilter) {
+	*out = *in
+	if in.Path != nil {
+		in, out := &in.Path, &out.Path
+		*out = new(PathModifier)
+		**out = **in
+	}
+}
+
+func (in *URLRewriteFilter) DeepCopy() *URLRewriteFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(URLRewriteFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *RequestRedirectFilter) DeepCopyInto(out *RequestRedirectFilter) {
+	*out = *in
+	if in.Path != nil {
+		in, out := &in.Path, &out.Path
+		*out = new(PathModifier)
+		**out = **in
+	}
+	if in.Port != nil {
+		in, out := &in.Port, &out.Port
+		*out = new(int32)
+		**out = **in
+	}
+	if in.StatusCode != nil {
+		in, out := &in.StatusCode, &out.StatusCode
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+func (in *RequestRedirectFilter) DeepCopy() *RequestRedirectFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(RequestRedirectFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *PathModifier) DeepCopyInto(out *PathModifier) {
+	*out = *in
+}
+
+func (in *PathModifier) DeepCopy() *PathModifier {
+	if in == nil {
+		return nil
+	}
+	out := new(PathModifier)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *HeaderModifierFilter) DeepCopyInto(out *HeaderModifierFilter) {
+	*out = *in
+	if in.Set != nil {
+		in, out := &in.Set, &out.Set
+		*out = make([]HTTPHeader, len(*in))
+		copy(*out, *in)
+	}
+	if in.Add != nil {
+		in, out := &in.Add, &out.Add
+		*out = make([]HTTPHeader, len(*in))
+		copy(*out, *in)
+	}
+	if in.Remove != nil {
+		in, out := &in.Remove, &out.Remove
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+func (in *HeaderModifierFilter) DeepCopy() *HeaderModifierFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(HeaderModifierFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *HTTPHeader) DeepCopyInto(out *HTTPHeader) {
+	*out = *in
+}
+
+func (in *HTTPHeader) DeepCopy() *HTTPHeader {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPHeader)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *RequestMirrorFilter) DeepCopyInto(out *RequestMirrorFilter) {
+	*out = *in
+	if in.Port != nil {
+		in, out := &in.Port, &out.Port
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Percent != nil {
+		in, out := &in.Percent, &out.Percent
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Fraction != nil {
+		in, out := &in.Fraction, &out.Fraction
+		*out = new(MirrorFraction)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+func (in *RequestMirrorFilter) DeepCopy() *RequestMirrorFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(RequestMirrorFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *MirrorFraction) DeepCopyInto(out *MirrorFraction) {
+	*out = *in
+	if in.Denominator != nil {
+		in, out := &in.Denominator, &out.Denominator
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+func (in *MirrorFraction) DeepCopy() *MirrorFraction {
+	if in == nil {
+		return nil
+	}
+	out := new(MirrorFraction)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *CORSFilter) DeepCopyInto(out *CORSFilter) {
+	*out = *in
+	if in.AllowOrigins != nil {
+		in, out := &in.AllowOrigins, &out.AllowOrigins
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowCredentials != nil {
+		in, out := &in.AllowCredentials, &out.AllowCredentials
+		*out = new(bool)
+		**out = **in
+	}
+	if in.AllowMethods != nil {
+		in, out := &in.AllowMethods, &out.AllowMethods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowHeaders != nil {
+		in, out := &in.AllowHeaders, &out.AllowHeaders
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExposeHeaders != nil {
+		in, out := &in.ExposeHeaders, &out.ExposeHeaders
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+func (in *CORSFilter) DeepCopy() *CORSFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(CORSFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ProgressiveSpec) DeepCopyInto(out *ProgressiveSpec) {
+	*out = *in
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]CanaryStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Analysis != nil {
+		in, out := &in.Analysis, &out.Analysis
+		*out = new(AnalysisSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+func (in *ProgressiveSpec) DeepCopy() *ProgressiveSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ProgressiveSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *CanaryStep) DeepCopyInto(out *CanaryStep) {
+	*out = *in
+	if in.Pause != nil {
+		in, out := &in.Pause, &out.Pause
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+func (in *CanaryStep) DeepCopy() *CanaryStep {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *AnalysisSpec) DeepCopyInto(out *AnalysisSpec) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.InconclusiveLimit != nil {
+		in, out := &in.InconclusiveLimit, &out.InconclusiveLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]AnalysisCheck, len(*in))
+		copy(*out, *in)
+	}
+}
+
+func (in *AnalysisSpec) DeepCopy() *AnalysisSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AnalysisSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *AnalysisCheck) DeepCopyInto(out *AnalysisCheck) {
+	*out = *in
+}
+
+func (in *AnalysisCheck) DeepCopy() *AnalysisCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(AnalysisCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ResourceSpec) DeepCopyInto(out *ResourceSpec) {
+	*out = *in
+}
+
+func (in *ResourceSpec) DeepCopy() *ResourceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *HPASpec) DeepCopyInto(out *HPASpec) {
+	*out = *in
+	if in.CPUTarget != nil {
+		in, out := &in.CPUTarget, &out.CPUTarget
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MemoryTarget != nil {
+		in, out := &in.MemoryTarget, &out.MemoryTarget
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = make([]HPAMetric, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Behavior != nil {
+		in, out := &in.Behavior, &out.Behavior
+		*out = new(HPABehavior)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+func (in *HPASpec) DeepCopy() *HPASpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HPASpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ScheduleWindow) DeepCopyInto(out *ScheduleWindow) {
+	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Min != nil {
+		in, out := &in.Min, &out.Min
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Max != nil {
+		in, out := &in.Max, &out.Max
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+func (in *ScheduleWindow) DeepCopy() *ScheduleWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduleWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *AutoscalerSpec) DeepCopyInto(out *AutoscalerSpec) {
+	*out = *in
+	if in.Keda != nil {
+		in, out := &in.Keda, &out.Keda
+		*out = new(KEDASpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+func (in *AutoscalerSpec) DeepCopy() *AutoscalerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoscalerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *KEDASpec) DeepCopyInto(out *KEDASpec) {
+	*out = *in
+	if in.MinReplicaCount != nil {
+		in, out := &in.MinReplicaCount, &out.MinReplicaCount
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PollingInterval != nil {
+		in, out := &in.PollingInterval, &out.PollingInterval
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CooldownPeriod != nil {
+		in, out := &in.CooldownPeriod, &out.CooldownPeriod
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Triggers != nil {
+		in, out := &in.Triggers, &out.Triggers
+		*out = make([]KEDATrigger, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+func (in *KEDASpec) DeepCopy() *KEDASpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KEDASpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *KEDATrigger) DeepCopyInto(out *KEDATrigger) {
+	*out = *in
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.AuthenticationRef != nil {
+		in, out := &in.AuthenticationRef, &out.AuthenticationRef
+		*out = new(KEDAAuthenticationRef)
+		**out = **in
+	}
+}
+
+func (in *KEDATrigger) DeepCopy() *KEDATrigger {
+	if in == nil {
+		return nil
+	}
+	out := new(KEDATrigger)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *HPABehavior) DeepCopyInto(out *HPABehavior) {
+	*out = *in
+	if in.ScaleUp != nil {
+		in, out := &in.ScaleUp, &out.ScaleUp
+		*out = new(HPAScalingRules)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ScaleDown != nil {
+		in, out := &in.ScaleDown, &out.ScaleDown
+		*out = new(HPAScalingRules)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+func (in *HPABehavior) DeepCopy() *HPABehavior {
+	if in == nil {
+		return nil
+	}
+	out := new(HPABehavior)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *HPAScalingRules) DeepCopyInto(out *HPAScalingRules) {
+	*out = *in
+	if in.StabilizationWindowSeconds != nil {
+		in, out := &in.StabilizationWindowSeconds, &out.StabilizationWindowSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Policies != nil {
+		in, out := &in.Policies, &out.Policies
+		*out = make([]HPAScalingPolicy, len(*in))
+		copy(*out, *in)
+	}
+}
+
+func (in *HPAScalingRules) DeepCopy() *HPAScalingRules {
+	if in == nil {
+		return nil
+	}
+	out := new(HPAScalingRules)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *HPAMetric) DeepCopyInto(out *HPAMetric) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DescribedObject != nil {
+		in, out := &in.DescribedObject, &out.DescribedObject
+		*out = new(MetricObjectReference)
+		**out = **in
+	}
+	if in.AverageUtilization != nil {
+		in, out := &in.AverageUtilization, &out.AverageUtilization
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+func (in *HPAMetric) DeepCopy() *HPAMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(HPAMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *MetricObjectReference) DeepCopyInto(out *MetricObjectReference) {
+	*out = *in
+}
+
+func (in *MetricObjectReference) DeepCopy() *MetricObjectReference {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricObjectReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *EndpointStatus) DeepCopyInto(out *EndpointStatus) {
+	*out = *in
+	if in.RouteRejections != nil {
+		in, out := &in.RouteRejections, &out.RouteRejections
+		*out = make([]RouteRejection, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Progressive != nil {
+		in, out := &in.Progressive, &out.Progressive
+		*out = new(ProgressiveStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BlueGreen != nil {
+		in, out := &in.BlueGreen, &out.BlueGreen
+		*out = new(BlueGreenStatus)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+func (in *EndpointStatus) DeepCopy() *EndpointStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EndpointStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *RouteRejection) DeepCopyInto(out *RouteRejection) {
+	*out = *in
+}
+
+func (in *RouteRejection) DeepCopy() *RouteRejection {
+	if in == nil {
+		return nil
+	}
+	out := new(RouteRejection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *ProgressiveStatus) DeepCopyInto(out *ProgressiveStatus) {
+	*out = *in
+	if in.StepStartedAt != nil {
+		in, out := &in.StepStartedAt, &out.StepStartedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.LastAnalysisAt != nil {
+		in, out := &in.LastAnalysisAt, &out.LastAnalysisAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+func (in *ProgressiveStatus) DeepCopy() *ProgressiveStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ProgressiveStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *BlueGreenSpec) DeepCopyInto(out *BlueGreenSpec) {
+	*out = *in
+	if in.ScaleDownDelay != nil {
+		in, out := &in.ScaleDownDelay, &out.ScaleDownDelay
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.RevisionHistoryLimit != nil {
+		in, out := &in.RevisionHistoryLimit, &out.RevisionHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+func (in *BlueGreenSpec) DeepCopy() *BlueGreenSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BlueGreenSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *BlueGreenStatus) DeepCopyInto(out *BlueGreenStatus) {
+	*out = *in
+	if in.ScaleDownAt != nil {
+		in, out := &in.ScaleDownAt, &out.ScaleDownAt
+		*out = (*in).DeepCopy()
+	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]BlueGreenRevision, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+func (in *BlueGreenStatus) DeepCopy() *BlueGreenStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BlueGreenStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *BlueGreenRevision) DeepCopyInto(out *BlueGreenRevision) {
+	*out = *in
+	in.PromotedAt.DeepCopyInto(&out.PromotedAt)
+}
+
+func (in *BlueGreenRevision) DeepCopy() *BlueGreenRevision {
+	if in == nil {
+		return nil
+	}
+	out := new(BlueGreenRevision)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+)
+
+const (
+	defaultAnalysisInterval  = time.Minute
+	defaultInconclusiveLimit = 5
+)
+
+var analysisHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// analysisInterval is the time between analysis runs.
+func analysisInterval(analysis *esv1alpha1.AnalysisSpec) time.Duration {
+	if analysis.Interval != nil {
+		return analysis.Interval.Duration
+	}
+	return defaultAnalysisInterval
+}
+
+// inconclusiveLimit is how many consecutive inconclusive runs are tolerated.
+func inconclusiveLimit(analysis *esv1alpha1.AnalysisSpec) int32 {
+	if analysis.InconclusiveLimit != nil {
+		return *analysis.InconclusiveLimit
+	}
+	return defaultInconclusiveLimit
+}
+
+// runAnalysis evaluates every check once. It returns a failure message when a
+// check is out of bounds, or an error when a result could not be obtained;
+// the latter is inconclusive and does not trigger a rollback.
+func runAnalysis(ctx context.Context, analysis *esv1alpha1.AnalysisSpec) (string, error) {
+	for _, check := range analysis.Checks {
+		value, err := queryPrometheus(ctx, analysis.PrometheusURL, check.Query)
+		if err != nil {
+			return "", fmt.Errorf("check %q: %w", check.Name, err)
+		}
+		if check.Min != "" {
+			if lower, err := strconv.ParseFloat(check.Min, 64); err == nil && value < lower {
+				return fmt.Sprintf("check %q failed: %g is below min %s", check.Name, value, check.Min), nil
+			}
+		}
+		if check.Max != "" {
+			if upper, err := strconv.ParseFloat(check.Max, 64); err == nil && value > upper {
+				return fmt.Sprintf("check %q failed: %g is above max %s", check.Name, value, check.Max), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+type prometheusResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// queryPrometheus runs an instant query and returns its single value.
+func queryPrometheus(ctx context.Context, baseURL, query string) (float64, error) {
+	endpoint := strings.TrimSuffix(baseURL, "/") + "/api/v1/query?" + url.Values{"query": {query}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := analysisHTTPClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	var body prometheusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("decoding response: %w", err)
+	}
+	if body.Status != "success" {
+		return 0, fmt.Errorf("query failed: %s", body.Error)
+	}
+
+	var sample [2]interface{}
+	switch body.Data.ResultType {
+	case "scalar":
+		if err := json.Unmarshal(body.Data.Result, &sample); err != nil {
+			return 0, fmt.Errorf("decoding scalar: %w", err)
+		}
+	case "vector":
+		var vector []struct {
+			Value [2]interface{} `json:"value"`
+		}
+		if err := json.Unmarshal(body.Data.Result, &vector); err != nil {
+			return 0, fmt.Errorf("decoding vector: %w", err)
+		}
+		if len(vector) != 1 {
+			return 0, fmt.Errorf("expected 1 sample, got %d", len(vector))
+		}
+		sample = vector[0].Value
+	default:
+		return 0, fmt.Errorf("unsupported result type %q", body.Data.ResultType)
+	}
+
+	raw, ok := sample[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("unexpected sample value %v", sample[1])
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(value) {
+		return 0, fmt.Errorf("query returned NaN")
+	}
+	return value, nil
+}
@@ -0,0 +1,434 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+)
+
+const (
+	defaultScaleDownDelay       = 10 * time.Minute
+	defaultRevisionHistoryLimit = 10
+
+	// colorLabel tells the Deployments and Services of a blue/green
+	// endpoint apart. It is part of their selectors.
+	colorLabel = "endpointscaler.io/color"
+)
+
+// ReasonBlueGreenPromoted is the event reason for a blue/green cutover
+const ReasonBlueGreenPromoted = "BlueGreenPromoted"
+
+// reconcileBlueGreen releases the endpoint pod template as a blue/green
+// endpoint and returns the new release state together with when the
+// previous color is due for removal. A new pod template is applied to the
+// idle color, which becomes active once its Deployment is fully available;
+// the previous color is kept for the scale-down delay so that reverting the
+// template switches back without waiting for a rollout. The state is
+// persisted in EndpointStatus, so a restarted controller resumes the
+// release in progress.
+func (r *EndpointPolicyReconciler) reconcileBlueGreen(
+	ctx context.Context,
+	policy *esv1alpha1.EndpointPolicy,
+	endpoint *esv1alpha1.EndpointSpec,
+) (*esv1alpha1.BlueGreenStatus, time.Duration, error) {
+	logger := log.FromContext(ctx)
+	now := r.now()
+
+	inherited, err := r.inheritedPodTemplate(ctx, policy)
+	if err != nil {
+		return nil, 0, err
+	}
+	deployment, err := r.buildDeployment(policy, endpoint, inherited)
+	if err != nil {
+		return nil, 0, err
+	}
+	revision := templateRevision(&deployment.Spec.Template)
+
+	status := &esv1alpha1.BlueGreenStatus{}
+	if prev := findEndpointStatus(policy, endpoint.ID); prev != nil && prev.BlueGreen != nil {
+		status = prev.BlueGreen.DeepCopy()
+	} else {
+		// An endpoint switching to blue/green keeps serving from its single
+		// Deployment until the first color is available. A new endpoint has
+		// nothing to protect and starts on blue.
+		existing := &appsv1.Deployment{}
+		key := types.NamespacedName{Name: endpointResourceName(policy, endpoint), Namespace: appNamespace(policy)}
+		if err := r.Get(ctx, key, existing); apierrors.IsNotFound(err) {
+			status.ActiveColor = esv1alpha1.ColorBlue
+			status.ActiveRevision = revision
+			recordRelease(status, endpoint, deployment, now)
+		} else if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	switch revision {
+	case status.ActiveRevision:
+		status.PreviewColor, status.PreviewRevision = "", ""
+	case status.PreviewRevision:
+	default:
+		color := idleColor(status.ActiveColor)
+		if status.PreviousColor == color {
+			// Reverting to the previous template reuses its running pods,
+			// anything else replaces them
+			if status.PreviousRevision != revision {
+				logger.Info("Replacing previous color", "endpoint", endpoint.ID, "color", color)
+			}
+			status.PreviousColor, status.PreviousRevision, status.ScaleDownAt = "", "", nil
+		}
+		logger.Info("Releasing new revision", "endpoint", endpoint.ID, "color", color, "revision", revision)
+		status.PreviewColor, status.PreviewRevision = color, revision
+	}
+
+	// While a release is in preview the active color keeps its template, so
+	// only the preview is applied
+	color := status.ActiveColor
+	if status.PreviewColor != "" {
+		color = status.PreviewColor
+	}
+	generation, err := r.applyColor(ctx, policy, endpoint, deployment, color)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if status.PreviewColor != "" {
+		promoted, err := r.previewAvailable(ctx, policy, endpoint, status, generation)
+		if err != nil {
+			return nil, 0, err
+		}
+		if promoted {
+			logger.Info("Switching endpoint to new color", "endpoint", endpoint.ID,
+				"color", status.PreviewColor, "revision", status.PreviewRevision)
+			r.event(policy, corev1.EventTypeNormal, ReasonBlueGreenPromoted,
+				"Endpoint %q switched to %s (revision %s)", endpoint.ID, status.PreviewColor, status.PreviewRevision)
+			if status.ActiveColor != "" {
+				scaleDownAt := metav1.NewTime(now.Add(scaleDownDelay(endpoint.BlueGreen)))
+				status.PreviousColor, status.PreviousRevision = status.ActiveColor, status.ActiveRevision
+				status.ScaleDownAt = &scaleDownAt
+			}
+			status.ActiveColor, status.ActiveRevision = status.PreviewColor, status.PreviewRevision
+			status.PreviewColor, status.PreviewRevision = "", ""
+			recordRelease(status, endpoint, deployment, now)
+		}
+	}
+
+	// The previous color is removed by pruneColors once the route no
+	// longer needs it
+	var requeue time.Duration
+	if status.PreviousColor != "" && status.ScaleDownAt != nil {
+		if remaining := status.ScaleDownAt.Sub(now); remaining > 0 {
+			requeue = remaining
+		} else {
+			logger.Info("Removing previous color", "endpoint", endpoint.ID, "color", status.PreviousColor)
+			status.PreviousColor, status.PreviousRevision, status.ScaleDownAt = "", "", nil
+		}
+	}
+	return status, requeue, nil
+}
+
+// leaveBlueGreen keeps the active color of an endpoint whose blue/green
+// releases were turned off serving until the single Deployment is available,
+// and returns the release state still in effect: nil once the Deployment can
+// take over, or when the endpoint was not blue/green.
+func (r *EndpointPolicyReconciler) leaveBlueGreen(
+	ctx context.Context,
+	policy *esv1alpha1.EndpointPolicy,
+	endpoint *esv1alpha1.EndpointSpec,
+) (*esv1alpha1.BlueGreenStatus, error) {
+	prev := findEndpointStatus(policy, endpoint.ID)
+	if prev == nil || prev.BlueGreen == nil || prev.BlueGreen.ActiveColor == "" {
+		return nil, nil
+	}
+
+	deployment := &appsv1.Deployment{}
+	key := types.NamespacedName{Name: endpointResourceName(policy, endpoint), Namespace: appNamespace(policy)}
+	if err := r.Get(ctx, key, deployment); err != nil {
+		return nil, err
+	}
+	// The single Deployment is removed at the first cutover, so the cache
+	// holds no available copy of an older template
+	ready, err := r.readyToTakeOver(ctx, policy, endpoint, deployment, 0,
+		colorResourceName(policy, endpoint, prev.BlueGreen.ActiveColor))
+	if err != nil {
+		return nil, err
+	}
+	if ready {
+		log.FromContext(ctx).Info("Switching endpoint to its single Deployment", "endpoint", endpoint.ID)
+		return nil, nil
+	}
+	return prev.BlueGreen, nil
+}
+
+// applyColor applies the Deployment built for the endpoint, and the endpoint
+// Service, as the given color, and returns the generation of the applied
+// Deployment.
+func (r *EndpointPolicyReconciler) applyColor(
+	ctx context.Context,
+	policy *esv1alpha1.EndpointPolicy,
+	endpoint *esv1alpha1.EndpointSpec,
+	deployment *appsv1.Deployment,
+	color string,
+) (int64, error) {
+	logger := log.FromContext(ctx)
+
+	desired := deployment.DeepCopy()
+	desired.Name = colorResourceName(policy, endpoint, color)
+	desired.Labels[colorLabel] = color
+	desired.Spec.Selector.MatchLabels[colorLabel] = color
+	desired.Spec.Template.Labels[colorLabel] = color
+	if err := r.setOwner(policy, desired); err != nil {
+		return 0, err
+	}
+	logger.Info("Applying Deployment", "name", desired.Name)
+	if err := r.apply(ctx, desired); err != nil {
+		return 0, err
+	}
+
+	svc := r.buildService(policy, endpoint)
+	svc.Name = colorServiceName(policy, endpoint, color)
+	svc.Labels[colorLabel] = color
+	svc.Spec.Selector[colorLabel] = color
+	if err := r.setOwner(policy, svc); err != nil {
+		return 0, err
+	}
+	logger.Info("Applying Service", "name", svc.Name)
+	return desired.Generation, r.apply(ctx, svc)
+}
+
+// previewAvailable reports whether the preview color, applied at the given
+// generation, is ready to take over.
+func (r *EndpointPolicyReconciler) previewAvailable(
+	ctx context.Context,
+	policy *esv1alpha1.EndpointPolicy,
+	endpoint *esv1alpha1.EndpointSpec,
+	status *esv1alpha1.BlueGreenStatus,
+	generation int64,
+) (bool, error) {
+	preview := &appsv1.Deployment{}
+	key := types.NamespacedName{Name: colorResourceName(policy, endpoint, status.PreviewColor), Namespace: appNamespace(policy)}
+	if err := r.Get(ctx, key, preview); err != nil {
+		return false, err
+	}
+
+	active := endpointResourceName(policy, endpoint)
+	if status.ActiveColor != "" {
+		active = colorResourceName(policy, endpoint, status.ActiveColor)
+	}
+	return r.readyToTakeOver(ctx, policy, endpoint, preview, generation, active)
+}
+
+// readyToTakeOver reports whether a Deployment is fully available to take
+// the traffic of the Deployment named current. The Deployment is read from
+// the cache, which may not have seen the generation just applied yet: a
+// color reused within its scale-down delay would then still report the
+// availability of its previous template. An autoscaled endpoint leaves
+// the replica count to its autoscaler, which keeps targeting the current
+// Deployment until the cutover, so the Deployment is first scaled to the
+// current one's replicas to take the full load.
+func (r *EndpointPolicyReconciler) readyToTakeOver(
+	ctx context.Context,
+	policy *esv1alpha1.EndpointPolicy,
+	endpoint *esv1alpha1.EndpointSpec,
+	deployment *appsv1.Deployment,
+	generation int64,
+	current string,
+) (bool, error) {
+	if deployment.Generation < generation {
+		return false, nil
+	}
+	if endpoint.Autoscaled() {
+		active := &appsv1.Deployment{}
+		key := types.NamespacedName{Name: current, Namespace: appNamespace(policy)}
+		if err := r.Get(ctx, key, active); client.IgnoreNotFound(err) != nil {
+			return false, err
+		}
+		replicas := int32(1)
+		if active.Spec.Replicas != nil && *active.Spec.Replicas > replicas {
+			replicas = *active.Spec.Replicas
+		}
+		// A plain patch leaves spec.replicas out of the applied
+		// configuration, so the autoscaler can take it over
+		if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas < replicas {
+			patch := client.MergeFrom(deployment.DeepCopy())
+			deployment.Spec.Replicas = &replicas
+			if err := r.Patch(ctx, deployment, patch); err != nil {
+				return false, err
+			}
+			return false, nil
+		}
+	}
+
+	return deploymentCondition(deployment).Status == metav1.ConditionTrue, nil
+}
+
+// pruneColors deletes the endpoint Deployments and Services that the release
+// state no longer needs: the single Deployment after the first cutover, a
+// color past its scale-down delay or, once the single Deployment has taken
+// over from a turned off blue/green endpoint, both colors. It must run after
+// the route and autoscaler are applied, so that they never point at a
+// deleted backend.
+func (r *EndpointPolicyReconciler) pruneColors(
+	ctx context.Context,
+	policy *esv1alpha1.EndpointPolicy,
+	endpoint *esv1alpha1.EndpointSpec,
+) error {
+	var release *esv1alpha1.BlueGreenStatus
+	if status := findEndpointStatus(policy, endpoint.ID); status != nil {
+		release = status.BlueGreen
+	}
+	var keep []string
+	if endpoint.BlueGreen == nil || release == nil || release.ActiveColor == "" {
+		keep = append(keep, endpointResourceName(policy, endpoint), endpointServiceName(policy, endpoint))
+	}
+	if release != nil {
+		for _, c := range []string{release.ActiveColor, release.PreviewColor, release.PreviousColor} {
+			if c != "" {
+				keep = append(keep, colorResourceName(policy, endpoint, c), colorServiceName(policy, endpoint, c))
+			}
+		}
+	}
+
+	opts := []client.ListOption{
+		client.InNamespace(appNamespace(policy)),
+		client.MatchingLabels{
+			"endpointscaler.io/policy":           policy.Name,
+			"endpointscaler.io/policy-namespace": policy.Namespace,
+			"endpointscaler.io/endpoint":         endpoint.ID,
+			"app.kubernetes.io/managed-by":       "endpoint-scaler",
+		},
+	}
+	kept := map[string]bool{}
+	for _, name := range keep {
+		kept[name] = true
+	}
+
+	deployments := &appsv1.DeploymentList{}
+	if err := r.List(ctx, deployments, opts...); err != nil {
+		return err
+	}
+	for i := range deployments.Items {
+		if kept[deployments.Items[i].Name] {
+			continue
+		}
+		if err := r.Delete(ctx, &deployments.Items[i]); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	services := &corev1.ServiceList{}
+	if err := r.List(ctx, services, opts...); err != nil {
+		return err
+	}
+	for i := range services.Items {
+		if kept[services.Items[i].Name] {
+			continue
+		}
+		if err := r.Delete(ctx, &services.Items[i]); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordRelease adds the active color to the release history.
+func recordRelease(
+	status *esv1alpha1.BlueGreenStatus,
+	endpoint *esv1alpha1.EndpointSpec,
+	deployment *appsv1.Deployment,
+	now time.Time,
+) {
+	release := esv1alpha1.BlueGreenRevision{
+		Revision:   status.ActiveRevision,
+		Color:      status.ActiveColor,
+		PromotedAt: metav1.NewTime(now),
+	}
+	for _, c := range deployment.Spec.Template.Spec.Containers {
+		if c.Name == endpoint.ID {
+			release.Image = c.Image
+		}
+	}
+
+	limit := defaultRevisionHistoryLimit
+	if endpoint.BlueGreen.RevisionHistoryLimit != nil {
+		limit = int(*endpoint.BlueGreen.RevisionHistoryLimit)
+	}
+	status.History = append([]esv1alpha1.BlueGreenRevision{release}, status.History...)
+	if len(status.History) > limit {
+		status.History = status.History[:limit]
+	}
+}
+
+func scaleDownDelay(spec *esv1alpha1.BlueGreenSpec) time.Duration {
+	if spec.ScaleDownDelay != nil {
+		return spec.ScaleDownDelay.Duration
+	}
+	return defaultScaleDownDelay
+}
+
+// idleColor is the color a new revision is released as.
+func idleColor(active string) string {
+	if active == esv1alpha1.ColorBlue {
+		return esv1alpha1.ColorGreen
+	}
+	return esv1alpha1.ColorBlue
+}
+
+// setBlueGreenStatus records the release state on the policy so that the
+// route and autoscaler built afterwards in the same reconcile target the
+// active color.
+func setBlueGreenStatus(policy *esv1alpha1.EndpointPolicy, id string, release *esv1alpha1.BlueGreenStatus) {
+	if status := findEndpointStatus(policy, id); status != nil {
+		status.BlueGreen = release
+		return
+	}
+	policy.Status.EndpointStatuses = append(policy.Status.EndpointStatuses, esv1alpha1.EndpointStatus{
+		ID:        id,
+		BlueGreen: release,
+	})
+}
+
+// activeColor returns the color serving a blue/green endpoint, or one that
+// is being turned off, and "" when the endpoint has a single Deployment and
+// Service.
+func activeColor(policy *esv1alpha1.EndpointPolicy, endpoint *esv1alpha1.EndpointSpec) string {
+	status := findEndpointStatus(policy, endpoint.ID)
+	if status == nil || status.BlueGreen == nil {
+		return ""
+	}
+	return status.BlueGreen.ActiveColor
+}
+
+// endpointWorkloadName is the Deployment serving the endpoint, which its
+// autoscaler targets.
+func endpointWorkloadName(policy *esv1alpha1.EndpointPolicy, endpoint *esv1alpha1.EndpointSpec) string {
+	if color := activeColor(policy, endpoint); color != "" {
+		return colorResourceName(policy, endpoint, color)
+	}
+	return endpointResourceName(policy, endpoint)
+}
+
+// endpointBackendName is the Service the endpoint route sends traffic to.
+func endpointBackendName(policy *esv1alpha1.EndpointPolicy, endpoint *esv1alpha1.EndpointSpec) string {
+	if color := activeColor(policy, endpoint); color != "" {
+		return colorServiceName(policy, endpoint, color)
+	}
+	return endpointServiceName(policy, endpoint)
+}
+
+func colorResourceName(policy *esv1alpha1.EndpointPolicy, endpoint *esv1alpha1.EndpointSpec, color string) string {
+	return fmt.Sprintf("%s-%s", endpointResourceName(policy, endpoint), color)
+}
+
+func colorServiceName(policy *esv1alpha1.EndpointPolicy, endpoint *esv1alpha1.EndpointSpec, color string) string {
+	return fmt.Sprintf("%s-%s-%s-svc", policy.Spec.AppRef.Name, endpoint.ID, color)
+}
@@ -0,0 +1,403 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+)
+
+func blueGreenPolicy() *esv1alpha1.EndpointPolicy {
+	policy := scaleToZeroPolicy()
+	policy.Spec.Endpoints[0].BlueGreen = &esv1alpha1.BlueGreenSpec{
+		ScaleDownDelay: &metav1.Duration{Duration: 5 * time.Minute},
+	}
+	return policy
+}
+
+// updatePolicy applies change to the stored policy.
+func updatePolicy(t *testing.T, r *EndpointPolicyReconciler, change func(*esv1alpha1.EndpointPolicy)) {
+	t.Helper()
+	latest := &esv1alpha1.EndpointPolicy{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: "test-policy", Namespace: "default"}, latest); err != nil {
+		t.Fatal(err)
+	}
+	change(latest)
+	if err := r.Update(context.Background(), latest); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// blueGreenStatus returns the stored release state of the first endpoint.
+func blueGreenStatus(t *testing.T, r *EndpointPolicyReconciler) *esv1alpha1.BlueGreenStatus {
+	t.Helper()
+	latest := &esv1alpha1.EndpointPolicy{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: "test-policy", Namespace: "default"}, latest); err != nil {
+		t.Fatal(err)
+	}
+	release := latest.Status.EndpointStatuses[0].BlueGreen
+	if release == nil {
+		t.Fatal("expected blue/green status")
+	}
+	return release
+}
+
+func deploymentExists(t *testing.T, r *EndpointPolicyReconciler, name string) bool {
+	t.Helper()
+	err := r.Get(context.Background(), types.NamespacedName{Name: name, Namespace: "default"}, &appsv1.Deployment{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		t.Fatal(err)
+	}
+	return err == nil
+}
+
+func TestReconcile_BlueGreenRelease(t *testing.T) {
+	policy := blueGreenPolicy()
+	clock := clocktesting.NewFakeClock(time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC))
+	r := newFakeReconciler(t, policy)
+	r.Clock = clock
+	recorder := record.NewFakeRecorder(10)
+	r.Recorder = recorder
+	ctx := context.Background()
+	req := reconcileRequest(policy)
+	setImage := func(image string) {
+		updatePolicy(t, r, func(p *esv1alpha1.EndpointPolicy) { p.Spec.AppRef.Image = image })
+	}
+
+	// A new endpoint starts on blue
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if backends := routeBackends(t, r); len(backends) != 1 || backends[0] != "my-app-reports-blue-svc" {
+		t.Fatalf("expected route to the blue service, got %v", backends)
+	}
+	release := blueGreenStatus(t, r)
+	if release.ActiveColor != esv1alpha1.ColorBlue || len(release.History) != 1 || release.History[0].Image != "my-app:v1" {
+		t.Errorf("expected v1 active on blue, got %+v", release)
+	}
+	svc := &corev1.Service{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "my-app-reports-blue-svc", Namespace: "default"}, svc); err != nil {
+		t.Fatalf("expected blue service: %v", err)
+	}
+	if svc.Spec.Selector[colorLabel] != esv1alpha1.ColorBlue {
+		t.Errorf("expected blue service to select blue pods, got %v", svc.Spec.Selector)
+	}
+	markDeploymentAvailable(t, r, "my-app-reports-blue")
+
+	// A new image is released as green and kept off the route until available
+	setImage("my-app:v2")
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	green := &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "my-app-reports-green", Namespace: "default"}, green); err != nil {
+		t.Fatalf("expected green deployment: %v", err)
+	}
+	if image := green.Spec.Template.Spec.Containers[0].Image; image != "my-app:v2" {
+		t.Errorf("expected green to run v2, got %s", image)
+	}
+	blue := &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "my-app-reports-blue", Namespace: "default"}, blue); err != nil {
+		t.Fatal(err)
+	}
+	if image := blue.Spec.Template.Spec.Containers[0].Image; image != "my-app:v1" {
+		t.Errorf("expected blue to keep v1 during the preview, got %s", image)
+	}
+	if backends := routeBackends(t, r); backends[0] != "my-app-reports-blue-svc" {
+		t.Errorf("expected route to stay on blue, got %v", backends)
+	}
+	if release := blueGreenStatus(t, r); release.PreviewColor != esv1alpha1.ColorGreen {
+		t.Errorf("expected green in preview, got %+v", release)
+	}
+
+	// Once green is available the route switches and blue is kept
+	markDeploymentAvailable(t, r, "my-app-reports-green")
+	result, err := r.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if backends := routeBackends(t, r); len(backends) != 1 || backends[0] != "my-app-reports-green-svc" {
+		t.Errorf("expected route to the green service, got %v", backends)
+	}
+	release = blueGreenStatus(t, r)
+	if release.ActiveColor != esv1alpha1.ColorGreen || release.PreviousColor != esv1alpha1.ColorBlue || release.PreviewColor != "" {
+		t.Errorf("expected green active with blue kept, got %+v", release)
+	}
+	if len(release.History) != 2 || release.History[0].Image != "my-app:v2" || release.History[0].Color != esv1alpha1.ColorGreen {
+		t.Errorf("expected v2 on green at the top of the history, got %+v", release.History)
+	}
+	if result.RequeueAfter != 5*time.Minute {
+		t.Errorf("expected requeue at the scale-down in 5m, got %v", result.RequeueAfter)
+	}
+	events := drainEvents(recorder)
+	if len(events) != 1 || !strings.Contains(events[0], ReasonBlueGreenPromoted) {
+		t.Errorf("expected one %s event, got %v", ReasonBlueGreenPromoted, events)
+	}
+
+	// Reverting the image switches back to the running blue pods at once
+	clock.Step(time.Minute)
+	setImage("my-app:v1")
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if backends := routeBackends(t, r); backends[0] != "my-app-reports-blue-svc" {
+		t.Errorf("expected rollback to blue, got %v", backends)
+	}
+	release = blueGreenStatus(t, r)
+	if release.ActiveColor != esv1alpha1.ColorBlue || release.PreviousColor != esv1alpha1.ColorGreen || len(release.History) != 3 {
+		t.Errorf("expected blue active with green kept, got %+v", release)
+	}
+
+	// After the delay the previous color is removed
+	clock.Step(5 * time.Minute)
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if deploymentExists(t, r, "my-app-reports-green") {
+		t.Error("expected green deployment to be removed")
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: "my-app-reports-green-svc", Namespace: "default"}, &corev1.Service{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected green service to be removed, got %v", err)
+	}
+	if release := blueGreenStatus(t, r); release.PreviousColor != "" || release.ScaleDownAt != nil {
+		t.Errorf("expected no previous color, got %+v", release)
+	}
+}
+
+func TestReconcile_BlueGreenFromSingleDeployment(t *testing.T) {
+	policy := scaleToZeroPolicy()
+	r := newFakeReconciler(t, policy)
+	// A backend is only removed once the route has moved off it
+	r.Client = interceptor.NewClient(r.Client.(client.WithWatch), interceptor.Funcs{
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			svcName := obj.GetName()
+			if _, ok := obj.(*appsv1.Deployment); ok {
+				svcName += "-svc"
+			}
+			route := &gatewayv1.HTTPRoute{}
+			if err := c.Get(ctx, types.NamespacedName{Name: "my-app-reports", Namespace: "default"}, route); err == nil {
+				for _, ref := range route.Spec.Rules[0].BackendRefs {
+					if string(ref.Name) == svcName {
+						t.Errorf("%s deleted while the route points at %s", obj.GetName(), svcName)
+					}
+				}
+			}
+			return c.Delete(ctx, obj, opts...)
+		},
+	})
+	ctx := context.Background()
+	req := reconcileRequest(policy)
+	reconcile := func() {
+		t.Helper()
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			t.Fatalf("reconcile failed: %v", err)
+		}
+	}
+	expectRoute := func(want string) {
+		t.Helper()
+		if backends := routeBackends(t, r); len(backends) != 1 || backends[0] != want {
+			t.Errorf("expected route to %s, got %v", want, backends)
+		}
+	}
+
+	reconcile()
+	markDeploymentAvailable(t, r, "my-app-reports")
+	reconcile()
+	expectRoute("my-app-reports-svc")
+
+	// The existing Deployment keeps the traffic until blue is available
+	updatePolicy(t, r, func(p *esv1alpha1.EndpointPolicy) {
+		p.Spec.Endpoints[0].BlueGreen = &esv1alpha1.BlueGreenSpec{}
+	})
+	reconcile()
+	expectRoute("my-app-reports-svc")
+	if release := blueGreenStatus(t, r); release.ActiveColor != "" || release.PreviewColor != esv1alpha1.ColorBlue {
+		t.Errorf("expected blue in preview, got %+v", release)
+	}
+
+	markDeploymentAvailable(t, r, "my-app-reports-blue")
+	reconcile()
+	expectRoute("my-app-reports-blue-svc")
+	if deploymentExists(t, r, "my-app-reports") {
+		t.Error("expected the single Deployment to be removed")
+	}
+
+	// Turning blue/green off keeps blue serving until the single Deployment
+	// is available
+	updatePolicy(t, r, func(p *esv1alpha1.EndpointPolicy) { p.Spec.Endpoints[0].BlueGreen = nil })
+	reconcile()
+	expectRoute("my-app-reports-blue-svc")
+	if !deploymentExists(t, r, "my-app-reports-blue") || !deploymentExists(t, r, "my-app-reports") {
+		t.Fatal("expected blue and the single Deployment to run side by side")
+	}
+
+	markDeploymentAvailable(t, r, "my-app-reports")
+	reconcile()
+	expectRoute("my-app-reports-svc")
+	if deploymentExists(t, r, "my-app-reports-blue") {
+		t.Error("expected the blue Deployment to be removed")
+	}
+	latest := &esv1alpha1.EndpointPolicy{}
+	if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+		t.Fatal(err)
+	}
+	if release := latest.Status.EndpointStatuses[0].BlueGreen; release != nil {
+		t.Errorf("expected no blue/green status, got %+v", release)
+	}
+}
+
+func TestReconcile_BlueGreenAutoscaledPreview(t *testing.T) {
+	policy := blueGreenPolicy()
+	policy.Spec.Endpoints[0].HPA = &esv1alpha1.HPASpec{Min: 2, Max: 10, CPUTarget: ptr.To(int32(70))}
+	r := newFakeReconciler(t, policy)
+	ctx := context.Background()
+	req := reconcileRequest(policy)
+
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	// The HPA has scaled blue out
+	blue := &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "my-app-reports-blue", Namespace: "default"}, blue); err != nil {
+		t.Fatal(err)
+	}
+	blue.Spec.Replicas = ptr.To(int32(6))
+	if err := r.Update(ctx, blue); err != nil {
+		t.Fatal(err)
+	}
+
+	updatePolicy(t, r, func(p *esv1alpha1.EndpointPolicy) { p.Spec.AppRef.Image = "my-app:v2" })
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	green := &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "my-app-reports-green", Namespace: "default"}, green); err != nil {
+		t.Fatal(err)
+	}
+	if green.Spec.Replicas == nil || *green.Spec.Replicas != 6 {
+		t.Errorf("expected green scaled to blue's 6 replicas, got %v", green.Spec.Replicas)
+	}
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "my-app-reports", Namespace: "default"}, hpa); err != nil {
+		t.Fatal(err)
+	}
+	if hpa.Spec.ScaleTargetRef.Name != "my-app-reports-blue" {
+		t.Errorf("expected the HPA to target blue during the preview, got %s", hpa.Spec.ScaleTargetRef.Name)
+	}
+
+	markDeploymentAvailable(t, r, "my-app-reports-green")
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: "my-app-reports", Namespace: "default"}, hpa); err != nil {
+		t.Fatal(err)
+	}
+	if hpa.Spec.ScaleTargetRef.Name != "my-app-reports-green" {
+		t.Errorf("expected the HPA to follow the cutover to green, got %s", hpa.Spec.ScaleTargetRef.Name)
+	}
+}
+
+func TestReconcile_BlueGreenReplacesPreviousColorFromStaleCache(t *testing.T) {
+	policy := blueGreenPolicy()
+	r := newFakeReconciler(t, policy)
+	// stale, once set, is what a lagging cache returns for the blue
+	// Deployment
+	var stale *appsv1.Deployment
+	r.Client = interceptor.NewClient(r.Client.(client.WithWatch), interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if dep, ok := obj.(*appsv1.Deployment); ok && stale != nil && key.Name == stale.Name {
+				stale.DeepCopyInto(dep)
+				return nil
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+		// The fake client leaves generations alone, so bump them on spec
+		// changes the way the API server does
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			dep, ok := obj.(*appsv1.Deployment)
+			if !ok {
+				return c.Patch(ctx, obj, patch, opts...)
+			}
+			old := &appsv1.Deployment{}
+			if err := c.Get(ctx, client.ObjectKeyFromObject(dep), old); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+			if err := c.Patch(ctx, dep, patch, opts...); err != nil {
+				return err
+			}
+			if equality.Semantic.DeepEqual(old.Spec, dep.Spec) {
+				return nil
+			}
+			dep.Generation = old.Generation + 1
+			return c.Update(ctx, dep)
+		},
+	})
+	ctx := context.Background()
+	req := reconcileRequest(policy)
+	reconcile := func() {
+		t.Helper()
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			t.Fatalf("reconcile failed: %v", err)
+		}
+	}
+
+	// v1 on blue, then v2 on green with blue kept for the scale-down delay
+	reconcile()
+	markDeploymentAvailable(t, r, "my-app-reports-blue")
+	updatePolicy(t, r, func(p *esv1alpha1.EndpointPolicy) { p.Spec.AppRef.Image = "my-app:v2" })
+	reconcile()
+	markDeploymentAvailable(t, r, "my-app-reports-green")
+	reconcile()
+	if release := blueGreenStatus(t, r); release.ActiveColor != esv1alpha1.ColorGreen || release.PreviousColor != esv1alpha1.ColorBlue {
+		t.Fatalf("expected green active with blue kept, got %+v", release)
+	}
+
+	// v3 replaces the still available blue pods, while the cache has not
+	// seen the new template yet
+	stale = &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "my-app-reports-blue", Namespace: "default"}, stale); err != nil {
+		t.Fatal(err)
+	}
+	updatePolicy(t, r, func(p *esv1alpha1.EndpointPolicy) { p.Spec.AppRef.Image = "my-app:v3" })
+	reconcile()
+	if backends := routeBackends(t, r); backends[0] != "my-app-reports-green-svc" {
+		t.Errorf("expected route to stay on green until blue runs v3, got %v", backends)
+	}
+	if release := blueGreenStatus(t, r); release.PreviewColor != esv1alpha1.ColorBlue || release.ActiveColor != esv1alpha1.ColorGreen {
+		t.Fatalf("expected blue in preview, got %+v", release)
+	}
+
+	// Once the cache catches up, the old rollout status is not enough either
+	stale = nil
+	reconcile()
+	if backends := routeBackends(t, r); backends[0] != "my-app-reports-green-svc" {
+		t.Errorf("expected route to stay on green during the blue rollout, got %v", backends)
+	}
+
+	markDeploymentAvailable(t, r, "my-app-reports-blue")
+	reconcile()
+	if backends := routeBackends(t, r); backends[0] != "my-app-reports-blue-svc" {
+		t.Errorf("expected route to switch to blue, got %v", backends)
+	}
+	if release := blueGreenStatus(t, r); release.History[0].Image != "my-app:v3" {
+		t.Errorf("expected v3 at the top of the history, got %+v", release.History)
+	}
+}
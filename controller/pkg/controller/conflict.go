@@ -0,0 +1,239 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+)
+
+const (
+	// RouteClaimIndex indexes policies by every (gateway, hostname, match)
+	// tuple their endpoints route.
+	RouteClaimIndex = "spec.endpoints.routeClaims"
+
+	// ReasonRouteConflict marks an endpoint whose match is already routed by
+	// another endpoint on the same gateway and hostname.
+	ReasonRouteConflict = "RouteConflict"
+)
+
+// routeClaim is a single match an endpoint routes on a gateway and hostname.
+type routeClaim struct {
+	endpointIndex int
+	endpointID    string
+	key           string
+	match         string
+}
+
+// RouteConflict is an endpoint claim that is also made by another endpoint.
+type RouteConflict struct {
+	// EndpointID is the endpoint of the policy being checked
+	EndpointID string
+	// Match describes the contested match
+	Match string
+	// Policy and Endpoint identify the other claimant
+	Policy   types.NamespacedName
+	Endpoint string
+	// Lost is true when the other claimant takes precedence
+	Lost bool
+	// Key is the contested claim as indexed under RouteClaimIndex
+	Key string
+}
+
+func (c RouteConflict) String() string {
+	return fmt.Sprintf("%s on gateway is also routed by endpoint %q of EndpointPolicy %s", c.Match, c.Endpoint, c.Policy)
+}
+
+// routeClaims returns the claims of every endpoint of the policy. Matches are
+// normalized so that equivalent specs produce the same key.
+func routeClaims(policy *esv1alpha1.EndpointPolicy) []routeClaim {
+	gatewayNS := policy.Spec.GatewayRef.Namespace
+	if gatewayNS == "" {
+		gatewayNS = policy.Namespace
+	}
+	prefix := fmt.Sprintf("%s/%s|%s|", gatewayNS, policy.Spec.GatewayRef.Name, policy.Spec.GatewayRef.Hostname)
+
+	var claims []routeClaim
+	for i := range policy.Spec.Endpoints {
+		endpoint := &policy.Spec.Endpoints[i]
+		var matches []string
+		if endpoint.Type == "grpc" {
+			matches = []string{fmt.Sprintf("grpc %s/%s", endpoint.Match.Service, endpoint.Match.Method)}
+		} else {
+			for _, m := range endpoint.HTTPMatches() {
+				matches = append(matches, httpMatchKey(&m))
+			}
+		}
+		for _, match := range matches {
+			claims = append(claims, routeClaim{
+				endpointIndex: i,
+				endpointID:    endpoint.ID,
+				key:           prefix + match,
+				match:         match,
+			})
+		}
+	}
+	return claims
+}
+
+func httpMatchKey(m *esv1alpha1.MatchSpec) string {
+	pathType := m.PathType
+	if pathType == "" {
+		pathType = "PathPrefix"
+	}
+	parts := []string{"http", pathType, m.Path}
+	if m.Method != "" {
+		parts = append(parts, "method="+m.Method)
+	}
+
+	var conditions []string
+	for _, h := range m.Headers {
+		conditions = append(conditions, fmt.Sprintf("header:%s%s%s", strings.ToLower(h.Name), matchOperator(h.Type), h.Value))
+	}
+	for _, q := range m.QueryParams {
+		conditions = append(conditions, fmt.Sprintf("query:%s%s%s", q.Name, matchOperator(q.Type), q.Value))
+	}
+	sort.Strings(conditions)
+	return strings.Join(append(parts, conditions...), " ")
+}
+
+func matchOperator(matchType string) string {
+	if matchType == "RegularExpression" {
+		return "~"
+	}
+	return "="
+}
+
+// IndexRouteClaims is the index function for RouteClaimIndex.
+func IndexRouteClaims(obj client.Object) []string {
+	policy := obj.(*esv1alpha1.EndpointPolicy)
+	claims := routeClaims(policy)
+	keys := make([]string, 0, len(claims))
+	seen := map[string]bool{}
+	for _, claim := range claims {
+		if !seen[claim.key] {
+			keys = append(keys, claim.key)
+			seen[claim.key] = true
+		}
+	}
+	return keys
+}
+
+// FindRouteConflicts returns every claim of the policy that another endpoint,
+// in this or another valid policy, also makes. The claimant of the oldest
+// policy wins, then the first by namespace/name, then the first endpoint in
+// the list. A policy without a creation timestamp is being created and loses
+// to every existing one. c must have RouteClaimIndex registered.
+func FindRouteConflicts(ctx context.Context, c client.Reader, policy *esv1alpha1.EndpointPolicy) ([]RouteConflict, error) {
+	claims := routeClaims(policy)
+	self := types.NamespacedName{Name: policy.Name, Namespace: policy.Namespace}
+
+	var conflicts []RouteConflict
+	for _, claim := range claims {
+		for _, other := range claims {
+			if other.key != claim.key || other.endpointIndex == claim.endpointIndex {
+				continue
+			}
+			conflicts = append(conflicts, RouteConflict{
+				EndpointID: claim.endpointID,
+				Match:      claim.match,
+				Policy:     self,
+				Endpoint:   other.endpointID,
+				Lost:       other.endpointIndex < claim.endpointIndex,
+				Key:        claim.key,
+			})
+			break
+		}
+
+		policies := &esv1alpha1.EndpointPolicyList{}
+		if err := c.List(ctx, policies, client.MatchingFields{RouteClaimIndex: claim.key}); err != nil {
+			return nil, err
+		}
+		for i := range policies.Items {
+			other := &policies.Items[i]
+			if other.Namespace == policy.Namespace && other.Name == policy.Name {
+				continue
+			}
+			if other.Spec.Validate() != nil {
+				continue
+			}
+			for _, otherClaim := range routeClaims(other) {
+				if otherClaim.key != claim.key {
+					continue
+				}
+				conflicts = append(conflicts, RouteConflict{
+					EndpointID: claim.endpointID,
+					Match:      claim.match,
+					Policy:     types.NamespacedName{Name: other.Name, Namespace: other.Namespace},
+					Endpoint:   otherClaim.endpointID,
+					Lost:       takesPrecedence(other, policy),
+					Key:        claim.key,
+				})
+				break
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+// takesPrecedence reports whether a's claims win over b's.
+func takesPrecedence(a, b *esv1alpha1.EndpointPolicy) bool {
+	aTime, bTime := a.CreationTimestamp, b.CreationTimestamp
+	switch {
+	case aTime.IsZero() != bTime.IsZero():
+		return bTime.IsZero()
+	case !aTime.Equal(&bTime):
+		return aTime.Before(&bTime)
+	case a.Namespace != b.Namespace:
+		return a.Namespace < b.Namespace
+	default:
+		return a.Name < b.Name
+	}
+}
+
+// lostRouteConflicts returns, per endpoint ID, the first conflict the
+// endpoint loses.
+func (r *EndpointPolicyReconciler) lostRouteConflicts(
+	ctx context.Context,
+	policy *esv1alpha1.EndpointPolicy,
+) (map[string]RouteConflict, error) {
+	conflicts, err := FindRouteConflicts(ctx, r.Client, policy)
+	if err != nil {
+		return nil, err
+	}
+	lost := map[string]RouteConflict{}
+	for _, conflict := range conflicts {
+		if _, ok := lost[conflict.EndpointID]; conflict.Lost && !ok {
+			lost[conflict.EndpointID] = conflict
+		}
+	}
+	return lost, nil
+}
+
+// policiesSharingClaims maps a policy to every other policy routing one of
+// the same matches, so that losers are re-evaluated when a winner changes or
+// goes away.
+func (r *EndpointPolicyReconciler) policiesSharingClaims(ctx context.Context, obj client.Object) []reconcile.Request {
+	var requests []reconcile.Request
+	seen := map[types.NamespacedName]bool{{Name: obj.GetName(), Namespace: obj.GetNamespace()}: true}
+	for _, key := range IndexRouteClaims(obj) {
+		policies := &esv1alpha1.EndpointPolicyList{}
+		if err := r.List(ctx, policies, client.MatchingFields{RouteClaimIndex: key}); err != nil {
+			return requests
+		}
+		for i := range policies.Items {
+			name := client.ObjectKeyFromObject(&policies.Items[i])
+			if !seen[name] {
+				seen[name] = true
+				requests = append(requests, reconcile.Request{NamespacedName: name})
+			}
+		}
+	}
+	return requests
+}
@@ -0,0 +1,259 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+)
+
+// conflictingPolicies returns an older and a newer policy that both route
+// /api/lookup on the same gateway.
+func conflictingPolicies() (*esv1alpha1.EndpointPolicy, *esv1alpha1.EndpointPolicy) {
+	older := testEndpointPolicy()
+	older.Name = "older"
+	older.CreationTimestamp = metav1.NewTime(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	newer := testEndpointPolicy()
+	newer.Name = "newer"
+	newer.CreationTimestamp = metav1.NewTime(older.CreationTimestamp.Add(time.Hour))
+	newer.Spec.AppRef.Name = "other-app"
+	newer.Spec.Endpoints[1].Match.Path = "/api/other"
+	return older, newer
+}
+
+// withRouteTargets adds the Gateway and main Services the policies of
+// conflictingPolicies route through.
+func withRouteTargets(objs ...client.Object) []client.Object {
+	return append(objs,
+		testGateway(),
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "my-app-svc", Namespace: "default"}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "other-app-svc", Namespace: "default"}},
+	)
+}
+
+func TestHTTPMatchKey_Normalized(t *testing.T) {
+	a := &esv1alpha1.MatchSpec{
+		Path: "/api",
+		Headers: []esv1alpha1.HeaderMatch{
+			{Name: "X-Tenant", Value: "a"},
+			{Name: "X-Version", Value: "2"},
+		},
+	}
+	b := &esv1alpha1.MatchSpec{
+		PathType: "PathPrefix",
+		Path:     "/api",
+		Headers: []esv1alpha1.HeaderMatch{
+			{Name: "x-version", Value: "2"},
+			{Name: "x-tenant", Value: "a"},
+		},
+	}
+	if httpMatchKey(a) != httpMatchKey(b) {
+		t.Errorf("expected equivalent matches to share a key, got %q and %q", httpMatchKey(a), httpMatchKey(b))
+	}
+
+	b.Method = "GET"
+	if httpMatchKey(a) == httpMatchKey(b) {
+		t.Errorf("expected method to distinguish matches, got %q", httpMatchKey(a))
+	}
+}
+
+func TestIndexRouteClaims(t *testing.T) {
+	policy := testGRPCEndpointPolicy()
+	policy.Spec.GatewayRef.Hostname = "api.example.com"
+
+	keys := IndexRouteClaims(policy)
+	want := "default/my-gateway|api.example.com|grpc com.example.UserService/GetUser"
+	if len(keys) != 1 || keys[0] != want {
+		t.Errorf("expected claim %q, got %v", want, keys)
+	}
+}
+
+func TestReconcile_RouteConflictOldestPolicyWins(t *testing.T) {
+	older, newer := conflictingPolicies()
+	r := newFakeReconciler(t, withRouteTargets(older, newer)...)
+	ctx := context.Background()
+
+	for _, policy := range []*esv1alpha1.EndpointPolicy{newer, older} {
+		if _, err := r.Reconcile(ctx, reconcileRequest(policy)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	route := &gatewayv1.HTTPRoute{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "my-app-lookup", Namespace: "default"}, route); err != nil {
+		t.Errorf("expected winning route to exist: %v", err)
+	}
+	err := r.Get(ctx, types.NamespacedName{Name: "other-app-lookup", Namespace: "default"}, route)
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected no route for the losing endpoint, got %v", err)
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: "other-app-fallback-endpoint", Namespace: "default"}, route); err != nil {
+		t.Errorf("expected non-conflicting endpoint of the losing policy to be routed: %v", err)
+	}
+
+	updated := &esv1alpha1.EndpointPolicy{}
+	if err := r.Get(ctx, reconcileRequest(newer).NamespacedName, updated); err != nil {
+		t.Fatal(err)
+	}
+	status := updated.Status.EndpointStatuses[0]
+	if status.Ready || status.Reason != ReasonRouteConflict {
+		t.Errorf("expected endpoint not ready with reason %s, got %+v", ReasonRouteConflict, status)
+	}
+	if !strings.Contains(status.Message, "default/older") {
+		t.Errorf("expected message to name the winning policy, got %q", status.Message)
+	}
+	if s := updated.Status.EndpointStatuses[1]; s.Reason == ReasonRouteConflict || s.RouteName == "" {
+		t.Errorf("expected non-conflicting endpoint to be routed, got %+v", s)
+	}
+
+	winner := &esv1alpha1.EndpointPolicy{}
+	if err := r.Get(ctx, reconcileRequest(older).NamespacedName, winner); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range winner.Status.EndpointStatuses {
+		if s.Reason == ReasonRouteConflict || s.RouteName == "" {
+			t.Errorf("expected winning policy endpoints to be routed, got %+v", s)
+		}
+	}
+}
+
+func TestReconcile_RouteConflictRemovesLoserRoute(t *testing.T) {
+	older, newer := conflictingPolicies()
+	newer.Spec.Endpoints[0].Match.Path = "/api/newer"
+	r := newFakeReconciler(t, withRouteTargets(older, newer)...)
+	ctx := context.Background()
+
+	if _, err := r.Reconcile(ctx, reconcileRequest(newer)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The newer policy moves onto a path the older one already routes
+	current := &esv1alpha1.EndpointPolicy{}
+	if err := r.Get(ctx, reconcileRequest(newer).NamespacedName, current); err != nil {
+		t.Fatal(err)
+	}
+	current.Spec.Endpoints[0].Match.Path = "/api/lookup"
+	if err := r.Update(ctx, current); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(ctx, reconcileRequest(newer)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := r.Get(ctx, types.NamespacedName{Name: "other-app-lookup", Namespace: "default"}, &gatewayv1.HTTPRoute{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected losing route to be removed, got %v", err)
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: "other-app-lookup", Namespace: "default"}, &appsv1.Deployment{}); err != nil {
+		t.Errorf("expected losing deployment to be kept, got %v", err)
+	}
+}
+
+func TestReconcile_RouteConflictAfterReorderKeepsWorkloads(t *testing.T) {
+	policy := testEndpointPolicy()
+	r := newFakeReconciler(t, withRouteTargets(policy)...)
+	ctx := context.Background()
+
+	if _, err := r.Reconcile(ctx, reconcileRequest(policy)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A new endpoint with the same match is inserted above the existing one
+	current := &esv1alpha1.EndpointPolicy{}
+	if err := r.Get(ctx, reconcileRequest(policy).NamespacedName, current); err != nil {
+		t.Fatal(err)
+	}
+	inserted := *current.Spec.Endpoints[0].DeepCopy()
+	inserted.ID = "lookup-v2"
+	current.Spec.Endpoints = append([]esv1alpha1.EndpointSpec{inserted}, current.Spec.Endpoints...)
+	if err := r.Update(ctx, current); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(ctx, reconcileRequest(policy)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := r.Get(ctx, reconcileRequest(policy).NamespacedName, current); err != nil {
+		t.Fatal(err)
+	}
+	if s := findEndpointStatus(current, "lookup"); s == nil || s.Reason != ReasonRouteConflict {
+		t.Errorf("expected the existing endpoint to lose the conflict, got %+v", s)
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: "my-app-lookup-v2", Namespace: "default"}, &gatewayv1.HTTPRoute{}); err != nil {
+		t.Errorf("expected the inserted endpoint to be routed, got %v", err)
+	}
+	err := r.Get(ctx, types.NamespacedName{Name: "my-app-lookup", Namespace: "default"}, &gatewayv1.HTTPRoute{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected the losing route to be removed, got %v", err)
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: "my-app-lookup", Namespace: "default"}, &appsv1.Deployment{}); err != nil {
+		t.Errorf("expected the losing deployment to be kept, got %v", err)
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: "my-app-lookup-svc", Namespace: "default"}, &corev1.Service{}); err != nil {
+		t.Errorf("expected the losing service to be kept, got %v", err)
+	}
+}
+
+func TestReconcile_RouteConflictWithinPolicy(t *testing.T) {
+	policy := testEndpointPolicy()
+	policy.Spec.Endpoints[1].Match.Path = "/api/lookup"
+	r := newFakeReconciler(t, withRouteTargets(policy)...)
+	ctx := context.Background()
+
+	if _, err := r.Reconcile(ctx, reconcileRequest(policy)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	updated := &esv1alpha1.EndpointPolicy{}
+	if err := r.Get(ctx, reconcileRequest(policy).NamespacedName, updated); err != nil {
+		t.Fatal(err)
+	}
+	first, second := updated.Status.EndpointStatuses[0], updated.Status.EndpointStatuses[1]
+	if first.Reason == ReasonRouteConflict || first.RouteName == "" {
+		t.Errorf("expected first endpoint to win, got %+v", first)
+	}
+	if second.Ready || second.Reason != ReasonRouteConflict || !strings.Contains(second.Message, `"lookup"`) {
+		t.Errorf("expected second endpoint to lose to %q, got %+v", "lookup", second)
+	}
+}
+
+func TestReconcile_RouteConflictIgnoresInvalidPolicy(t *testing.T) {
+	older, newer := conflictingPolicies()
+	older.Spec.AppRef.Image = ""
+	r := newFakeReconciler(t, withRouteTargets(older, newer)...)
+	ctx := context.Background()
+
+	if _, err := r.Reconcile(ctx, reconcileRequest(newer)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	updated := &esv1alpha1.EndpointPolicy{}
+	if err := r.Get(ctx, reconcileRequest(newer).NamespacedName, updated); err != nil {
+		t.Fatal(err)
+	}
+	if status := updated.Status.EndpointStatuses[0]; status.Reason == ReasonRouteConflict || status.RouteName == "" {
+		t.Errorf("expected invalid policy not to claim routes, got %+v", status)
+	}
+}
+
+func TestPoliciesSharingClaims(t *testing.T) {
+	older, newer := conflictingPolicies()
+	unrelated := testGRPCEndpointPolicy()
+	r := newFakeReconciler(t, older, newer, unrelated)
+
+	requests := r.policiesSharingClaims(context.Background(), older)
+	if len(requests) != 1 || requests[0].NamespacedName != reconcileRequest(newer).NamespacedName {
+		t.Errorf("expected only the conflicting policy to be enqueued, got %v", requests)
+	}
+}
@@ -9,8 +9,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
-	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/client"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
@@ -24,52 +23,89 @@ func (r *EndpointPolicyReconciler) reconcileDeployment(
 	logger := log.FromContext(ctx)
 	name := endpointResourceName(policy, endpoint)
 
-	desired, err := r.buildDeployment(policy, endpoint)
+	inherited, err := r.inheritedPodTemplate(ctx, policy)
 	if err != nil {
 		return "", err
 	}
-	if err := ctrl.SetControllerReference(policy, desired, r.Scheme); err != nil {
+	desired, err := r.buildDeployment(policy, endpoint, inherited)
+	if err != nil {
+		return "", err
+	}
+	if err := r.setOwner(policy, desired); err != nil {
 		return "", err
 	}
 
-	existing := &appsv1.Deployment{}
-	err = r.Get(ctx, types.NamespacedName{Name: name, Namespace: policy.Namespace}, existing)
-	if err != nil {
-		if client.IgnoreNotFound(err) != nil {
-			return "", err
+	logger.Info("Applying Deployment", "name", name)
+	return name, r.apply(ctx, desired)
+}
+
+// inheritedPodTemplate returns the pod template of the Deployment named by
+// appRef.deploymentRef, or nil when the policy does not reference one. The
+// labels matched by that Deployment's selector are removed so that endpoint
+// pods are never selected by the main Deployment or Service.
+func (r *EndpointPolicyReconciler) inheritedPodTemplate(
+	ctx context.Context,
+	policy *esv1alpha1.EndpointPolicy,
+) (*corev1.PodTemplateSpec, error) {
+	ref := policy.Spec.AppRef.DeploymentRef
+	if ref == nil {
+		return nil, nil
+	}
+
+	main := &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: appNamespace(policy)}, main); err != nil {
+		return nil, fmt.Errorf("appRef.deploymentRef %q: %w", ref.Name, err)
+	}
+
+	template := main.Spec.Template.DeepCopy()
+	if main.Spec.Selector != nil {
+		for key := range main.Spec.Selector.MatchLabels {
+			delete(template.Labels, key)
+		}
+		for _, expr := range main.Spec.Selector.MatchExpressions {
+			delete(template.Labels, expr.Key)
 		}
-		logger.Info("Creating Deployment", "name", name)
-		return name, r.Create(ctx, desired)
 	}
 
-	existing.Spec = desired.Spec
-	existing.Labels = desired.Labels
-	logger.Info("Updating Deployment", "name", name)
-	return name, r.Update(ctx, existing)
+	if ref.Container != "" {
+		found := false
+		for _, c := range template.Spec.Containers {
+			found = found || c.Name == ref.Container
+		}
+		if !found {
+			return nil, fmt.Errorf("container %q not found in deployment %q", ref.Container, ref.Name)
+		}
+	}
+	return template, nil
 }
 
 func (r *EndpointPolicyReconciler) buildDeployment(
 	policy *esv1alpha1.EndpointPolicy,
 	endpoint *esv1alpha1.EndpointSpec,
+	inherited *corev1.PodTemplateSpec,
 ) (*appsv1.Deployment, error) {
 	name := endpointResourceName(policy, endpoint)
 	labels := generateLabels(policy, endpoint)
 
-	var replicas int32 = 1
-	if endpoint.Replicas != nil {
-		replicas = *endpoint.Replicas
-	}
-	if endpoint.HPA != nil {
-		replicas = endpoint.HPA.Min
+	// When an HPA or a ScaledObject owns the endpoint, spec.replicas is left
+	// unset so the applied configuration never claims it and scale-out is not
+	// undone.
+	var replicas *int32
+	if !endpoint.Autoscaled() {
+		count := int32(1)
+		if endpoint.Replicas != nil {
+			count = *endpoint.Replicas
+		}
+		replicas = &count
 	}
 
 	containerPort := policy.Spec.AppRef.ContainerPort
 	if containerPort == 0 {
-		containerPort = 8080
+		containerPort = esv1alpha1.DefaultContainerPort
 	}
 
 	image := policy.Spec.AppRef.Image
-	if image == "" {
+	if image == "" && inherited == nil {
 		return nil, fmt.Errorf("appRef.image is required")
 	}
 
@@ -81,33 +117,70 @@ func (r *EndpointPolicyReconciler) buildDeployment(
 			ContainerPort: containerPort,
 			Protocol:      corev1.ProtocolTCP,
 		}},
-		Env: []corev1.EnvVar{{
-			Name:  "ENDPOINTSCALER_GUARDRAIL",
-			Value: endpoint.ID,
-		}},
+		Env: []corev1.EnvVar{
+			{Name: guardrailEnvName, Value: endpoint.ID},
+		},
+	}
+	// Only HTTP requests can be proxied to the main service
+	if endpoint.Type != "grpc" {
+		container.Env = append(container.Env,
+			corev1.EnvVar{Name: fallbackEnvName, Value: mainServiceURL(policy)},
+			corev1.EnvVar{Name: forwardTokenEnvName, Value: string(policy.UID)},
+		)
 	}
 
 	if endpoint.Resources != nil {
 		container.Resources = buildResourceRequirements(endpoint.Resources)
 	}
 
+	if policy.Spec.SDKProbes {
+		container.LivenessProbe = sdkProbe(sdkHealthzPath)
+		container.ReadinessProbe = sdkProbe(sdkReadyzPath)
+	}
+
+	template, err := mergePodTemplate(policy, endpoint, inherited, &corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: labels},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{container}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
-			Namespace: policy.Namespace,
-			Labels:    labels,
+			Namespace: appNamespace(policy),
+			Labels:    objectLabels(policy, endpoint),
 		},
 		Spec: appsv1.DeploymentSpec{
-			Replicas: &replicas,
+			Replicas: replicas,
 			Selector: &metav1.LabelSelector{MatchLabels: labels},
-			Template: corev1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{Labels: labels},
-				Spec:       corev1.PodSpec{Containers: []corev1.Container{container}},
-			},
+			Template: *template,
 		},
 	}, nil
 }
 
+// sdkProbe probes one of the SDK health handlers on the endpoint's "http"
+// port.
+func sdkProbe(path string) *corev1.Probe {
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			HTTPGet: &corev1.HTTPGetAction{Path: path, Port: intstr.FromString("http")},
+		},
+		PeriodSeconds:    10,
+		FailureThreshold: 3,
+	}
+}
+
+// mainServiceURL is the in-cluster URL of the main application service.
+func mainServiceURL(policy *esv1alpha1.EndpointPolicy) string {
+	port := policy.Spec.AppRef.Port
+	if port == 0 {
+		port = esv1alpha1.DefaultPort
+	}
+	return fmt.Sprintf("http://%s.%s.svc:%d", mainServiceName(policy), appNamespace(policy), port)
+}
+
 func buildResourceRequirements(res *esv1alpha1.ResourceSpec) corev1.ResourceRequirements {
 	reqs := corev1.ResourceRequirements{
 		Limits:   corev1.ResourceList{},
@@ -1,9 +1,13 @@
 package controller
 
 import (
+	"context"
 	"testing"
 
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 
 	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
 )
@@ -47,7 +51,7 @@ func TestBuildDeployment(t *testing.T) {
 	}
 	endpoint := &policy.Spec.Endpoints[0]
 
-	deployment, err := r.buildDeployment(policy, endpoint)
+	deployment, err := r.buildDeployment(policy, endpoint, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -62,9 +66,9 @@ func TestBuildDeployment(t *testing.T) {
 		t.Errorf("expected namespace 'default', got %q", deployment.Namespace)
 	}
 
-	// Check replicas (should use HPA.Min when HPA is set)
-	if *deployment.Spec.Replicas != 2 {
-		t.Errorf("expected replicas 2 (from HPA.Min), got %d", *deployment.Spec.Replicas)
+	// Check replicas (left to the HPA when HPA is set)
+	if deployment.Spec.Replicas != nil {
+		t.Errorf("expected replicas to be unset when HPA is set, got %d", *deployment.Spec.Replicas)
 	}
 
 	// Check container
@@ -98,6 +102,20 @@ func TestBuildDeployment(t *testing.T) {
 		t.Error("ENDPOINTSCALER_GUARDRAIL env var not found")
 	}
 
+	// Check ENDPOINTSCALER_FALLBACK_URL env var
+	foundFallback := false
+	for _, env := range container.Env {
+		if env.Name == "ENDPOINTSCALER_FALLBACK_URL" {
+			foundFallback = true
+			if env.Value != "http://my-app-svc.default.svc:8080" {
+				t.Errorf("expected fallback URL of the main service, got %q", env.Value)
+			}
+		}
+	}
+	if !foundFallback {
+		t.Error("ENDPOINTSCALER_FALLBACK_URL env var not found")
+	}
+
 	// Check resources
 	cpuLimit := container.Resources.Limits["cpu"]
 	if cpuLimit.String() != "2" {
@@ -120,6 +138,38 @@ func TestBuildDeployment(t *testing.T) {
 	}
 }
 
+func TestBuildDeployment_FallbackEnv(t *testing.T) {
+	r := &EndpointPolicyReconciler{}
+	env := func(policy *esv1alpha1.EndpointPolicy) map[string]string {
+		t.Helper()
+		deployment, err := r.buildDeployment(policy, &policy.Spec.Endpoints[0], nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		vars := map[string]string{}
+		for _, e := range deployment.Spec.Template.Spec.Containers[0].Env {
+			vars[e.Name] = e.Value
+		}
+		return vars
+	}
+
+	policy := testEndpointPolicy()
+	policy.UID = "policy-uid"
+	vars := env(policy)
+	if vars["ENDPOINTSCALER_FALLBACK_URL"] == "" || vars["ENDPOINTSCALER_FORWARD_TOKEN"] != "policy-uid" {
+		t.Errorf("expected the fallback URL and the policy UID as forward token, got %v", vars)
+	}
+
+	// gRPC requests cannot be proxied over HTTP
+	vars = env(testGRPCEndpointPolicy())
+	if _, ok := vars["ENDPOINTSCALER_FALLBACK_URL"]; ok {
+		t.Errorf("expected no fallback URL for a gRPC endpoint, got %v", vars)
+	}
+	if _, ok := vars["ENDPOINTSCALER_FORWARD_TOKEN"]; ok {
+		t.Errorf("expected no forward token for a gRPC endpoint, got %v", vars)
+	}
+}
+
 func TestBuildDeployment_MissingImage(t *testing.T) {
 	r := &EndpointPolicyReconciler{}
 	policy := &esv1alpha1.EndpointPolicy{
@@ -144,7 +194,7 @@ func TestBuildDeployment_MissingImage(t *testing.T) {
 	}
 	endpoint := &policy.Spec.Endpoints[0]
 
-	_, err := r.buildDeployment(policy, endpoint)
+	_, err := r.buildDeployment(policy, endpoint, nil)
 
 	// Should return error when image is not specified
 	if err == nil {
@@ -177,7 +227,7 @@ func TestBuildDeployment_DefaultPort(t *testing.T) {
 	}
 	endpoint := &policy.Spec.Endpoints[0]
 
-	deployment, err := r.buildDeployment(policy, endpoint)
+	deployment, err := r.buildDeployment(policy, endpoint, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -218,7 +268,7 @@ func TestBuildDeployment_ExplicitReplicas(t *testing.T) {
 	}
 	endpoint := &policy.Spec.Endpoints[0]
 
-	deployment, err := r.buildDeployment(policy, endpoint)
+	deployment, err := r.buildDeployment(policy, endpoint, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -252,7 +302,7 @@ func TestBuildDeployment_Labels(t *testing.T) {
 	}
 	endpoint := &policy.Spec.Endpoints[0]
 
-	deployment, err := r.buildDeployment(policy, endpoint)
+	deployment, err := r.buildDeployment(policy, endpoint, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -279,3 +329,228 @@ func TestBuildDeployment_Labels(t *testing.T) {
 		}
 	}
 }
+
+func TestReconcileDeployment_PreservesHPAReplicas(t *testing.T) {
+	r := newFakeReconciler(t)
+	c := r.Client
+
+	cpu := int32(70)
+	policy := &esv1alpha1.EndpointPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-policy",
+			Namespace: "default",
+			UID:       "policy-uid",
+		},
+		Spec: esv1alpha1.EndpointPolicySpec{
+			AppRef: esv1alpha1.AppReference{
+				Name:  "my-app",
+				Image: "my-app:v1.0.0",
+			},
+			GatewayRef: esv1alpha1.GatewayReference{
+				Name: "my-gateway",
+			},
+			Endpoints: []esv1alpha1.EndpointSpec{
+				{
+					ID:  "lookup",
+					HPA: &esv1alpha1.HPASpec{Min: 2, Max: 10, CPUTarget: &cpu},
+				},
+			},
+		},
+	}
+	endpoint := &policy.Spec.Endpoints[0]
+	ctx := context.Background()
+
+	if _, err := r.reconcileDeployment(ctx, policy, endpoint); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Simulate the HPA scaling the Deployment out
+	key := types.NamespacedName{Name: "my-app-lookup", Namespace: "default"}
+	live := &appsv1.Deployment{}
+	if err := c.Get(ctx, key, live); err != nil {
+		t.Fatalf("failed to get deployment: %v", err)
+	}
+	scaled := int32(7)
+	live.Spec.Replicas = &scaled
+	if err := c.Update(ctx, live); err != nil {
+		t.Fatalf("failed to scale deployment: %v", err)
+	}
+
+	if _, err := r.reconcileDeployment(ctx, policy, endpoint); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := c.Get(ctx, key, live); err != nil {
+		t.Fatalf("failed to get deployment: %v", err)
+	}
+	if live.Spec.Replicas == nil || *live.Spec.Replicas != 7 {
+		t.Errorf("expected HPA-managed replicas 7 to be preserved, got %v", live.Spec.Replicas)
+	}
+}
+
+func mainDeployment(image string) *appsv1.Deployment {
+	labels := map[string]string{"app": "my-app"}
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-app", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"app": "my-app", "team": "search"},
+					Annotations: map[string]string{"prometheus.io/scrape": "true"},
+				},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: "my-app",
+					Containers: []corev1.Container{
+						{Name: "proxy", Image: "envoy:v1"},
+						{
+							Name:  "web",
+							Image: image,
+							Env:   []corev1.EnvVar{{Name: "DATABASE_URL", Value: "postgres://db"}},
+							Ports: []corev1.ContainerPort{{Name: "web", ContainerPort: 8080}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func deploymentRefPolicy() *esv1alpha1.EndpointPolicy {
+	replicas := int32(3)
+	return &esv1alpha1.EndpointPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-policy",
+			Namespace: "default",
+			UID:       "policy-uid",
+		},
+		Spec: esv1alpha1.EndpointPolicySpec{
+			AppRef: esv1alpha1.AppReference{
+				Name:          "my-app",
+				DeploymentRef: &esv1alpha1.DeploymentReference{Name: "my-app", Container: "web"},
+			},
+			GatewayRef: esv1alpha1.GatewayReference{Name: "my-gateway"},
+			Endpoints: []esv1alpha1.EndpointSpec{
+				{
+					ID:        "lookup",
+					Match:     esv1alpha1.MatchSpec{Path: "/api/lookup"},
+					Replicas:  &replicas,
+					Resources: &esv1alpha1.ResourceSpec{CPULimit: "2"},
+				},
+			},
+		},
+	}
+}
+
+func TestReconcileDeployment_DeploymentRef(t *testing.T) {
+	r := newFakeReconciler(t, mainDeployment("my-app:v1"))
+	policy := deploymentRefPolicy()
+	endpoint := &policy.Spec.Endpoints[0]
+	ctx := context.Background()
+
+	if _, err := r.reconcileDeployment(ctx, policy, endpoint); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deployment := &appsv1.Deployment{}
+	key := types.NamespacedName{Name: "my-app-lookup", Namespace: "default"}
+	if err := r.Get(ctx, key, deployment); err != nil {
+		t.Fatalf("failed to get deployment: %v", err)
+	}
+
+	if *deployment.Spec.Replicas != 3 {
+		t.Errorf("expected 3 replicas, got %d", *deployment.Spec.Replicas)
+	}
+	template := deployment.Spec.Template
+	if _, ok := template.Labels["app"]; ok {
+		t.Errorf("expected main selector label to be dropped, got %v", template.Labels)
+	}
+	if template.Labels["team"] != "search" || template.Annotations["prometheus.io/scrape"] != "true" {
+		t.Errorf("expected inherited labels and annotations, got %v %v", template.Labels, template.Annotations)
+	}
+	if template.Spec.ServiceAccountName != "my-app" {
+		t.Errorf("expected inherited service account, got %q", template.Spec.ServiceAccountName)
+	}
+	if len(template.Spec.Containers) != 2 {
+		t.Fatalf("expected endpoint and proxy containers, got %d", len(template.Spec.Containers))
+	}
+
+	var container *corev1.Container
+	for i := range template.Spec.Containers {
+		if template.Spec.Containers[i].Name == "lookup" {
+			container = &template.Spec.Containers[i]
+		}
+	}
+	if container == nil {
+		t.Fatalf("endpoint container not found in %v", template.Spec.Containers)
+	}
+	if container.Image != "my-app:v1" {
+		t.Errorf("expected inherited image, got %q", container.Image)
+	}
+	if container.Resources.Limits.Cpu().String() != "2" {
+		t.Errorf("expected endpoint CPU limit 2, got %s", container.Resources.Limits.Cpu())
+	}
+	env := map[string]string{}
+	for _, e := range container.Env {
+		env[e.Name] = e.Value
+	}
+	if env["DATABASE_URL"] != "postgres://db" || env["ENDPOINTSCALER_GUARDRAIL"] != "lookup" {
+		t.Errorf("expected inherited env plus guardrail, got %v", container.Env)
+	}
+	if len(container.Ports) != 1 || container.Ports[0].Name != "http" {
+		t.Errorf("expected only the controller-owned http port, got %v", container.Ports)
+	}
+
+	// An image bump on the main app rolls out to the endpoint
+	main := &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "my-app", Namespace: "default"}, main); err != nil {
+		t.Fatalf("failed to get main deployment: %v", err)
+	}
+	main.Spec.Template.Spec.Containers[1].Image = "my-app:v2"
+	if err := r.Update(ctx, main); err != nil {
+		t.Fatalf("failed to update main deployment: %v", err)
+	}
+
+	if _, err := r.reconcileDeployment(ctx, policy, endpoint); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Get(ctx, key, deployment); err != nil {
+		t.Fatalf("failed to get deployment: %v", err)
+	}
+	for _, c := range deployment.Spec.Template.Spec.Containers {
+		if c.Name == "lookup" && c.Image != "my-app:v2" {
+			t.Errorf("expected image my-app:v2 after main update, got %q", c.Image)
+		}
+	}
+}
+
+func TestReconcileDeployment_DeploymentRefMissing(t *testing.T) {
+	r := newFakeReconciler(t)
+	policy := deploymentRefPolicy()
+
+	_, err := r.reconcileDeployment(context.Background(), policy, &policy.Spec.Endpoints[0])
+	if err == nil {
+		t.Fatal("expected error for missing main deployment")
+	}
+}
+
+func TestReconcileDeployment_DeploymentRefImageOverride(t *testing.T) {
+	r := newFakeReconciler(t, mainDeployment("my-app:v1"))
+	policy := deploymentRefPolicy()
+	policy.Spec.AppRef.Image = "my-app:canary"
+	ctx := context.Background()
+
+	if _, err := r.reconcileDeployment(ctx, policy, &policy.Spec.Endpoints[0]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deployment := &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "my-app-lookup", Namespace: "default"}, deployment); err != nil {
+		t.Fatalf("failed to get deployment: %v", err)
+	}
+	for _, c := range deployment.Spec.Template.Spec.Containers {
+		if c.Name == "lookup" && c.Image != "my-app:canary" {
+			t.Errorf("expected appRef.image to override, got %q", c.Image)
+		}
+	}
+}
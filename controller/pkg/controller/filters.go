@@ -0,0 +1,241 @@
+package controller
+
+import (
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+)
+
+// buildHTTPFilters returns the rule filters of the endpoint's HTTPRoute: its
+// redirect and CORS filters, in the order they are listed, followed by the
+// mirror of a shadow endpoint. Rules that only reach the main service get
+// none of the endpoint's own filters.
+func buildHTTPFilters(policy *esv1alpha1.EndpointPolicy, endpoint *esv1alpha1.EndpointSpec) []gatewayv1.HTTPRouteFilter {
+	var filters []gatewayv1.HTTPRouteFilter
+	if hasRedirect(endpoint) || !mainServiceOnly(policy, endpoint) {
+		for i := range endpoint.Filters {
+			if f := &endpoint.Filters[i]; !backendFilter(f.Type) {
+				filters = append(filters, buildHTTPFilter(policy, f))
+			}
+		}
+	}
+	if endpoint.Strategy == StrategyShadow {
+		filters = append(filters, gatewayv1.HTTPRouteFilter{
+			Type:          gatewayv1.HTTPRouteFilterRequestMirror,
+			RequestMirror: shadowMirror(policy, endpoint),
+		})
+	}
+	return filters
+}
+
+// buildHTTPBackendFilters returns the filters set on the endpoint's backend,
+// so that they only apply to the requests the endpoint serves.
+func buildHTTPBackendFilters(policy *esv1alpha1.EndpointPolicy, endpoint *esv1alpha1.EndpointSpec) []gatewayv1.HTTPRouteFilter {
+	var filters []gatewayv1.HTTPRouteFilter
+	for i := range endpoint.Filters {
+		if f := &endpoint.Filters[i]; backendFilter(f.Type) {
+			filters = append(filters, buildHTTPFilter(policy, f))
+		}
+	}
+	return filters
+}
+
+// backendFilter reports whether a filter type is set on the endpoint backend
+// rather than on the route rule.
+func backendFilter(filterType string) bool {
+	switch filterType {
+	case "URLRewrite", "RequestHeaderModifier", "ResponseHeaderModifier", "RequestMirror":
+		return true
+	}
+	return false
+}
+
+func buildHTTPFilter(policy *esv1alpha1.EndpointPolicy, f *esv1alpha1.HTTPFilter) gatewayv1.HTTPRouteFilter {
+	filter := gatewayv1.HTTPRouteFilter{Type: gatewayv1.HTTPRouteFilterType(f.Type)}
+	switch f.Type {
+	case "URLRewrite":
+		filter.URLRewrite = &gatewayv1.HTTPURLRewriteFilter{
+			Hostname: preciseHostname(f.URLRewrite.Hostname),
+			Path:     buildPathModifier(f.URLRewrite.Path),
+		}
+	case "RequestRedirect":
+		filter.RequestRedirect = buildRequestRedirect(f.RequestRedirect)
+	case "RequestHeaderModifier":
+		filter.RequestHeaderModifier = buildHeaderFilter(f.RequestHeaderModifier)
+	case "ResponseHeaderModifier":
+		filter.ResponseHeaderModifier = buildHeaderFilter(f.ResponseHeaderModifier)
+	case "RequestMirror":
+		filter.RequestMirror = buildRequestMirror(policy, f.RequestMirror)
+	case "CORS":
+		filter.CORS = buildCORSFilter(f.CORS)
+	}
+	return filter
+}
+
+// buildGRPCFilters returns the mirror of a shadow gRPC endpoint.
+func buildGRPCFilters(policy *esv1alpha1.EndpointPolicy, endpoint *esv1alpha1.EndpointSpec) []gatewayv1.GRPCRouteFilter {
+	if endpoint.Strategy != StrategyShadow {
+		return nil
+	}
+	return []gatewayv1.GRPCRouteFilter{{
+		Type:          gatewayv1.GRPCRouteFilterRequestMirror,
+		RequestMirror: shadowMirror(policy, endpoint),
+	}}
+}
+
+// shadowMirror mirrors the share of requests set by endpoint.Shadow to the
+// endpoint Service.
+func shadowMirror(policy *esv1alpha1.EndpointPolicy, endpoint *esv1alpha1.EndpointSpec) *gatewayv1.HTTPRequestMirrorFilter {
+	var percent *int32
+	var fraction *esv1alpha1.MirrorFraction
+	if endpoint.Shadow != nil {
+		percent, fraction = endpoint.Shadow.Percent, endpoint.Shadow.Fraction
+	}
+	port := backendPort(policy)
+	if endpoint.Type == "grpc" {
+		port = grpcBackendPort(policy)
+	}
+	return mirrorFilter(policy, endpointBackendName(policy, endpoint), port, percent, fraction)
+}
+
+// mainServiceOnly reports whether the endpoint's route sends every request
+// to the main service: shadow endpoints only receive mirrored copies, and
+// endpoints scaled to zero fall back to the main service.
+func mainServiceOnly(policy *esv1alpha1.EndpointPolicy, endpoint *esv1alpha1.EndpointSpec) bool {
+	return endpoint.Strategy == StrategyShadow || scaledToZero(policy, endpoint)
+}
+
+// hasRedirect reports whether the endpoint's route answers with a redirect,
+// in which case it must not have backends.
+func hasRedirect(endpoint *esv1alpha1.EndpointSpec) bool {
+	for _, f := range endpoint.Filters {
+		if f.Type == "RequestRedirect" {
+			return true
+		}
+	}
+	return false
+}
+
+func preciseHostname(hostname string) *gatewayv1.PreciseHostname {
+	if hostname == "" {
+		return nil
+	}
+	h := gatewayv1.PreciseHostname(hostname)
+	return &h
+}
+
+func buildPathModifier(m *esv1alpha1.PathModifier) *gatewayv1.HTTPPathModifier {
+	if m == nil {
+		return nil
+	}
+	modifier := &gatewayv1.HTTPPathModifier{Type: gatewayv1.HTTPPathModifierType(m.Type)}
+	switch m.Type {
+	case "ReplacePrefixMatch":
+		prefix := m.ReplacePrefixMatch
+		modifier.ReplacePrefixMatch = &prefix
+	case "ReplaceFullPath":
+		path := m.ReplaceFullPath
+		modifier.ReplaceFullPath = &path
+	}
+	return modifier
+}
+
+func buildRequestRedirect(r *esv1alpha1.RequestRedirectFilter) *gatewayv1.HTTPRequestRedirectFilter {
+	redirect := &gatewayv1.HTTPRequestRedirectFilter{
+		Hostname: preciseHostname(r.Hostname),
+		Path:     buildPathModifier(r.Path),
+	}
+	if r.Scheme != "" {
+		scheme := r.Scheme
+		redirect.Scheme = &scheme
+	}
+	if r.Port != nil {
+		port := gatewayv1.PortNumber(*r.Port)
+		redirect.Port = &port
+	}
+	if r.StatusCode != nil {
+		code := int(*r.StatusCode)
+		redirect.StatusCode = &code
+	}
+	return redirect
+}
+
+func buildHeaderFilter(h *esv1alpha1.HeaderModifierFilter) *gatewayv1.HTTPHeaderFilter {
+	filter := &gatewayv1.HTTPHeaderFilter{Remove: h.Remove}
+	for _, header := range h.Set {
+		filter.Set = append(filter.Set, gatewayv1.HTTPHeader{Name: gatewayv1.HTTPHeaderName(header.Name), Value: header.Value})
+	}
+	for _, header := range h.Add {
+		filter.Add = append(filter.Add, gatewayv1.HTTPHeader{Name: gatewayv1.HTTPHeaderName(header.Name), Value: header.Value})
+	}
+	return filter
+}
+
+// buildRequestMirror mirrors to a Service in the application namespace, on
+// appRef.port unless the filter names a port.
+func buildRequestMirror(policy *esv1alpha1.EndpointPolicy, m *esv1alpha1.RequestMirrorFilter) *gatewayv1.HTTPRequestMirrorFilter {
+	port := backendPort(policy)
+	if m.Port != nil {
+		port = gatewayv1.PortNumber(*m.Port)
+	}
+	return mirrorFilter(policy, m.ServiceName, port, m.Percent, m.Fraction)
+}
+
+func mirrorFilter(
+	policy *esv1alpha1.EndpointPolicy,
+	serviceName string,
+	port gatewayv1.PortNumber,
+	percent *int32,
+	fraction *esv1alpha1.MirrorFraction,
+) *gatewayv1.HTTPRequestMirrorFilter {
+	kind := gatewayv1.Kind("Service")
+	mirror := &gatewayv1.HTTPRequestMirrorFilter{
+		BackendRef: gatewayv1.BackendObjectReference{
+			Kind:      &kind,
+			Namespace: backendNamespace(policy),
+			Name:      gatewayv1.ObjectName(serviceName),
+			Port:      &port,
+		},
+	}
+	if percent != nil {
+		p := *percent
+		mirror.Percent = &p
+	}
+	if fraction != nil {
+		mirror.Fraction = &gatewayv1.Fraction{Numerator: fraction.Numerator}
+		if fraction.Denominator != nil {
+			denominator := *fraction.Denominator
+			mirror.Fraction.Denominator = &denominator
+		}
+	}
+	return mirror
+}
+
+// backendPort is the port of the main and endpoint Services.
+func backendPort(policy *esv1alpha1.EndpointPolicy) gatewayv1.PortNumber {
+	if policy.Spec.AppRef.Port == 0 {
+		return gatewayv1.PortNumber(esv1alpha1.DefaultPort)
+	}
+	return gatewayv1.PortNumber(policy.Spec.AppRef.Port)
+}
+
+func buildCORSFilter(c *esv1alpha1.CORSFilter) *gatewayv1.HTTPCORSFilter {
+	cors := &gatewayv1.HTTPCORSFilter{MaxAge: c.MaxAge}
+	if c.AllowCredentials != nil {
+		allow := *c.AllowCredentials
+		cors.AllowCredentials = &allow
+	}
+	for _, origin := range c.AllowOrigins {
+		cors.AllowOrigins = append(cors.AllowOrigins, gatewayv1.CORSOrigin(origin))
+	}
+	for _, method := range c.AllowMethods {
+		cors.AllowMethods = append(cors.AllowMethods, gatewayv1.HTTPMethodWithWildcard(method))
+	}
+	for _, name := range c.AllowHeaders {
+		cors.AllowHeaders = append(cors.AllowHeaders, gatewayv1.HTTPHeaderName(name))
+	}
+	for _, name := range c.ExposeHeaders {
+		cors.ExposeHeaders = append(cors.ExposeHeaders, gatewayv1.HTTPHeaderName(name))
+	}
+	return cors
+}
@@ -0,0 +1,269 @@
+package controller
+
+import (
+	"testing"
+
+	"k8s.io/utils/ptr"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+)
+
+func TestBuildHTTPRoute_Filters(t *testing.T) {
+	r := &EndpointPolicyReconciler{}
+	policy := testEndpointPolicy()
+	endpoint := &policy.Spec.Endpoints[0] // canary endpoint
+	endpoint.Filters = []esv1alpha1.HTTPFilter{
+		{
+			Type: "URLRewrite",
+			URLRewrite: &esv1alpha1.URLRewriteFilter{
+				Path: &esv1alpha1.PathModifier{Type: "ReplacePrefixMatch", ReplacePrefixMatch: "/lookup"},
+			},
+		},
+		{
+			Type: "RequestHeaderModifier",
+			RequestHeaderModifier: &esv1alpha1.HeaderModifierFilter{
+				Set:    []esv1alpha1.HTTPHeader{{Name: "X-Endpoint", Value: "lookup"}},
+				Remove: []string{"X-Debug"},
+			},
+		},
+		{
+			Type: "RequestMirror",
+			RequestMirror: &esv1alpha1.RequestMirrorFilter{
+				ServiceName: "recorder",
+				Percent:     ptr.To(int32(10)),
+			},
+		},
+		{
+			Type: "CORS",
+			CORS: &esv1alpha1.CORSFilter{
+				AllowOrigins: []string{"https://app.example.com"},
+				AllowMethods: []string{"GET", "POST"},
+				MaxAge:       600,
+			},
+		},
+	}
+
+	route := r.buildHTTPRoute(policy, endpoint)
+	rule := route.Spec.Rules[0]
+
+	if len(rule.BackendRefs) != 2 {
+		t.Fatalf("expected the canary split to be kept, got %d backend refs", len(rule.BackendRefs))
+	}
+	if main := rule.BackendRefs[0]; main.Name != "my-app-svc" || main.Filters != nil {
+		t.Errorf("expected the main service share to be unfiltered, got %+v", main)
+	}
+	filters := rule.BackendRefs[1].Filters
+	if len(filters) != 3 {
+		t.Fatalf("expected 3 filters on the endpoint backend, got %d", len(filters))
+	}
+
+	rewrite := filters[0]
+	if rewrite.Type != gatewayv1.HTTPRouteFilterURLRewrite || rewrite.URLRewrite == nil {
+		t.Fatalf("expected URLRewrite filter first, got %+v", rewrite)
+	}
+	if path := rewrite.URLRewrite.Path; path.Type != gatewayv1.PrefixMatchHTTPPathModifier || *path.ReplacePrefixMatch != "/lookup" {
+		t.Errorf("expected prefix rewrite to /lookup, got %+v", path)
+	}
+	if rewrite.URLRewrite.Hostname != nil {
+		t.Errorf("expected no hostname rewrite, got %q", *rewrite.URLRewrite.Hostname)
+	}
+
+	headers := filters[1].RequestHeaderModifier
+	if headers == nil || len(headers.Set) != 1 || headers.Set[0].Name != "X-Endpoint" || headers.Set[0].Value != "lookup" {
+		t.Errorf("expected X-Endpoint to be set, got %+v", headers)
+	}
+	if len(headers.Remove) != 1 || headers.Remove[0] != "X-Debug" {
+		t.Errorf("expected X-Debug to be removed, got %v", headers.Remove)
+	}
+
+	mirror := filters[2].RequestMirror
+	if mirror == nil || mirror.BackendRef.Name != "recorder" || *mirror.BackendRef.Port != 8080 {
+		t.Fatalf("expected mirror to recorder:8080, got %+v", mirror)
+	}
+	if mirror.Percent == nil || *mirror.Percent != 10 {
+		t.Errorf("expected mirror percent 10, got %v", mirror.Percent)
+	}
+	if mirror.BackendRef.Namespace != nil {
+		t.Errorf("expected no mirror namespace for a same-namespace policy, got %q", *mirror.BackendRef.Namespace)
+	}
+
+	// CORS answers preflight requests itself, so it stays on the rule
+	if len(rule.Filters) != 1 {
+		t.Fatalf("expected only the CORS filter on the rule, got %+v", rule.Filters)
+	}
+	cors := rule.Filters[0].CORS
+	if cors == nil || len(cors.AllowOrigins) != 1 || cors.AllowOrigins[0] != "https://app.example.com" || cors.MaxAge != 600 {
+		t.Errorf("unexpected CORS filter %+v", cors)
+	}
+	if len(cors.AllowMethods) != 2 || cors.AllowMethods[1] != "POST" {
+		t.Errorf("expected GET and POST, got %v", cors.AllowMethods)
+	}
+}
+
+// rewriteFilters rewrites the endpoint path and allows cross-origin requests.
+func rewriteFilters() []esv1alpha1.HTTPFilter {
+	return []esv1alpha1.HTTPFilter{
+		{
+			Type: "URLRewrite",
+			URLRewrite: &esv1alpha1.URLRewriteFilter{
+				Path: &esv1alpha1.PathModifier{Type: "ReplacePrefixMatch", ReplacePrefixMatch: "/lookup"},
+			},
+		},
+		{
+			Type: "CORS",
+			CORS: &esv1alpha1.CORSFilter{AllowOrigins: []string{"https://app.example.com"}},
+		},
+	}
+}
+
+func TestBuildHTTPRoute_FiltersSkipMainServiceRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*esv1alpha1.EndpointPolicy, *esv1alpha1.EndpointSpec)
+		rule   int
+	}{
+		{
+			name: "shadow",
+			mutate: func(_ *esv1alpha1.EndpointPolicy, endpoint *esv1alpha1.EndpointSpec) {
+				endpoint.Strategy = StrategyShadow
+			},
+		},
+		{
+			name: "scaled to zero",
+			mutate: func(policy *esv1alpha1.EndpointPolicy, endpoint *esv1alpha1.EndpointSpec) {
+				policy.Status.EndpointStatuses = []esv1alpha1.EndpointStatus{{ID: endpoint.ID, ScaledToZero: true}}
+			},
+		},
+		{
+			name: "requests outside the A/B test",
+			mutate: func(_ *esv1alpha1.EndpointPolicy, endpoint *esv1alpha1.EndpointSpec) {
+				endpoint.Strategy = StrategyABTest
+				endpoint.ABTest = &esv1alpha1.ABTestSpec{Headers: []esv1alpha1.HeaderMatch{{Name: "X-Beta", Value: "true"}}}
+			},
+			rule: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &EndpointPolicyReconciler{}
+			policy := testEndpointPolicy()
+			endpoint := &policy.Spec.Endpoints[0]
+			endpoint.Filters = rewriteFilters()
+			tt.mutate(policy, endpoint)
+
+			rule := r.buildHTTPRoute(policy, endpoint).Spec.Rules[tt.rule]
+
+			if len(rule.BackendRefs) != 1 || rule.BackendRefs[0].Name != "my-app-svc" {
+				t.Fatalf("expected all traffic on the main service, got %+v", rule.BackendRefs)
+			}
+			if rule.BackendRefs[0].Filters != nil {
+				t.Errorf("expected no filters on the main service, got %+v", rule.BackendRefs[0].Filters)
+			}
+			for _, f := range rule.Filters {
+				if f.Type != gatewayv1.HTTPRouteFilterRequestMirror || f.RequestMirror.BackendRef.Name != "my-app-lookup-svc" {
+					t.Errorf("expected only the shadow mirror on the rule, got %+v", f)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildHTTPRoute_RedirectHasNoBackends(t *testing.T) {
+	r := &EndpointPolicyReconciler{}
+	policy := testEndpointPolicy()
+	endpoint := &policy.Spec.Endpoints[1] // fallback endpoint
+	endpoint.Filters = []esv1alpha1.HTTPFilter{{
+		Type: "RequestRedirect",
+		RequestRedirect: &esv1alpha1.RequestRedirectFilter{
+			Scheme:     "https",
+			Hostname:   "api.example.com",
+			StatusCode: ptr.To(int32(301)),
+		},
+	}}
+
+	rule := r.buildHTTPRoute(policy, endpoint).Spec.Rules[0]
+
+	if len(rule.BackendRefs) != 0 {
+		t.Errorf("expected no backend refs with a redirect, got %d", len(rule.BackendRefs))
+	}
+	redirect := rule.Filters[0].RequestRedirect
+	if redirect == nil {
+		t.Fatal("expected a RequestRedirect filter")
+	}
+	if *redirect.Scheme != "https" || *redirect.Hostname != "api.example.com" || *redirect.StatusCode != 301 {
+		t.Errorf("unexpected redirect %+v", redirect)
+	}
+	if redirect.Path != nil || redirect.Port != nil {
+		t.Errorf("expected path and port to be kept, got %+v", redirect)
+	}
+}
+
+func TestBuildHTTPRoute_NoFilters(t *testing.T) {
+	r := &EndpointPolicyReconciler{}
+	policy := testEndpointPolicy()
+
+	rule := r.buildHTTPRoute(policy, &policy.Spec.Endpoints[0]).Spec.Rules[0]
+	if rule.Filters != nil {
+		t.Errorf("expected no filters, got %+v", rule.Filters)
+	}
+}
+
+func TestBuildHTTPRoute_Shadow(t *testing.T) {
+	r := &EndpointPolicyReconciler{}
+	policy := testEndpointPolicy()
+	endpoint := &policy.Spec.Endpoints[0]
+	endpoint.Strategy = StrategyShadow
+	endpoint.Shadow = &esv1alpha1.ShadowSpec{Fraction: &esv1alpha1.MirrorFraction{Numerator: 1, Denominator: ptr.To(int32(1000))}}
+	endpoint.Filters = []esv1alpha1.HTTPFilter{{
+		Type: "RequestHeaderModifier",
+		RequestHeaderModifier: &esv1alpha1.HeaderModifierFilter{
+			Set: []esv1alpha1.HTTPHeader{{Name: "X-Endpoint", Value: "lookup"}},
+		},
+	}}
+
+	rule := r.buildHTTPRoute(policy, endpoint).Spec.Rules[0]
+
+	if len(rule.BackendRefs) != 1 || rule.BackendRefs[0].Name != "my-app-svc" || *rule.BackendRefs[0].Weight != 100 {
+		t.Fatalf("expected all traffic on the main service, got %+v", rule.BackendRefs)
+	}
+	if len(rule.Filters) != 1 {
+		t.Fatalf("expected only the mirror, got %+v", rule.Filters)
+	}
+	mirror := rule.Filters[0]
+	if mirror.Type != gatewayv1.HTTPRouteFilterRequestMirror || mirror.RequestMirror == nil {
+		t.Fatalf("expected a RequestMirror filter, got %+v", mirror)
+	}
+	ref := mirror.RequestMirror.BackendRef
+	if ref.Name != "my-app-lookup-svc" || *ref.Port != 8080 {
+		t.Errorf("expected mirror to my-app-lookup-svc:8080, got %s:%d", ref.Name, *ref.Port)
+	}
+	fraction := mirror.RequestMirror.Fraction
+	if fraction == nil || fraction.Numerator != 1 || *fraction.Denominator != 1000 || mirror.RequestMirror.Percent != nil {
+		t.Errorf("expected a 1/1000 fraction, got %+v", mirror.RequestMirror)
+	}
+}
+
+func TestBuildGRPCRoute_Shadow(t *testing.T) {
+	r := &EndpointPolicyReconciler{}
+	policy := testGRPCEndpointPolicy()
+	endpoint := &policy.Spec.Endpoints[0]
+	endpoint.Strategy = StrategyShadow
+
+	rule := r.buildGRPCRoute(policy, endpoint).Spec.Rules[0]
+
+	if len(rule.BackendRefs) != 1 || rule.BackendRefs[0].Name != gatewayv1.ObjectName(mainServiceName(policy)) {
+		t.Fatalf("expected all traffic on the main service, got %+v", rule.BackendRefs)
+	}
+	if len(rule.Filters) != 1 || rule.Filters[0].Type != gatewayv1.GRPCRouteFilterRequestMirror {
+		t.Fatalf("expected a RequestMirror filter, got %+v", rule.Filters)
+	}
+	mirror := rule.Filters[0].RequestMirror
+	if mirror.BackendRef.Name != gatewayv1.ObjectName(endpointServiceName(policy, endpoint)) {
+		t.Errorf("expected mirror to the endpoint service, got %s", mirror.BackendRef.Name)
+	}
+	if mirror.Percent != nil || mirror.Fraction != nil {
+		t.Errorf("expected every request to be mirrored, got %+v", mirror)
+	}
+}
@@ -0,0 +1,205 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+)
+
+const (
+	// teardownTimeout bounds how long deletion waits for the gateway to accept
+	// the drained routes before removing the endpoint workloads anyway.
+	teardownTimeout = 2 * time.Minute
+
+	teardownPollInterval = 5 * time.Second
+)
+
+// finalize tears a policy down in an order that never leaves a route pointing
+// at a missing backend: routes are first shifted back to the main service,
+// without their filters, the gateway must accept them, and only then are the
+// endpoint Deployments, Services and HPAs removed, followed by the routes and
+// the finalizer.
+func (r *EndpointPolicyReconciler) finalize(ctx context.Context, policy *esv1alpha1.EndpointPolicy) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(policy, finalizerName) {
+		return ctrl.Result{}, nil
+	}
+	logger := log.FromContext(ctx)
+
+	// A drain that keeps failing must not block deletion forever either
+	drained, err := r.drainRoutes(ctx, policy)
+	if err != nil || !drained {
+		if r.now().Sub(policy.DeletionTimestamp.Time) < teardownTimeout {
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			logger.Info("Waiting for gateway to accept drained routes", "name", policy.Name)
+			return ctrl.Result{RequeueAfter: teardownPollInterval}, nil
+		}
+		if err != nil {
+			logger.Error(err, "Timed out draining routes, continuing teardown", "name", policy.Name)
+		} else {
+			logger.Info("Timed out waiting for drained routes, continuing teardown", "name", policy.Name)
+		}
+	}
+
+	logger.Info("Cleaning up EndpointPolicy", "name", policy.Name)
+	if err := r.pruneStale(ctx, policy, map[string]bool{}, map[string]bool{}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	controllerutil.RemoveFinalizer(policy, finalizerName)
+	if err := r.Update(ctx, policy); err != nil {
+		return ctrl.Result{}, err
+	}
+	RemovePolicyMetrics(policy.Namespace, policy.Name)
+	return ctrl.Result{}, nil
+}
+
+// drainRoutes points every rule of the policy's routes at the main service,
+// except the redirect rules that have no backends, removes their filters, as
+// they only belong to requests the endpoint serves, and reports whether the
+// gateway has accepted all of them. When the main service does not exist
+// there is nothing to drain to and it reports true.
+func (r *EndpointPolicyReconciler) drainRoutes(ctx context.Context, policy *esv1alpha1.EndpointPolicy) (bool, error) {
+	svc := &corev1.Service{}
+	err := r.Get(ctx, types.NamespacedName{Name: mainServiceName(policy), Namespace: appNamespace(policy)}, svc)
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	labels := client.MatchingLabels{
+		"endpointscaler.io/policy":     policy.Name,
+		"app.kubernetes.io/managed-by": "endpoint-scaler",
+	}
+	kind := gatewayv1.Kind("Service")
+	backendNS := backendNamespace(policy)
+	weight := int32(100)
+	drained := true
+
+	httpRoutes := &gatewayv1.HTTPRouteList{}
+	if err := r.List(ctx, httpRoutes, client.InNamespace(policy.Namespace), labels); err != nil {
+		return false, err
+	}
+	for i := range httpRoutes.Items {
+		route := &httpRoutes.Items[i]
+		for j := range route.Spec.Rules {
+			// A redirect rule answers requests itself and must stay
+			// without backends
+			refs := route.Spec.Rules[j].BackendRefs
+			if len(refs) == 0 {
+				continue
+			}
+			port := refs[0].Port
+			if port == nil {
+				defaultPort := backendPort(policy)
+				port = &defaultPort
+			}
+			route.Spec.Rules[j].Filters = nil
+			route.Spec.Rules[j].BackendRefs = []gatewayv1.HTTPBackendRef{{
+				BackendRef: gatewayv1.BackendRef{
+					BackendObjectReference: gatewayv1.BackendObjectReference{
+						Kind:      &kind,
+						Namespace: backendNS,
+						Name:      gatewayv1.ObjectName(mainServiceName(policy)),
+						Port:      port,
+					},
+					Weight: &weight,
+				},
+			}}
+		}
+		if err := r.apply(ctx, route); err != nil {
+			return false, err
+		}
+		if !routeAccepted(policy, route.Namespace, route.Generation, route.Status.Parents) {
+			drained = false
+		}
+	}
+
+	grpcRoutes := &gatewayv1.GRPCRouteList{}
+	if err := r.List(ctx, grpcRoutes, client.InNamespace(policy.Namespace), labels); err != nil {
+		return false, err
+	}
+	for i := range grpcRoutes.Items {
+		route := &grpcRoutes.Items[i]
+		for j := range route.Spec.Rules {
+			var port *gatewayv1.PortNumber
+			if refs := route.Spec.Rules[j].BackendRefs; len(refs) > 0 {
+				port = refs[0].Port
+			}
+			route.Spec.Rules[j].Filters = nil
+			route.Spec.Rules[j].BackendRefs = []gatewayv1.GRPCBackendRef{{
+				BackendRef: gatewayv1.BackendRef{
+					BackendObjectReference: gatewayv1.BackendObjectReference{
+						Kind:      &kind,
+						Namespace: backendNS,
+						Name:      gatewayv1.ObjectName(mainServiceName(policy)),
+						Port:      port,
+					},
+					Weight: &weight,
+				},
+			}}
+		}
+		if err := r.apply(ctx, route); err != nil {
+			return false, err
+		}
+		if !routeAccepted(policy, route.Namespace, route.Generation, route.Status.Parents) {
+			drained = false
+		}
+	}
+
+	return drained, nil
+}
+
+// routeAccepted reports whether the policy's gateway has accepted the given
+// generation of a route, based on the route's status.parents.
+func routeAccepted(
+	policy *esv1alpha1.EndpointPolicy,
+	routeNamespace string,
+	generation int64,
+	parents []gatewayv1.RouteParentStatus,
+) bool {
+	parent := findGatewayParent(policy, routeNamespace, parents)
+	if parent == nil {
+		return false
+	}
+	cond := meta.FindStatusCondition(parent.Conditions, string(gatewayv1.RouteConditionAccepted))
+	return cond != nil && cond.Status == metav1.ConditionTrue && cond.ObservedGeneration >= generation
+}
+
+// findGatewayParent returns the status entry written for the policy's gateway.
+func findGatewayParent(
+	policy *esv1alpha1.EndpointPolicy,
+	routeNamespace string,
+	parents []gatewayv1.RouteParentStatus,
+) *gatewayv1.RouteParentStatus {
+	gatewayNS := policy.Spec.GatewayRef.Namespace
+	if gatewayNS == "" {
+		gatewayNS = routeNamespace
+	}
+	for i := range parents {
+		ref := parents[i].ParentRef
+		ns := routeNamespace
+		if ref.Namespace != nil {
+			ns = string(*ref.Namespace)
+		}
+		if string(ref.Name) == policy.Spec.GatewayRef.Name && ns == gatewayNS {
+			return &parents[i]
+		}
+	}
+	return nil
+}
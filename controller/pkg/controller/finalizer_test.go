@@ -0,0 +1,280 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+)
+
+func TestFinalize_DrainsRoutesBeforeRemovingWorkloads(t *testing.T) {
+	policy := testEndpointPolicy()
+	policy.Spec.Endpoints = policy.Spec.Endpoints[:1]
+	policy.UID = "policy-uid"
+	policy.Finalizers = []string{finalizerName}
+	mainSvc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "my-app-svc", Namespace: "default"}}
+
+	r := newFakeReconciler(t, policy, mainSvc, testGateway())
+	ctx := context.Background()
+	req := reconcileRequest(policy)
+
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	latest := &esv1alpha1.EndpointPolicy{}
+	if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Delete(ctx, latest); err != nil {
+		t.Fatal(err)
+	}
+
+	// First pass: route is shifted to the main service, workloads stay
+	result, err := r.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RequeueAfter == 0 {
+		t.Error("expected requeue while waiting for the gateway")
+	}
+
+	routeKey := types.NamespacedName{Name: "my-app-lookup", Namespace: "default"}
+	route := &gatewayv1.HTTPRoute{}
+	if err := r.Get(ctx, routeKey, route); err != nil {
+		t.Fatalf("expected route to remain: %v", err)
+	}
+	refs := route.Spec.Rules[0].BackendRefs
+	if len(refs) != 1 || string(refs[0].Name) != "my-app-svc" || *refs[0].Weight != 100 {
+		t.Errorf("expected route drained to my-app-svc, got %v", refs)
+	}
+
+	depKey := types.NamespacedName{Name: "my-app-lookup", Namespace: "default"}
+	if err := r.Get(ctx, depKey, &appsv1.Deployment{}); err != nil {
+		t.Errorf("expected deployment to remain until the route is accepted: %v", err)
+	}
+
+	// Gateway accepts the drained route
+	gatewayNS := gatewayv1.Namespace("gateway-ns")
+	route.Status.Parents = []gatewayv1.RouteParentStatus{{
+		ParentRef:      gatewayv1.ParentReference{Name: "my-gateway", Namespace: &gatewayNS},
+		ControllerName: "example.com/gateway",
+		Conditions: []metav1.Condition{{
+			Type:               string(gatewayv1.RouteConditionAccepted),
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: route.Generation,
+			Reason:             "Accepted",
+			LastTransitionTime: metav1.Now(),
+		}},
+	}}
+	if err := r.Status().Update(ctx, route); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := r.Get(ctx, depKey, &appsv1.Deployment{}); err == nil {
+		t.Error("expected deployment to be deleted")
+	}
+	if err := r.Get(ctx, req.NamespacedName, &esv1alpha1.EndpointPolicy{}); err == nil {
+		t.Error("expected policy to be gone once the finalizer is removed")
+	}
+}
+
+func TestFinalize_KeepsRedirectRulesWithoutBackends(t *testing.T) {
+	policy := testEndpointPolicy()
+	policy.UID = "policy-uid"
+	policy.Finalizers = []string{finalizerName}
+	policy.Spec.Endpoints[1].Strategy = StrategyPrimary
+	policy.Spec.Endpoints[1].Filters = []esv1alpha1.HTTPFilter{{
+		Type:            "RequestRedirect",
+		RequestRedirect: &esv1alpha1.RequestRedirectFilter{Hostname: "api.example.com"},
+	}}
+	mainSvc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "my-app-svc", Namespace: "default"}}
+
+	r := newFakeReconciler(t, policy, mainSvc, testGateway())
+	ctx := context.Background()
+	req := reconcileRequest(policy)
+
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	latest := &esv1alpha1.EndpointPolicy{}
+	if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Delete(ctx, latest); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	redirect := &gatewayv1.HTTPRoute{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "my-app-fallback-endpoint", Namespace: "default"}, redirect); err != nil {
+		t.Fatal(err)
+	}
+	rule := redirect.Spec.Rules[0]
+	if len(rule.BackendRefs) != 0 {
+		t.Errorf("expected the redirect rule to stay without backends, got %+v", rule.BackendRefs)
+	}
+	if len(rule.Filters) != 1 || rule.Filters[0].RequestRedirect == nil {
+		t.Errorf("expected the redirect filter to be kept, got %+v", rule.Filters)
+	}
+
+	drained := &gatewayv1.HTTPRoute{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "my-app-lookup", Namespace: "default"}, drained); err != nil {
+		t.Fatal(err)
+	}
+	refs := drained.Spec.Rules[0].BackendRefs
+	if len(refs) != 1 || string(refs[0].Name) != "my-app-svc" || refs[0].Port == nil || *refs[0].Port != backendPort(policy) {
+		t.Errorf("expected route drained to my-app-svc on the service port, got %+v", refs)
+	}
+}
+
+func TestFinalize_RemovesFilters(t *testing.T) {
+	for _, strategy := range []string{StrategyShadow, StrategyCanary} {
+		t.Run(strategy, func(t *testing.T) {
+			policy := testEndpointPolicy()
+			policy.Spec.Endpoints = policy.Spec.Endpoints[:1]
+			policy.UID = "policy-uid"
+			policy.Finalizers = []string{finalizerName}
+			endpoint := &policy.Spec.Endpoints[0]
+			endpoint.Strategy = strategy
+			endpoint.Filters = rewriteFilters()
+			mainSvc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "my-app-svc", Namespace: "default"}}
+
+			r := newFakeReconciler(t, policy, mainSvc, testGateway())
+			ctx := context.Background()
+			req := reconcileRequest(policy)
+
+			// The route falls back to the main service until the endpoint
+			// has ready pods
+			for _, available := range []bool{false, true} {
+				if available {
+					markDeploymentAvailable(t, r, "my-app-lookup")
+				}
+				if _, err := r.Reconcile(ctx, req); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			routeKey := types.NamespacedName{Name: "my-app-lookup", Namespace: "default"}
+			route := &gatewayv1.HTTPRoute{}
+			if err := r.Get(ctx, routeKey, route); err != nil {
+				t.Fatal(err)
+			}
+			if len(route.Spec.Rules[0].Filters) == 0 {
+				t.Fatal("expected the rule to be filtered before deletion")
+			}
+
+			latest := &esv1alpha1.EndpointPolicy{}
+			if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+				t.Fatal(err)
+			}
+			if err := r.Delete(ctx, latest); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := r.Reconcile(ctx, req); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := r.Get(ctx, routeKey, route); err != nil {
+				t.Fatal(err)
+			}
+			rule := route.Spec.Rules[0]
+			if len(rule.Filters) != 0 {
+				t.Errorf("expected the drained rule to be unfiltered, got %+v", rule.Filters)
+			}
+			if len(rule.BackendRefs) != 1 || rule.BackendRefs[0].Name != "my-app-svc" || rule.BackendRefs[0].Filters != nil {
+				t.Errorf("expected an unfiltered main service backend, got %+v", rule.BackendRefs)
+			}
+		})
+	}
+}
+
+func TestFinalize_TimesOutWaitingForGateway(t *testing.T) {
+	policy := testEndpointPolicy()
+	policy.Spec.Endpoints = policy.Spec.Endpoints[:1]
+	policy.Finalizers = []string{finalizerName}
+	deleted := metav1.NewTime(time.Now().Add(-2 * teardownTimeout))
+	policy.DeletionTimestamp = &deleted
+	mainSvc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "my-app-svc", Namespace: "default"}}
+
+	r := newFakeReconciler(t, policy, mainSvc)
+
+	result, err := r.Reconcile(context.Background(), reconcileRequest(policy))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RequeueAfter != 0 {
+		t.Errorf("expected teardown to continue after timeout, got requeue %v", result.RequeueAfter)
+	}
+}
+
+func TestFinalize_TimesOutFailingDrain(t *testing.T) {
+	policy := testEndpointPolicy()
+	policy.Spec.Endpoints = policy.Spec.Endpoints[:1]
+	policy.Finalizers = []string{finalizerName}
+	deleted := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	policy.DeletionTimestamp = &metav1.Time{Time: deleted}
+	mainSvc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "my-app-svc", Namespace: "default"}}
+
+	r := newFakeReconciler(t, policy, mainSvc)
+	r.Client = interceptor.NewClient(r.Client.(client.WithWatch), interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if _, ok := obj.(*corev1.Service); ok && key.Name == "my-app-svc" {
+				return errors.New("service unavailable")
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+	})
+	clock := clocktesting.NewFakeClock(deleted.Add(time.Minute))
+	r.Clock = clock
+	ctx := context.Background()
+	req := reconcileRequest(policy)
+
+	if _, err := r.Reconcile(ctx, req); err == nil {
+		t.Fatal("expected the drain error before the teardown timeout")
+	}
+
+	clock.SetTime(deleted.Add(teardownTimeout + time.Second))
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("expected teardown to continue after the timeout, got %v", err)
+	}
+	if err := r.Get(ctx, req.NamespacedName, &esv1alpha1.EndpointPolicy{}); err == nil {
+		t.Error("expected policy to be gone once the finalizer is removed")
+	}
+}
+
+// testGateway is the Gateway referenced by testEndpointPolicy, open to routes
+// from every namespace.
+func testGateway() *gatewayv1.Gateway {
+	all := gatewayv1.NamespacesFromAll
+	return &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-gateway", Namespace: "gateway-ns"},
+		Spec: gatewayv1.GatewaySpec{
+			GatewayClassName: "example",
+			Listeners: []gatewayv1.Listener{{
+				Name:     "http",
+				Port:     80,
+				Protocol: gatewayv1.HTTPProtocolType,
+				AllowedRoutes: &gatewayv1.AllowedRoutes{
+					Namespaces: &gatewayv1.RouteNamespaces{From: &all},
+				},
+			}},
+		},
+	}
+}
@@ -5,9 +5,8 @@ import (
 
 	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
-	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
@@ -19,32 +18,25 @@ func (r *EndpointPolicyReconciler) reconcileHPA(
 	policy *esv1alpha1.EndpointPolicy,
 	endpoint *esv1alpha1.EndpointSpec,
 ) error {
-	if endpoint.HPA == nil {
-		return nil
-	}
-
 	logger := log.FromContext(ctx)
 	name := endpointResourceName(policy, endpoint)
 
-	desired := r.buildHPA(policy, endpoint)
-	if err := ctrl.SetControllerReference(policy, desired, r.Scheme); err != nil {
-		return err
+	// An endpoint that dropped its HPA, or moved to KEDA, must not keep the
+	// old HPA fighting over the replica count.
+	if endpoint.HPA == nil {
+		stale := &autoscalingv2.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: appNamespace(policy)},
+		}
+		return client.IgnoreNotFound(r.Delete(ctx, stale))
 	}
 
-	existing := &autoscalingv2.HorizontalPodAutoscaler{}
-	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: policy.Namespace}, existing)
-	if err != nil {
-		if client.IgnoreNotFound(err) != nil {
-			return err
-		}
-		logger.Info("Creating HPA", "name", name)
-		return r.Create(ctx, desired)
+	desired := r.buildHPA(policy, endpoint)
+	if err := r.setOwner(policy, desired); err != nil {
+		return err
 	}
 
-	existing.Spec = desired.Spec
-	existing.Labels = desired.Labels
-	logger.Info("Updating HPA", "name", name)
-	return r.Update(ctx, existing)
+	logger.Info("Applying HPA", "name", name)
+	return r.apply(ctx, desired)
 }
 
 func (r *EndpointPolicyReconciler) buildHPA(
@@ -52,19 +44,18 @@ func (r *EndpointPolicyReconciler) buildHPA(
 	endpoint *esv1alpha1.EndpointSpec,
 ) *autoscalingv2.HorizontalPodAutoscaler {
 	name := endpointResourceName(policy, endpoint)
-	labels := generateLabels(policy, endpoint)
 
 	hpa := &autoscalingv2.HorizontalPodAutoscaler{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
-			Namespace: policy.Namespace,
-			Labels:    labels,
+			Namespace: appNamespace(policy),
+			Labels:    objectLabels(policy, endpoint),
 		},
 		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
 			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
 				APIVersion: "apps/v1",
 				Kind:       "Deployment",
-				Name:       name,
+				Name:       endpointWorkloadName(policy, endpoint),
 			},
 			MinReplicas: &endpoint.HPA.Min,
 			MaxReplicas: endpoint.HPA.Max,
@@ -98,5 +89,104 @@ func (r *EndpointPolicyReconciler) buildHPA(
 		})
 	}
 
+	for i := range endpoint.HPA.Metrics {
+		hpa.Spec.Metrics = append(hpa.Spec.Metrics, buildMetricSpec(endpoint, &endpoint.HPA.Metrics[i]))
+	}
+
+	if b := endpoint.HPA.Behavior; b != nil {
+		hpa.Spec.Behavior = &autoscalingv2.HorizontalPodAutoscalerBehavior{
+			ScaleUp:   buildScalingRules(b.ScaleUp),
+			ScaleDown: buildScalingRules(b.ScaleDown),
+		}
+	}
+
 	return hpa
 }
+
+func buildScalingRules(rules *esv1alpha1.HPAScalingRules) *autoscalingv2.HPAScalingRules {
+	if rules == nil {
+		return nil
+	}
+	out := &autoscalingv2.HPAScalingRules{
+		StabilizationWindowSeconds: rules.StabilizationWindowSeconds,
+	}
+	if rules.SelectPolicy != "" {
+		selectPolicy := autoscalingv2.ScalingPolicySelect(rules.SelectPolicy)
+		out.SelectPolicy = &selectPolicy
+	}
+	for _, p := range rules.Policies {
+		out.Policies = append(out.Policies, autoscalingv2.HPAScalingPolicy{
+			Type:          autoscalingv2.HPAScalingPolicyType(p.Type),
+			Value:         p.Value,
+			PeriodSeconds: p.PeriodSeconds,
+		})
+	}
+	return out
+}
+
+// buildMetricSpec renders an HPAMetric as an autoscaling/v2 MetricSpec.
+// Quantities were checked by validation, so parse errors leave the target
+// value unset.
+func buildMetricSpec(endpoint *esv1alpha1.EndpointSpec, m *esv1alpha1.HPAMetric) autoscalingv2.MetricSpec {
+	target := autoscalingv2.MetricTarget{}
+	switch {
+	case m.AverageUtilization != nil:
+		target.Type = autoscalingv2.UtilizationMetricType
+		target.AverageUtilization = m.AverageUtilization
+	case m.Value != "":
+		target.Type = autoscalingv2.ValueMetricType
+		if q, err := resource.ParseQuantity(m.Value); err == nil {
+			target.Value = &q
+		}
+	default:
+		target.Type = autoscalingv2.AverageValueMetricType
+		if q, err := resource.ParseQuantity(m.AverageValue); err == nil {
+			target.AverageValue = &q
+		}
+	}
+
+	metric := autoscalingv2.MetricIdentifier{Name: m.Name, Selector: m.Selector}
+
+	switch m.Type {
+	case "Pods":
+		return autoscalingv2.MetricSpec{
+			Type: autoscalingv2.PodsMetricSourceType,
+			Pods: &autoscalingv2.PodsMetricSource{Metric: metric, Target: target},
+		}
+	case "Object":
+		described := autoscalingv2.CrossVersionObjectReference{}
+		if m.DescribedObject != nil {
+			described = autoscalingv2.CrossVersionObjectReference{
+				APIVersion: m.DescribedObject.APIVersion,
+				Kind:       m.DescribedObject.Kind,
+				Name:       m.DescribedObject.Name,
+			}
+		}
+		return autoscalingv2.MetricSpec{
+			Type: autoscalingv2.ObjectMetricSourceType,
+			Object: &autoscalingv2.ObjectMetricSource{
+				DescribedObject: described,
+				Metric:          metric,
+				Target:          target,
+			},
+		}
+	case "ContainerResource":
+		container := m.Container
+		if container == "" {
+			container = endpoint.ID
+		}
+		return autoscalingv2.MetricSpec{
+			Type: autoscalingv2.ContainerResourceMetricSourceType,
+			ContainerResource: &autoscalingv2.ContainerResourceMetricSource{
+				Name:      corev1.ResourceName(m.Name),
+				Container: container,
+				Target:    target,
+			},
+		}
+	default:
+		return autoscalingv2.MetricSpec{
+			Type:     autoscalingv2.ExternalMetricSourceType,
+			External: &autoscalingv2.ExternalMetricSource{Metric: metric, Target: target},
+		}
+	}
+}
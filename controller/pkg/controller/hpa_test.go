@@ -3,6 +3,7 @@ package controller
 import (
 	"testing"
 
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -174,3 +175,167 @@ func TestBuildHPA_NoMetrics(t *testing.T) {
 		t.Errorf("expected 0 metrics, got %d", len(hpa.Spec.Metrics))
 	}
 }
+
+func TestBuildHPA_CustomMetrics(t *testing.T) {
+	r := &EndpointPolicyReconciler{}
+	utilization := int32(60)
+	policy := &esv1alpha1.EndpointPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-policy",
+			Namespace: "default",
+		},
+		Spec: esv1alpha1.EndpointPolicySpec{
+			AppRef: esv1alpha1.AppReference{
+				Name: "my-app",
+			},
+			GatewayRef: esv1alpha1.GatewayReference{
+				Name: "my-gateway",
+			},
+			Endpoints: []esv1alpha1.EndpointSpec{
+				{
+					ID: "lookup",
+					HPA: &esv1alpha1.HPASpec{
+						Min: 1,
+						Max: 20,
+						Metrics: []esv1alpha1.HPAMetric{
+							{Type: "Pods", Name: "http_requests_per_second", AverageValue: "100"},
+							{
+								Type:            "Object",
+								Name:            "requests_per_second",
+								DescribedObject: &esv1alpha1.MetricObjectReference{APIVersion: "networking.k8s.io/v1", Kind: "Ingress", Name: "main"},
+								Value:           "2k",
+							},
+							{
+								Type:     "External",
+								Name:     "queue_depth",
+								Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"queue": "lookup"}},
+								Value:    "30",
+							},
+							{Type: "ContainerResource", Name: "cpu", AverageUtilization: &utilization},
+						},
+					},
+				},
+			},
+		},
+	}
+	endpoint := &policy.Spec.Endpoints[0]
+
+	hpa := r.buildHPA(policy, endpoint)
+
+	if len(hpa.Spec.Metrics) != 4 {
+		t.Fatalf("expected 4 metrics, got %d", len(hpa.Spec.Metrics))
+	}
+
+	pods := hpa.Spec.Metrics[0]
+	if pods.Type != autoscalingv2.PodsMetricSourceType || pods.Pods == nil {
+		t.Fatalf("expected Pods metric, got %+v", pods)
+	}
+	if pods.Pods.Metric.Name != "http_requests_per_second" {
+		t.Errorf("expected metric name http_requests_per_second, got %s", pods.Pods.Metric.Name)
+	}
+	if pods.Pods.Target.Type != autoscalingv2.AverageValueMetricType || pods.Pods.Target.AverageValue.String() != "100" {
+		t.Errorf("expected AverageValue target 100, got %+v", pods.Pods.Target)
+	}
+
+	object := hpa.Spec.Metrics[1]
+	if object.Type != autoscalingv2.ObjectMetricSourceType || object.Object == nil {
+		t.Fatalf("expected Object metric, got %+v", object)
+	}
+	if object.Object.DescribedObject.Kind != "Ingress" || object.Object.DescribedObject.Name != "main" {
+		t.Errorf("expected described Ingress main, got %+v", object.Object.DescribedObject)
+	}
+	if object.Object.Target.Type != autoscalingv2.ValueMetricType || object.Object.Target.Value.String() != "2k" {
+		t.Errorf("expected Value target 2k, got %+v", object.Object.Target)
+	}
+
+	external := hpa.Spec.Metrics[2]
+	if external.Type != autoscalingv2.ExternalMetricSourceType || external.External == nil {
+		t.Fatalf("expected External metric, got %+v", external)
+	}
+	if external.External.Metric.Selector == nil || external.External.Metric.Selector.MatchLabels["queue"] != "lookup" {
+		t.Errorf("expected selector queue=lookup, got %+v", external.External.Metric.Selector)
+	}
+
+	container := hpa.Spec.Metrics[3]
+	if container.Type != autoscalingv2.ContainerResourceMetricSourceType || container.ContainerResource == nil {
+		t.Fatalf("expected ContainerResource metric, got %+v", container)
+	}
+	if container.ContainerResource.Container != "lookup" {
+		t.Errorf("expected container to default to the endpoint container, got %s", container.ContainerResource.Container)
+	}
+	if container.ContainerResource.Name != corev1.ResourceCPU || *container.ContainerResource.Target.AverageUtilization != 60 {
+		t.Errorf("expected cpu utilization 60, got %+v", container.ContainerResource)
+	}
+}
+
+func TestBuildHPA_Behavior(t *testing.T) {
+	r := &EndpointPolicyReconciler{}
+	cpuTarget := int32(70)
+	window := int32(300)
+	policy := &esv1alpha1.EndpointPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-policy",
+			Namespace: "default",
+		},
+		Spec: esv1alpha1.EndpointPolicySpec{
+			AppRef: esv1alpha1.AppReference{
+				Name: "my-app",
+			},
+			GatewayRef: esv1alpha1.GatewayReference{
+				Name: "my-gateway",
+			},
+			Endpoints: []esv1alpha1.EndpointSpec{
+				{
+					ID: "compute",
+					HPA: &esv1alpha1.HPASpec{
+						Min:       1,
+						Max:       10,
+						CPUTarget: &cpuTarget,
+						Behavior: &esv1alpha1.HPABehavior{
+							ScaleDown: &esv1alpha1.HPAScalingRules{
+								StabilizationWindowSeconds: &window,
+								Policies: []esv1alpha1.HPAScalingPolicy{
+									{Type: "Pods", Value: 1, PeriodSeconds: 60},
+								},
+							},
+							ScaleUp: &esv1alpha1.HPAScalingRules{
+								SelectPolicy: "Min",
+								Policies: []esv1alpha1.HPAScalingPolicy{
+									{Type: "Percent", Value: 100, PeriodSeconds: 30},
+									{Type: "Pods", Value: 4, PeriodSeconds: 30},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	endpoint := &policy.Spec.Endpoints[0]
+
+	hpa := r.buildHPA(policy, endpoint)
+
+	behavior := hpa.Spec.Behavior
+	if behavior == nil || behavior.ScaleUp == nil || behavior.ScaleDown == nil {
+		t.Fatalf("expected scaleUp and scaleDown behavior, got %+v", behavior)
+	}
+
+	down := behavior.ScaleDown
+	if down.StabilizationWindowSeconds == nil || *down.StabilizationWindowSeconds != 300 {
+		t.Errorf("expected scaleDown window 300, got %v", down.StabilizationWindowSeconds)
+	}
+	if down.SelectPolicy != nil {
+		t.Errorf("expected scaleDown selectPolicy to be left to the default, got %v", *down.SelectPolicy)
+	}
+	if len(down.Policies) != 1 || down.Policies[0].Type != autoscalingv2.PodsScalingPolicy || down.Policies[0].Value != 1 {
+		t.Errorf("expected one Pods policy of 1, got %+v", down.Policies)
+	}
+
+	up := behavior.ScaleUp
+	if up.SelectPolicy == nil || *up.SelectPolicy != autoscalingv2.MinChangePolicySelect {
+		t.Errorf("expected scaleUp selectPolicy Min, got %v", up.SelectPolicy)
+	}
+	if len(up.Policies) != 2 || up.Policies[0].Type != autoscalingv2.PercentScalingPolicy || up.Policies[0].PeriodSeconds != 30 {
+		t.Errorf("expected Percent and Pods policies, got %+v", up.Policies)
+	}
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"context"
+	"errors"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+)
+
+// scaledObjectGVK is the KEDA ScaledObject kind. KEDA is an optional
+// dependency, so ScaledObjects are handled as unstructured objects rather
+// than through KEDA's Go types.
+var scaledObjectGVK = schema.GroupVersionKind{Group: "keda.sh", Version: "v1alpha1", Kind: "ScaledObject"}
+
+var errKEDANotInstalled = errors.New("KEDA is not installed: the keda.sh/v1alpha1 ScaledObject CRD was not found")
+
+// kedaInstalled reports whether the cluster serves ScaledObjects.
+func kedaInstalled(mapper meta.RESTMapper) (bool, error) {
+	_, err := mapper.RESTMapping(scaledObjectGVK.GroupKind(), scaledObjectGVK.Version)
+	if meta.IsNoMatchError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// reconcileScaledObject applies the ScaledObject of a KEDA endpoint, and
+// removes one left behind when the endpoint switched to another autoscaler.
+func (r *EndpointPolicyReconciler) reconcileScaledObject(
+	ctx context.Context,
+	policy *esv1alpha1.EndpointPolicy,
+	endpoint *esv1alpha1.EndpointSpec,
+) error {
+	installed, err := kedaInstalled(r.RESTMapper())
+	if err != nil {
+		return err
+	}
+
+	name := endpointResourceName(policy, endpoint)
+	if !endpoint.UsesKEDA() {
+		if !installed {
+			return nil
+		}
+		stale := &unstructured.Unstructured{}
+		stale.SetGroupVersionKind(scaledObjectGVK)
+		stale.SetName(name)
+		stale.SetNamespace(appNamespace(policy))
+		return client.IgnoreNotFound(r.Delete(ctx, stale))
+	}
+	if !installed {
+		return errKEDANotInstalled
+	}
+
+	desired := buildScaledObject(policy, endpoint)
+	if err := r.setOwner(policy, desired); err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Info("Applying ScaledObject", "name", name)
+	return r.apply(ctx, desired)
+}
+
+// buildScaledObject renders the endpoint's KEDA configuration as a
+// ScaledObject targeting the endpoint Deployment.
+func buildScaledObject(
+	policy *esv1alpha1.EndpointPolicy,
+	endpoint *esv1alpha1.EndpointSpec,
+) *unstructured.Unstructured {
+	keda := endpoint.Autoscaler.Keda
+	name := endpointResourceName(policy, endpoint)
+
+	minReplicas := int64(0)
+	if keda.MinReplicaCount != nil {
+		minReplicas = int64(*keda.MinReplicaCount)
+	}
+	spec := map[string]interface{}{
+		"scaleTargetRef": map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"name":       endpointWorkloadName(policy, endpoint),
+		},
+		"minReplicaCount": minReplicas,
+		"maxReplicaCount": int64(keda.MaxReplicaCount),
+	}
+	if keda.PollingInterval != nil {
+		spec["pollingInterval"] = int64(*keda.PollingInterval)
+	}
+	if keda.CooldownPeriod != nil {
+		spec["cooldownPeriod"] = int64(*keda.CooldownPeriod)
+	}
+
+	triggers := make([]interface{}, 0, len(keda.Triggers))
+	for _, t := range keda.Triggers {
+		trigger := map[string]interface{}{"type": t.Type}
+		if t.Name != "" {
+			trigger["name"] = t.Name
+		}
+		metadata := map[string]interface{}{}
+		for k, v := range t.Metadata {
+			metadata[k] = v
+		}
+		trigger["metadata"] = metadata
+		if t.MetricType != "" {
+			trigger["metricType"] = t.MetricType
+		}
+		if ref := t.AuthenticationRef; ref != nil {
+			authRef := map[string]interface{}{"name": ref.Name}
+			if ref.Kind != "" {
+				authRef["kind"] = ref.Kind
+			}
+			trigger["authenticationRef"] = authRef
+		}
+		triggers = append(triggers, trigger)
+	}
+	spec["triggers"] = triggers
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetGroupVersionKind(scaledObjectGVK)
+	obj.SetName(name)
+	obj.SetNamespace(appNamespace(policy))
+	obj.SetLabels(objectLabels(policy, endpoint))
+	return obj
+}
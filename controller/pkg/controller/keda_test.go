@@ -0,0 +1,228 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+)
+
+// newKEDAReconciler returns a fake reconciler whose cluster serves the KEDA
+// ScaledObject CRD.
+func newKEDAReconciler(t *testing.T, objs ...client.Object) *EndpointPolicyReconciler {
+	t.Helper()
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(scaledObjectGVK, meta.RESTScopeNamespace)
+	return newFakeReconcilerWithMapper(t, mapper, objs...)
+}
+
+func kedaPolicy() *esv1alpha1.EndpointPolicy {
+	cooldown := int32(600)
+	return &esv1alpha1.EndpointPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-policy",
+			Namespace: "default",
+			UID:       "policy-uid",
+		},
+		Spec: esv1alpha1.EndpointPolicySpec{
+			AppRef: esv1alpha1.AppReference{
+				Name:  "my-app",
+				Image: "my-app:v1",
+			},
+			GatewayRef: esv1alpha1.GatewayReference{
+				Name: "my-gateway",
+			},
+			Endpoints: []esv1alpha1.EndpointSpec{
+				{
+					ID:    "reports",
+					Type:  "http",
+					Match: esv1alpha1.MatchSpec{Path: "/api/reports"},
+					Autoscaler: &esv1alpha1.AutoscalerSpec{
+						Kind: "keda",
+						Keda: &esv1alpha1.KEDASpec{
+							MaxReplicaCount: 5,
+							CooldownPeriod:  &cooldown,
+							Triggers: []esv1alpha1.KEDATrigger{
+								{
+									Type:     "rabbitmq",
+									Metadata: map[string]string{"queueName": "reports", "mode": "QueueLength", "value": "20"},
+									AuthenticationRef: &esv1alpha1.KEDAAuthenticationRef{
+										Name: "rabbitmq-auth",
+									},
+								},
+								{
+									Type:     "cron",
+									Name:     "business-hours",
+									Metadata: map[string]string{"timezone": "Europe/Berlin", "start": "0 8 * * 1-5", "end": "0 18 * * 1-5", "desiredReplicas": "1"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func getScaledObject(ctx context.Context, c client.Client, key types.NamespacedName) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(scaledObjectGVK)
+	return obj, c.Get(ctx, key, obj)
+}
+
+func TestBuildScaledObject(t *testing.T) {
+	policy := kedaPolicy()
+	obj := buildScaledObject(policy, &policy.Spec.Endpoints[0])
+
+	if obj.GetName() != "my-app-reports" || obj.GetNamespace() != "default" {
+		t.Errorf("expected default/my-app-reports, got %s/%s", obj.GetNamespace(), obj.GetName())
+	}
+	if obj.GetLabels()["endpointscaler.io/endpoint"] != "reports" {
+		t.Errorf("expected endpoint label, got %v", obj.GetLabels())
+	}
+
+	target, _, _ := unstructured.NestedString(obj.Object, "spec", "scaleTargetRef", "name")
+	if target != "my-app-reports" {
+		t.Errorf("expected scaleTargetRef my-app-reports, got %q", target)
+	}
+	minReplicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "minReplicaCount")
+	if !found || minReplicas != 0 {
+		t.Errorf("expected minReplicaCount 0, got %d (found %v)", minReplicas, found)
+	}
+	maxReplicas, _, _ := unstructured.NestedInt64(obj.Object, "spec", "maxReplicaCount")
+	if maxReplicas != 5 {
+		t.Errorf("expected maxReplicaCount 5, got %d", maxReplicas)
+	}
+	cooldown, _, _ := unstructured.NestedInt64(obj.Object, "spec", "cooldownPeriod")
+	if cooldown != 600 {
+		t.Errorf("expected cooldownPeriod 600, got %d", cooldown)
+	}
+	if _, found, _ := unstructured.NestedInt64(obj.Object, "spec", "pollingInterval"); found {
+		t.Error("expected pollingInterval to be left to KEDA's default")
+	}
+
+	triggers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "triggers")
+	if len(triggers) != 2 {
+		t.Fatalf("expected 2 triggers, got %d", len(triggers))
+	}
+	queue := triggers[0].(map[string]interface{})
+	if queue["type"] != "rabbitmq" {
+		t.Errorf("expected rabbitmq trigger, got %v", queue["type"])
+	}
+	if v, _, _ := unstructured.NestedString(queue, "metadata", "queueName"); v != "reports" {
+		t.Errorf("expected queueName reports, got %q", v)
+	}
+	if v, _, _ := unstructured.NestedString(queue, "authenticationRef", "name"); v != "rabbitmq-auth" {
+		t.Errorf("expected authenticationRef rabbitmq-auth, got %q", v)
+	}
+	if cron := triggers[1].(map[string]interface{}); cron["name"] != "business-hours" {
+		t.Errorf("expected cron trigger name business-hours, got %v", cron["name"])
+	}
+}
+
+func TestReconcile_KEDAEndpoint(t *testing.T) {
+	policy := kedaPolicy()
+	r := newKEDAReconciler(t, policy)
+	ctx := context.Background()
+	req := reconcileRequest(policy)
+	key := types.NamespacedName{Name: "my-app-reports", Namespace: "default"}
+
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	scaledObject, err := getScaledObject(ctx, r.Client, key)
+	if err != nil {
+		t.Fatalf("expected ScaledObject: %v", err)
+	}
+	if owners := scaledObject.GetOwnerReferences(); len(owners) != 1 || owners[0].UID != "policy-uid" {
+		t.Errorf("expected the policy as owner, got %v", owners)
+	}
+
+	dep := &appsv1.Deployment{}
+	if err := r.Get(ctx, key, dep); err != nil {
+		t.Fatalf("expected deployment: %v", err)
+	}
+	if dep.Spec.Replicas != nil && *dep.Spec.Replicas != 1 {
+		t.Errorf("expected replicas to be left to KEDA, got %d", *dep.Spec.Replicas)
+	}
+	if err := r.Get(ctx, key, &autoscalingv2.HorizontalPodAutoscaler{}); err == nil {
+		t.Error("expected no native HPA for a KEDA endpoint")
+	}
+
+	// Switching to a native HPA replaces the ScaledObject
+	latest := &esv1alpha1.EndpointPolicy{}
+	if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+		t.Fatal(err)
+	}
+	cpu := int32(70)
+	latest.Spec.Endpoints[0].Autoscaler = nil
+	latest.Spec.Endpoints[0].HPA = &esv1alpha1.HPASpec{Min: 1, Max: 5, CPUTarget: &cpu}
+	if err := r.Update(ctx, latest); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := getScaledObject(ctx, r.Client, key); err == nil {
+		t.Error("expected ScaledObject to be deleted after switching to an HPA")
+	}
+	if err := r.Get(ctx, key, &autoscalingv2.HorizontalPodAutoscaler{}); err != nil {
+		t.Errorf("expected native HPA: %v", err)
+	}
+
+	// Switching back removes the HPA, and pruning covers ScaledObjects
+	if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+		t.Fatal(err)
+	}
+	latest.Spec.Endpoints[0].HPA = nil
+	latest.Spec.Endpoints[0].Autoscaler = kedaPolicy().Spec.Endpoints[0].Autoscaler
+	if err := r.Update(ctx, latest); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Get(ctx, key, &autoscalingv2.HorizontalPodAutoscaler{}); err == nil {
+		t.Error("expected native HPA to be deleted after switching to KEDA")
+	}
+	if _, err := getScaledObject(ctx, r.Client, key); err != nil {
+		t.Fatalf("expected ScaledObject after switching back to KEDA: %v", err)
+	}
+	if err := r.pruneStale(ctx, latest, map[string]bool{}, map[string]bool{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := getScaledObject(ctx, r.Client, key); err == nil {
+		t.Error("expected ScaledObject to be pruned")
+	}
+}
+
+func TestReconcile_KEDANotInstalled(t *testing.T) {
+	policy := kedaPolicy()
+	r := newFakeReconciler(t, policy)
+	ctx := context.Background()
+	req := reconcileRequest(policy)
+
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	latest := &esv1alpha1.EndpointPolicy{}
+	if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+		t.Fatal(err)
+	}
+	status := latest.Status.EndpointStatuses[0]
+	if status.Ready || !strings.Contains(status.Message, "KEDA is not installed") {
+		t.Errorf("expected KEDA not installed error, got ready=%v message=%q", status.Ready, status.Message)
+	}
+}
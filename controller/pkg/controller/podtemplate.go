@@ -0,0 +1,186 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+)
+
+const (
+	// templateContainerName is the container in a user template that is
+	// merged into the endpoint container.
+	templateContainerName = "app"
+
+	guardrailEnvName = "ENDPOINTSCALER_GUARDRAIL"
+
+	// fallbackEnvName is the URL of the main service, which the SDK guard can
+	// proxy misrouted requests to.
+	fallbackEnvName = "ENDPOINTSCALER_FALLBACK_URL"
+
+	// forwardTokenEnvName is shared by the endpoint pods of a policy. The SDK
+	// guard marks the requests it proxies with it, so that clients cannot
+	// forge that mark.
+	forwardTokenEnvName = "ENDPOINTSCALER_FORWARD_TOKEN"
+
+	// SDK health handler paths, see sdk/go/lifecycle.go
+	sdkHealthzPath = "/endpointscaler/healthz"
+	sdkReadyzPath  = "/endpointscaler/readyz"
+)
+
+// mergePodTemplate layers the inherited main Deployment template, the policy
+// template, the endpoint template and the generated pod template, in that
+// order, using strategic merge patch semantics. The generated template is
+// applied last so the selector labels, the "http" port and the guardrail env
+// always come from the controller.
+func mergePodTemplate(
+	policy *esv1alpha1.EndpointPolicy,
+	endpoint *esv1alpha1.EndpointSpec,
+	inherited *corev1.PodTemplateSpec,
+	generated *corev1.PodTemplateSpec,
+) (*corev1.PodTemplateSpec, error) {
+	if inherited == nil && policy.Spec.Template == nil && endpoint.Template == nil {
+		return generated, nil
+	}
+
+	var layers []*corev1.PodTemplateSpec
+	if inherited != nil {
+		layers = append(layers, inheritedTemplate(inherited, policy.Spec.AppRef.DeploymentRef, endpoint))
+	}
+	if policy.Spec.Template != nil {
+		layers = append(layers, userTemplate(policy.Spec.Template, endpoint))
+	}
+	if endpoint.Template != nil {
+		layers = append(layers, userTemplate(endpoint.Template, endpoint))
+	}
+	layers = append(layers, generated)
+
+	merged := []byte("{}")
+	for _, layer := range layers {
+		patch, err := templatePatch(layer)
+		if err != nil {
+			return nil, err
+		}
+		merged, err = strategicpatch.StrategicMergePatch(merged, patch, corev1.PodTemplateSpec{})
+		if err != nil {
+			return nil, fmt.Errorf("merging pod template: %w", err)
+		}
+	}
+
+	template := &corev1.PodTemplateSpec{}
+	if err := json.Unmarshal(merged, template); err != nil {
+		return nil, err
+	}
+	protectEndpointContainer(template, generated, endpoint)
+	return template, nil
+}
+
+// templatePatch returns a template layer as a strategic merge patch. Fields
+// marshaled as null, such as the containers of a template that only sets a
+// nodeSelector, are left out: in a patch they would delete what the earlier
+// layers set.
+func templatePatch(layer *corev1.PodTemplateSpec) ([]byte, error) {
+	data, err := json.Marshal(layer)
+	if err != nil {
+		return nil, err
+	}
+	var patch map[string]interface{}
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, err
+	}
+	dropNulls(patch)
+	return json.Marshal(patch)
+}
+
+// dropNulls removes the null values of a decoded JSON object, recursively.
+func dropNulls(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if field == nil {
+				delete(v, key)
+				continue
+			}
+			dropNulls(field)
+		}
+	case []interface{}:
+		for _, item := range v {
+			dropNulls(item)
+		}
+	}
+}
+
+// inheritedTemplate returns a copy of the main Deployment's pod template with
+// its application container renamed to the endpoint container.
+func inheritedTemplate(
+	template *corev1.PodTemplateSpec,
+	ref *esv1alpha1.DeploymentReference,
+	endpoint *esv1alpha1.EndpointSpec,
+) *corev1.PodTemplateSpec {
+	template = template.DeepCopy()
+	for i := range template.Spec.Containers {
+		if (ref.Container == "" && i == 0) || template.Spec.Containers[i].Name == ref.Container {
+			template.Spec.Containers[i].Name = endpoint.ID
+			break
+		}
+	}
+	return template
+}
+
+// userTemplate returns a copy of a user template with the "app" container
+// renamed to the endpoint container so that the two merge.
+func userTemplate(template *corev1.PodTemplateSpec, endpoint *esv1alpha1.EndpointSpec) *corev1.PodTemplateSpec {
+	template = template.DeepCopy()
+	for i := range template.Spec.Containers {
+		if template.Spec.Containers[i].Name == templateContainerName {
+			template.Spec.Containers[i].Name = endpoint.ID
+		}
+	}
+	return template
+}
+
+// protectEndpointContainer drops user-supplied ports, env and probes that
+// would conflict with the controller-owned "http" port, guardrail and
+// fallback env and SDK probes, which the merge alone cannot prevent because
+// ports merge by number and env by name.
+func protectEndpointContainer(
+	template *corev1.PodTemplateSpec,
+	generated *corev1.PodTemplateSpec,
+	endpoint *esv1alpha1.EndpointSpec,
+) {
+	owned := generated.Spec.Containers[0]
+	for i := range template.Spec.Containers {
+		container := &template.Spec.Containers[i]
+		if container.Name != endpoint.ID {
+			continue
+		}
+
+		ports := []corev1.ContainerPort{owned.Ports[0]}
+		for _, port := range container.Ports {
+			if port.Name != owned.Ports[0].Name && port.ContainerPort != owned.Ports[0].ContainerPort {
+				ports = append(ports, port)
+			}
+		}
+		container.Ports = ports
+
+		env := append([]corev1.EnvVar{}, owned.Env...)
+		for _, e := range container.Env {
+			if e.Name != guardrailEnvName && e.Name != fallbackEnvName && e.Name != forwardTokenEnvName {
+				env = append(env, e)
+			}
+		}
+		container.Env = env
+
+		// Probes merge field by field, which could leave a template probe
+		// with two handlers
+		if owned.LivenessProbe != nil {
+			container.LivenessProbe = owned.LivenessProbe
+		}
+		if owned.ReadinessProbe != nil {
+			container.ReadinessProbe = owned.ReadinessProbe
+		}
+	}
+}
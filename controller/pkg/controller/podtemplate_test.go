@@ -0,0 +1,297 @@
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+)
+
+func templatePolicy() *esv1alpha1.EndpointPolicy {
+	policy := testEndpointPolicy()
+	policy.Spec.Template = &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			ServiceAccountName: "my-app",
+			NodeSelector:       map[string]string{"pool": "general"},
+			Volumes: []corev1.Volume{{
+				Name: "config",
+				VolumeSource: corev1.VolumeSource{
+					ConfigMap: &corev1.ConfigMapVolumeSource{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "my-app-config"},
+					},
+				},
+			}},
+			Containers: []corev1.Container{
+				{
+					Name:    "app",
+					Command: []string{"/app/server"},
+					Env: []corev1.EnvVar{
+						{Name: "LOG_LEVEL", Value: "info"},
+						{Name: "ENDPOINTSCALER_GUARDRAIL", Value: "hijacked"},
+					},
+					EnvFrom: []corev1.EnvFromSource{{
+						SecretRef: &corev1.SecretEnvSource{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "my-app-secrets"},
+						},
+					}},
+					Ports: []corev1.ContainerPort{
+						{Name: "http", ContainerPort: 9999},
+						{Name: "metrics", ContainerPort: 9100},
+					},
+					VolumeMounts: []corev1.VolumeMount{{Name: "config", MountPath: "/etc/app"}},
+					ReadinessProbe: &corev1.Probe{
+						ProbeHandler: corev1.ProbeHandler{
+							HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: intstr.FromString("http")},
+						},
+					},
+				},
+				{Name: "proxy", Image: "envoy:v1"},
+			},
+		},
+	}
+	return policy
+}
+
+func TestBuildDeployment_PolicyTemplate(t *testing.T) {
+	r := &EndpointPolicyReconciler{}
+	policy := templatePolicy()
+	endpoint := &policy.Spec.Endpoints[0]
+
+	deployment, err := r.buildDeployment(policy, endpoint, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	spec := deployment.Spec.Template.Spec
+
+	if spec.ServiceAccountName != "my-app" {
+		t.Errorf("expected service account 'my-app', got %q", spec.ServiceAccountName)
+	}
+	if spec.NodeSelector["pool"] != "general" {
+		t.Errorf("expected node selector pool=general, got %v", spec.NodeSelector)
+	}
+	if len(spec.Volumes) != 1 || spec.Volumes[0].Name != "config" {
+		t.Errorf("expected config volume, got %v", spec.Volumes)
+	}
+	if len(spec.Containers) != 2 {
+		t.Fatalf("expected endpoint and sidecar containers, got %d", len(spec.Containers))
+	}
+
+	var container *corev1.Container
+	for i := range spec.Containers {
+		if spec.Containers[i].Name == endpoint.ID {
+			container = &spec.Containers[i]
+		}
+	}
+	if container == nil {
+		t.Fatalf("endpoint container %q not found", endpoint.ID)
+	}
+	if container.Image != policy.Spec.AppRef.Image {
+		t.Errorf("expected image %q, got %q", policy.Spec.AppRef.Image, container.Image)
+	}
+	if len(container.Command) != 1 || container.Command[0] != "/app/server" {
+		t.Errorf("expected command from template, got %v", container.Command)
+	}
+	if container.ReadinessProbe == nil || container.ReadinessProbe.HTTPGet.Path != "/healthz" {
+		t.Errorf("expected readiness probe from template, got %v", container.ReadinessProbe)
+	}
+	if len(container.EnvFrom) != 1 || len(container.VolumeMounts) != 1 {
+		t.Errorf("expected envFrom and volumeMounts from template, got %v %v", container.EnvFrom, container.VolumeMounts)
+	}
+
+	guardrails := 0
+	for _, e := range container.Env {
+		if e.Name == "ENDPOINTSCALER_GUARDRAIL" {
+			guardrails++
+			if e.Value != endpoint.ID {
+				t.Errorf("expected guardrail %q, got %q", endpoint.ID, e.Value)
+			}
+		}
+	}
+	if guardrails != 1 {
+		t.Errorf("expected exactly one guardrail env, got %d", guardrails)
+	}
+
+	if container.Ports[0].Name != "http" || container.Ports[0].ContainerPort != 8080 {
+		t.Errorf("expected controller-owned http port 8080, got %v", container.Ports[0])
+	}
+	for _, port := range container.Ports[1:] {
+		if port.Name == "http" || port.ContainerPort == 9999 {
+			t.Errorf("expected template http port to be dropped, got %v", container.Ports)
+		}
+	}
+}
+
+func TestBuildDeployment_EndpointTemplateOverridesPolicy(t *testing.T) {
+	r := &EndpointPolicyReconciler{}
+	policy := templatePolicy()
+	endpoint := &policy.Spec.Endpoints[0]
+	endpoint.Template = &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			NodeSelector:      map[string]string{"pool": "compute"},
+			PriorityClassName: "high",
+			Tolerations: []corev1.Toleration{{
+				Key:      "dedicated",
+				Operator: corev1.TolerationOpEqual,
+				Value:    "compute",
+				Effect:   corev1.TaintEffectNoSchedule,
+			}},
+			Containers: []corev1.Container{{
+				Name: "app",
+				Env:  []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "debug"}},
+			}},
+		},
+	}
+
+	deployment, err := r.buildDeployment(policy, endpoint, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	spec := deployment.Spec.Template.Spec
+
+	if spec.NodeSelector["pool"] != "compute" {
+		t.Errorf("expected endpoint node selector to win, got %v", spec.NodeSelector)
+	}
+	if spec.PriorityClassName != "high" || len(spec.Tolerations) != 1 {
+		t.Errorf("expected priority class and toleration from endpoint template, got %q %v", spec.PriorityClassName, spec.Tolerations)
+	}
+	if spec.ServiceAccountName != "my-app" {
+		t.Errorf("expected policy service account to be kept, got %q", spec.ServiceAccountName)
+	}
+
+	for _, c := range spec.Containers {
+		if c.Name != endpoint.ID {
+			continue
+		}
+		for _, e := range c.Env {
+			if e.Name == "LOG_LEVEL" && e.Value != "debug" {
+				t.Errorf("expected LOG_LEVEL=debug, got %q", e.Value)
+			}
+		}
+	}
+}
+
+func TestBuildDeployment_TemplateWithoutContainers(t *testing.T) {
+	r := &EndpointPolicyReconciler{}
+	policy := templatePolicy()
+	endpoint := &policy.Spec.Endpoints[0]
+	endpoint.Template = &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			NodeSelector: map[string]string{"pool": "compute"},
+			Tolerations: []corev1.Toleration{{
+				Key:      "dedicated",
+				Operator: corev1.TolerationOpExists,
+				Effect:   corev1.TaintEffectNoSchedule,
+			}},
+		},
+	}
+
+	deployment, err := r.buildDeployment(policy, endpoint, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	spec := deployment.Spec.Template.Spec
+
+	if spec.NodeSelector["pool"] != "compute" || len(spec.Tolerations) != 1 {
+		t.Errorf("expected node selector and toleration from endpoint template, got %v %v", spec.NodeSelector, spec.Tolerations)
+	}
+	if len(spec.Containers) != 2 {
+		t.Fatalf("expected endpoint and sidecar containers to be kept, got %+v", spec.Containers)
+	}
+	for _, c := range spec.Containers {
+		if c.Name == endpoint.ID && (len(c.Command) != 1 || len(c.VolumeMounts) != 1) {
+			t.Errorf("expected command and volume mounts from the policy template, got %v %v", c.Command, c.VolumeMounts)
+		}
+	}
+
+	// The same holds for a policy template layered on an inherited one
+	inherited := &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{Name: "web", Image: "my-app:v1", Args: []string{"--serve"}},
+				{Name: "proxy", Image: "envoy:v1"},
+			},
+		},
+	}
+	policy = deploymentRefPolicy()
+	policy.Spec.Template = &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{NodeSelector: map[string]string{"pool": "general"}},
+	}
+	deployment, err = r.buildDeployment(policy, &policy.Spec.Endpoints[0], inherited)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	spec = deployment.Spec.Template.Spec
+	if len(spec.Containers) != 2 {
+		t.Fatalf("expected inherited containers to be kept, got %+v", spec.Containers)
+	}
+	if c := spec.Containers[0]; c.Name != "lookup" || len(c.Args) != 1 || c.Args[0] != "--serve" {
+		t.Errorf("expected inherited args on the endpoint container, got %+v", c)
+	}
+}
+
+func TestBuildDeployment_TemplateKeepsSelectorLabels(t *testing.T) {
+	r := &EndpointPolicyReconciler{}
+	policy := templatePolicy()
+	policy.Spec.Template.Labels = map[string]string{
+		"team":                     "search",
+		"endpointscaler.io/policy": "someone-else",
+	}
+	endpoint := &policy.Spec.Endpoints[0]
+
+	deployment, err := r.buildDeployment(policy, endpoint, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	labels := deployment.Spec.Template.Labels
+	if labels["team"] != "search" {
+		t.Errorf("expected template label team=search, got %v", labels)
+	}
+	for key, value := range deployment.Spec.Selector.MatchLabels {
+		if labels[key] != value {
+			t.Errorf("selector label %s=%s overridden to %q", key, value, labels[key])
+		}
+	}
+}
+
+func TestBuildDeployment_SDKProbes(t *testing.T) {
+	r := &EndpointPolicyReconciler{}
+	policy := templatePolicy()
+	endpoint := &policy.Spec.Endpoints[0]
+
+	deployment, err := r.buildDeployment(policy, endpoint, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	container := deployment.Spec.Template.Spec.Containers[0]
+	if container.LivenessProbe != nil {
+		t.Errorf("expected no liveness probe without sdkProbes, got %v", container.LivenessProbe)
+	}
+
+	policy.Spec.SDKProbes = true
+	policy.Spec.Template.Spec.Containers[0].ReadinessProbe.Exec = &corev1.ExecAction{Command: []string{"true"}}
+	deployment, err = r.buildDeployment(policy, endpoint, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, c := range deployment.Spec.Template.Spec.Containers {
+		if c.Name != endpoint.ID {
+			continue
+		}
+		for path, probe := range map[string]*corev1.Probe{
+			"/endpointscaler/healthz": c.LivenessProbe,
+			"/endpointscaler/readyz":  c.ReadinessProbe,
+		} {
+			if probe == nil || probe.HTTPGet == nil || probe.HTTPGet.Path != path || probe.HTTPGet.Port.StrVal != "http" {
+				t.Errorf("expected probe on %s, got %v", path, probe)
+				continue
+			}
+			if probe.Exec != nil {
+				t.Errorf("expected template probe handler to be dropped, got %v", probe.Exec)
+			}
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+)
+
+// analysisInconclusive prefixes the status message of an analysis run that
+// could not obtain a result.
+const analysisInconclusive = "Analysis inconclusive"
+
+// minProgressiveRequeue keeps steps without a pause or analysis from being
+// advanced before the previous weight has reached the gateway.
+const minProgressiveRequeue = 10 * time.Second
+
+// reconcileProgressive advances the rollout of a "progressive" endpoint by at
+// most one step and returns its new state together with when it should next
+// be evaluated. The state is persisted in EndpointStatus, so a restarted
+// controller resumes from the recorded step.
+func (r *EndpointPolicyReconciler) reconcileProgressive(
+	ctx context.Context,
+	policy *esv1alpha1.EndpointPolicy,
+	endpoint *esv1alpha1.EndpointSpec,
+) (*esv1alpha1.ProgressiveStatus, time.Duration, error) {
+	logger := log.FromContext(ctx)
+	spec := endpoint.Progressive
+	now := r.now()
+
+	inherited, err := r.inheritedPodTemplate(ctx, policy)
+	if err != nil {
+		return nil, 0, err
+	}
+	deployment, err := r.buildDeployment(policy, endpoint, inherited)
+	if err != nil {
+		return nil, 0, err
+	}
+	revision := templateRevision(&deployment.Spec.Template)
+
+	var current *esv1alpha1.ProgressiveStatus
+	if prev := findEndpointStatus(policy, endpoint.ID); prev != nil {
+		current = prev.Progressive
+	}
+
+	if current == nil || current.Revision != revision {
+		logger.Info("Starting progressive rollout", "endpoint", endpoint.ID, "revision", revision)
+		started := metav1.NewTime(now)
+		return &esv1alpha1.ProgressiveStatus{
+			Revision:      revision,
+			Phase:         esv1alpha1.ProgressivePhaseProgressing,
+			Weight:        spec.Steps[0].Weight,
+			StepStartedAt: &started,
+			Message:       "Rollout started",
+		}, progressiveRequeue(spec, 0, 0, 0), nil
+	}
+
+	status := current.DeepCopy()
+	if status.Phase != esv1alpha1.ProgressivePhaseProgressing {
+		return status, 0, nil
+	}
+
+	// Steps may have been edited mid-rollout
+	if int(status.Step) >= len(spec.Steps) {
+		status.Step = int32(len(spec.Steps) - 1)
+	}
+	status.Weight = spec.Steps[status.Step].Weight
+
+	// Analysis runs at most once per interval, however often the policy is
+	// reconciled. Until the next run the step is held after an
+	// inconclusive result, and too many of them in a row roll back.
+	var sinceAnalysis time.Duration
+	if spec.Analysis != nil && status.LastAnalysisAt != nil {
+		sinceAnalysis = now.Sub(status.LastAnalysisAt.Time)
+	}
+	if spec.Analysis != nil && (status.LastAnalysisAt == nil || sinceAnalysis >= analysisInterval(spec.Analysis)) {
+		analyzed := metav1.NewTime(now)
+		status.LastAnalysisAt = &analyzed
+		sinceAnalysis = 0
+		failure, err := runAnalysis(ctx, spec.Analysis)
+		if err != nil {
+			status.InconclusiveRuns++
+			if status.InconclusiveRuns < inconclusiveLimit(spec.Analysis) {
+				status.Message = fmt.Sprintf("%s: %v", analysisInconclusive, err)
+				return status, progressiveRequeue(spec, status.Step, 0, 0), nil
+			}
+			failure = fmt.Sprintf("%s %d times in a row: %v", analysisInconclusive, status.InconclusiveRuns, err)
+		} else {
+			status.InconclusiveRuns = 0
+		}
+		if failure != "" {
+			logger.Info("Progressive rollout failed analysis, rolling back", "endpoint", endpoint.ID, "reason", failure)
+			status.Phase = esv1alpha1.ProgressivePhaseRolledBack
+			status.Weight = 0
+			status.Message = failure
+			return status, 0, nil
+		}
+		status.Message = "Analysis passed"
+	} else if spec.Analysis != nil && status.InconclusiveRuns > 0 {
+		return status, progressiveRequeue(spec, status.Step, 0, sinceAnalysis), nil
+	}
+
+	var elapsed time.Duration
+	if status.StepStartedAt != nil {
+		elapsed = now.Sub(status.StepStartedAt.Time)
+	}
+	if pause := stepPause(spec, status.Step); elapsed < pause {
+		return status, progressiveRequeue(spec, status.Step, elapsed, sinceAnalysis), nil
+	}
+
+	if int(status.Step) == len(spec.Steps)-1 {
+		logger.Info("Progressive rollout complete", "endpoint", endpoint.ID)
+		status.Phase = esv1alpha1.ProgressivePhaseSucceeded
+		status.Message = "Rollout complete"
+		return status, 0, nil
+	}
+
+	status.Step++
+	status.Weight = spec.Steps[status.Step].Weight
+	started := metav1.NewTime(now)
+	status.StepStartedAt = &started
+	logger.Info("Advancing progressive rollout", "endpoint", endpoint.ID, "step", status.Step, "weight", status.Weight)
+	return status, progressiveRequeue(spec, status.Step, 0, sinceAnalysis), nil
+}
+
+func stepPause(spec *esv1alpha1.ProgressiveSpec, step int32) time.Duration {
+	if pause := spec.Steps[step].Pause; pause != nil {
+		return pause.Duration
+	}
+	return 0
+}
+
+// progressiveRequeue is the time until the current step next needs attention:
+// the end of its pause or the next analysis run, whichever is sooner.
+// elapsed is the time spent on the step and sinceAnalysis the time since the
+// last analysis run.
+func progressiveRequeue(spec *esv1alpha1.ProgressiveSpec, step int32, elapsed, sinceAnalysis time.Duration) time.Duration {
+	requeue := stepPause(spec, step) - elapsed
+	if spec.Analysis != nil {
+		next := analysisInterval(spec.Analysis) - sinceAnalysis
+		if requeue <= 0 || next < requeue {
+			requeue = next
+		}
+	}
+	if requeue < minProgressiveRequeue {
+		requeue = minProgressiveRequeue
+	}
+	return requeue
+}
+
+// templateRevision hashes a pod template so that a new image or spec change
+// restarts the rollout.
+func templateRevision(template *corev1.PodTemplateSpec) string {
+	data, _ := json.Marshal(template)
+	h := fnv.New32a()
+	_, _ = h.Write(data)
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+func findEndpointStatus(policy *esv1alpha1.EndpointPolicy, id string) *esv1alpha1.EndpointStatus {
+	for i := range policy.Status.EndpointStatuses {
+		if policy.Status.EndpointStatuses[i].ID == id {
+			return &policy.Status.EndpointStatuses[i]
+		}
+	}
+	return nil
+}
+
+// setProgressiveStatus records the rollout state on the policy so that the
+// route built afterwards in the same reconcile uses the new weight.
+func setProgressiveStatus(policy *esv1alpha1.EndpointPolicy, id string, progress *esv1alpha1.ProgressiveStatus) {
+	if status := findEndpointStatus(policy, id); status != nil {
+		status.Progressive = progress
+		return
+	}
+	policy.Status.EndpointStatuses = append(policy.Status.EndpointStatuses, esv1alpha1.EndpointStatus{
+		ID:          id,
+		Progressive: progress,
+	})
+}
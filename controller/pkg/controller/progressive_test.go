@@ -0,0 +1,318 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+)
+
+// prometheusStub serves a fixed instant-query result.
+func prometheusStub(t *testing.T, value string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" || r.URL.Query().Get("query") == "" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1700000000,%q]}]}}`, value)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func progressivePolicy(prometheusURL string) *esv1alpha1.EndpointPolicy {
+	policy := testEndpointPolicy()
+	policy.Spec.Endpoints = policy.Spec.Endpoints[:1]
+	endpoint := &policy.Spec.Endpoints[0]
+	endpoint.Strategy = StrategyProgressive
+	endpoint.CanaryWeight = nil
+	endpoint.Progressive = &esv1alpha1.ProgressiveSpec{
+		Steps: []esv1alpha1.CanaryStep{
+			{Weight: 5, Pause: &metav1.Duration{Duration: 5 * time.Minute}},
+			{Weight: 25, Pause: &metav1.Duration{Duration: 5 * time.Minute}},
+			{Weight: 100},
+		},
+	}
+	if prometheusURL != "" {
+		endpoint.Progressive.Analysis = &esv1alpha1.AnalysisSpec{
+			PrometheusURL: prometheusURL,
+			Checks: []esv1alpha1.AnalysisCheck{{
+				Name:  "error-rate",
+				Query: `sum(rate(http_requests_total{code=~"5.."}[1m]))`,
+				Max:   "0.05",
+			}},
+		}
+	}
+	return policy
+}
+
+func TestReconcileProgressive_StepsThroughSchedule(t *testing.T) {
+	srv := prometheusStub(t, "0.01")
+	policy := progressivePolicy(srv.URL)
+	endpoint := &policy.Spec.Endpoints[0]
+	clock := clocktesting.NewFakePassiveClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	r := &EndpointPolicyReconciler{Clock: clock}
+	ctx := context.Background()
+
+	progress, _, err := r.reconcileProgressive(ctx, policy, endpoint)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if progress.Phase != esv1alpha1.ProgressivePhaseProgressing || progress.Step != 0 || progress.Weight != 5 {
+		t.Fatalf("expected step 0 at 5%%, got %+v", progress)
+	}
+	setProgressiveStatus(policy, endpoint.ID, progress)
+
+	// Pause has not elapsed
+	clock.SetTime(clock.Now().Add(time.Minute))
+	progress, requeue, err := r.reconcileProgressive(ctx, policy, endpoint)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if progress.Step != 0 {
+		t.Errorf("expected to remain on step 0, got %d", progress.Step)
+	}
+	if requeue != time.Minute {
+		t.Errorf("expected requeue at next analysis interval (1m), got %v", requeue)
+	}
+	setProgressiveStatus(policy, endpoint.ID, progress)
+
+	clock.SetTime(clock.Now().Add(5 * time.Minute))
+	progress, _, _ = r.reconcileProgressive(ctx, policy, endpoint)
+	if progress.Step != 1 || progress.Weight != 25 {
+		t.Fatalf("expected step 1 at 25%%, got %+v", progress)
+	}
+	setProgressiveStatus(policy, endpoint.ID, progress)
+
+	// The route follows the persisted weight
+	refs := r.buildHTTPBackendRefs(policy, endpoint)
+	if *refs[0].Weight != 75 || *refs[1].Weight != 25 {
+		t.Errorf("expected weights 75/25, got %d/%d", *refs[0].Weight, *refs[1].Weight)
+	}
+
+	clock.SetTime(clock.Now().Add(5 * time.Minute))
+	progress, _, _ = r.reconcileProgressive(ctx, policy, endpoint)
+	setProgressiveStatus(policy, endpoint.ID, progress)
+	progress, _, _ = r.reconcileProgressive(ctx, policy, endpoint)
+	if progress.Phase != esv1alpha1.ProgressivePhaseSucceeded || progress.Weight != 100 {
+		t.Errorf("expected rollout to succeed at 100%%, got %+v", progress)
+	}
+}
+
+func TestReconcileProgressive_RollsBackOnFailedAnalysis(t *testing.T) {
+	srv := prometheusStub(t, "0.4")
+	policy := progressivePolicy(srv.URL)
+	endpoint := &policy.Spec.Endpoints[0]
+	r := &EndpointPolicyReconciler{Clock: clocktesting.NewFakePassiveClock(time.Now())}
+	ctx := context.Background()
+
+	progress, _, _ := r.reconcileProgressive(ctx, policy, endpoint)
+	setProgressiveStatus(policy, endpoint.ID, progress)
+
+	progress, requeue, err := r.reconcileProgressive(ctx, policy, endpoint)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if progress.Phase != esv1alpha1.ProgressivePhaseRolledBack || progress.Weight != 0 {
+		t.Errorf("expected rollback to 0%%, got %+v", progress)
+	}
+	if requeue != 0 {
+		t.Errorf("expected no requeue after rollback, got %v", requeue)
+	}
+	setProgressiveStatus(policy, endpoint.ID, progress)
+
+	refs := r.buildHTTPBackendRefs(policy, endpoint)
+	if *refs[0].Weight != 100 || *refs[1].Weight != 0 {
+		t.Errorf("expected weights 100/0 after rollback, got %d/%d", *refs[0].Weight, *refs[1].Weight)
+	}
+
+	// A new image starts a fresh rollout
+	policy.Spec.AppRef.Image = "my-app:v2"
+	progress, _, _ = r.reconcileProgressive(ctx, policy, endpoint)
+	if progress.Phase != esv1alpha1.ProgressivePhaseProgressing || progress.Step != 0 {
+		t.Errorf("expected new revision to restart the rollout, got %+v", progress)
+	}
+}
+
+func TestReconcileProgressive_InconclusiveAnalysis(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[]}}`)
+	}))
+	t.Cleanup(srv.Close)
+	policy := progressivePolicy(srv.URL)
+	endpoint := &policy.Spec.Endpoints[0]
+	r := &EndpointPolicyReconciler{Clock: clocktesting.NewFakePassiveClock(time.Now())}
+	ctx := context.Background()
+
+	progress, _, _ := r.reconcileProgressive(ctx, policy, endpoint)
+	setProgressiveStatus(policy, endpoint.ID, progress)
+
+	progress, _, _ = r.reconcileProgressive(ctx, policy, endpoint)
+	if progress.Phase != esv1alpha1.ProgressivePhaseProgressing {
+		t.Errorf("expected rollout to keep progressing on an empty result, got %+v", progress)
+	}
+}
+
+func TestReconcileProgressive_AnalysisWaitsForInterval(t *testing.T) {
+	queries := 0
+	value := "0.01"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries++
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1700000000,%q]}]}}`, value)
+	}))
+	t.Cleanup(srv.Close)
+	policy := progressivePolicy(srv.URL)
+	endpoint := &policy.Spec.Endpoints[0]
+	endpoint.Progressive.Analysis.Interval = &metav1.Duration{Duration: 2 * time.Minute}
+	clock := clocktesting.NewFakePassiveClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	r := &EndpointPolicyReconciler{Clock: clock}
+	ctx := context.Background()
+
+	progress, _, _ := r.reconcileProgressive(ctx, policy, endpoint)
+	setProgressiveStatus(policy, endpoint.ID, progress)
+
+	progress, _, _ = r.reconcileProgressive(ctx, policy, endpoint)
+	if queries != 1 || progress.LastAnalysisAt == nil || !progress.LastAnalysisAt.Time.Equal(clock.Now()) {
+		t.Fatalf("expected one analysis run recorded at %v, got %d runs and %+v", clock.Now(), queries, progress)
+	}
+	setProgressiveStatus(policy, endpoint.ID, progress)
+
+	// Reconciles within the interval do not query Prometheus again
+	clock.SetTime(clock.Now().Add(90 * time.Second))
+	progress, requeue, _ := r.reconcileProgressive(ctx, policy, endpoint)
+	if queries != 1 {
+		t.Errorf("expected no analysis before the interval elapsed, got %d runs", queries)
+	}
+	if requeue != 30*time.Second {
+		t.Errorf("expected requeue at the next analysis run (30s), got %v", requeue)
+	}
+	setProgressiveStatus(policy, endpoint.ID, progress)
+
+	// A failure is only seen once the interval has elapsed
+	value = "0.4"
+	clock.SetTime(clock.Now().Add(20 * time.Second))
+	progress, _, _ = r.reconcileProgressive(ctx, policy, endpoint)
+	if queries != 1 || progress.Phase != esv1alpha1.ProgressivePhaseProgressing {
+		t.Errorf("expected no analysis before the interval elapsed, got %d runs and %+v", queries, progress)
+	}
+	setProgressiveStatus(policy, endpoint.ID, progress)
+
+	clock.SetTime(clock.Now().Add(10 * time.Second))
+	progress, _, _ = r.reconcileProgressive(ctx, policy, endpoint)
+	if queries != 2 || progress.Phase != esv1alpha1.ProgressivePhaseRolledBack {
+		t.Errorf("expected a second run to roll back, got %d runs and %+v", queries, progress)
+	}
+}
+
+func TestReconcileProgressive_InconclusiveAnalysisHoldsStep(t *testing.T) {
+	queries := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries++
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[]}}`)
+	}))
+	t.Cleanup(srv.Close)
+	policy := progressivePolicy(srv.URL)
+	endpoint := &policy.Spec.Endpoints[0]
+	endpoint.Progressive.Steps[0].Pause = nil
+	clock := clocktesting.NewFakePassiveClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	r := &EndpointPolicyReconciler{Clock: clock}
+	ctx := context.Background()
+
+	progress, _, _ := r.reconcileProgressive(ctx, policy, endpoint)
+	setProgressiveStatus(policy, endpoint.ID, progress)
+	progress, _, _ = r.reconcileProgressive(ctx, policy, endpoint)
+	setProgressiveStatus(policy, endpoint.ID, progress)
+
+	// Without a conclusive run the step is not advanced between runs
+	clock.SetTime(clock.Now().Add(30 * time.Second))
+	progress, _, _ = r.reconcileProgressive(ctx, policy, endpoint)
+	if queries != 1 || progress.Step != 0 {
+		t.Errorf("expected step 0 held without a new run, got %d runs and %+v", queries, progress)
+	}
+}
+
+func TestReconcileProgressive_InconclusiveLimitRollsBack(t *testing.T) {
+	empty := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if empty {
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[]}}`)
+			return
+		}
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1700000000,"0.01"]}]}}`)
+	}))
+	t.Cleanup(srv.Close)
+	policy := progressivePolicy(srv.URL)
+	endpoint := &policy.Spec.Endpoints[0]
+	endpoint.Progressive.Analysis.InconclusiveLimit = ptr.To(int32(3))
+	clock := clocktesting.NewFakePassiveClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	r := &EndpointPolicyReconciler{Clock: clock}
+	ctx := context.Background()
+
+	progress, _, _ := r.reconcileProgressive(ctx, policy, endpoint)
+	setProgressiveStatus(policy, endpoint.ID, progress)
+	run := func() *esv1alpha1.ProgressiveStatus {
+		t.Helper()
+		progress, _, err := r.reconcileProgressive(ctx, policy, endpoint)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		setProgressiveStatus(policy, endpoint.ID, progress)
+		clock.SetTime(clock.Now().Add(time.Minute))
+		return progress
+	}
+
+	// A conclusive run resets the count
+	run()
+	empty = false
+	if progress := run(); progress.InconclusiveRuns != 0 {
+		t.Errorf("expected a passing run to reset the count, got %+v", progress)
+	}
+
+	empty = true
+	for i := int32(1); i < 3; i++ {
+		if progress := run(); progress.Phase != esv1alpha1.ProgressivePhaseProgressing || progress.InconclusiveRuns != i {
+			t.Fatalf("expected %d inconclusive runs to be tolerated, got %+v", i, progress)
+		}
+	}
+	progress = run()
+	if progress.Phase != esv1alpha1.ProgressivePhaseRolledBack || progress.Weight != 0 || progress.InconclusiveRuns != 3 {
+		t.Fatalf("expected a rollback after 3 inconclusive runs, got %+v", progress)
+	}
+	if !strings.Contains(progress.Message, "inconclusive 3 times in a row") {
+		t.Errorf("expected the limit in the message, got %q", progress.Message)
+	}
+}
+
+func TestQueryPrometheus_Scalar(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"scalar","result":[1700000000,"0.25"]}}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	value, err := queryPrometheus(context.Background(), srv.URL, "vector(0.25)")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value != 0.25 {
+		t.Errorf("expected 0.25, got %v", value)
+	}
+}
+
+func TestEndpointWeight_ProgressiveDefaultsToFirstStep(t *testing.T) {
+	policy := progressivePolicy("")
+	endpoint := &policy.Spec.Endpoints[0]
+
+	if w := endpointWeight(policy, endpoint); w != 5 {
+		t.Errorf("expected first step weight 5, got %d", w)
+	}
+}
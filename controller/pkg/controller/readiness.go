@@ -0,0 +1,194 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+)
+
+// Endpoint condition types
+const (
+	EndpointConditionDeploymentAvailable = "DeploymentAvailable"
+	EndpointConditionRouteAccepted       = "RouteAccepted"
+)
+
+// Endpoint reasons for not being ready
+const (
+	ReasonDeploymentUnavailable = "DeploymentUnavailable"
+	ReasonRouteNotAccepted      = "RouteNotAccepted"
+)
+
+// observeEndpoint sets the endpoint's readiness from the live state of its
+// Deployment and route: the Deployment must be Available with every replica
+// updated and available, and the policy's gateway must report the current
+// route generation as Accepted with ResolvedRefs.
+func (r *EndpointPolicyReconciler) observeEndpoint(
+	ctx context.Context,
+	policy *esv1alpha1.EndpointPolicy,
+	endpoint *esv1alpha1.EndpointSpec,
+	status *esv1alpha1.EndpointStatus,
+) error {
+	deployment := &appsv1.Deployment{}
+	key := types.NamespacedName{Name: status.DeploymentName, Namespace: appNamespace(policy)}
+	if err := r.Get(ctx, key, deployment); err != nil {
+		return err
+	}
+	status.AvailableReplicas = deployment.Status.AvailableReplicas
+	available := deploymentCondition(deployment)
+
+	var generation int64
+	var parents []gatewayv1.RouteParentStatus
+	routeKey := types.NamespacedName{Name: status.RouteName, Namespace: policy.Namespace}
+	if endpoint.Type == "grpc" {
+		route := &gatewayv1.GRPCRoute{}
+		if err := r.Get(ctx, routeKey, route); err != nil {
+			return err
+		}
+		generation, parents = route.Generation, route.Status.Parents
+	} else {
+		route := &gatewayv1.HTTPRoute{}
+		if err := r.Get(ctx, routeKey, route); err != nil {
+			return err
+		}
+		generation, parents = route.Generation, route.Status.Parents
+	}
+	accepted, rejections := routeCondition(policy, policy.Namespace, generation, parents)
+	status.RouteRejections = rejections
+
+	for _, cond := range []metav1.Condition{available, accepted} {
+		cond.LastTransitionTime = metav1.NewTime(r.now())
+		meta.SetStatusCondition(&status.Conditions, cond)
+	}
+
+	switch {
+	case available.Status != metav1.ConditionTrue:
+		status.Reason = ReasonDeploymentUnavailable
+		status.Message = fmt.Sprintf("Deployment %s: %s", status.DeploymentName, available.Message)
+	case accepted.Status != metav1.ConditionTrue:
+		status.Reason = ReasonRouteNotAccepted
+		status.Message = fmt.Sprintf("Route %s: %s", status.RouteName, accepted.Message)
+	default:
+		status.Ready = true
+	}
+	return nil
+}
+
+// deploymentCondition reports whether the Deployment has finished rolling out
+// and all of its replicas are available.
+func deploymentCondition(deployment *appsv1.Deployment) metav1.Condition {
+	cond := metav1.Condition{
+		Type:               EndpointConditionDeploymentAvailable,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: deployment.Generation,
+	}
+
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	s := deployment.Status
+	available := meta.FindStatusCondition(deploymentConditions(deployment), string(appsv1.DeploymentAvailable))
+
+	switch {
+	case s.ObservedGeneration < deployment.Generation:
+		cond.Reason = "RolloutPending"
+		cond.Message = "waiting for the deployment controller to observe the latest spec"
+	case available == nil || available.Status != metav1.ConditionTrue:
+		cond.Reason = "Unavailable"
+		cond.Message = "deployment does not have minimum availability"
+		if available != nil && available.Message != "" {
+			cond.Message = available.Message
+		}
+	case s.UpdatedReplicas < replicas:
+		cond.Reason = "RolloutInProgress"
+		cond.Message = fmt.Sprintf("%d of %d replicas updated", s.UpdatedReplicas, replicas)
+	case s.Replicas > s.UpdatedReplicas:
+		cond.Reason = "RolloutInProgress"
+		cond.Message = fmt.Sprintf("%d old replicas pending termination", s.Replicas-s.UpdatedReplicas)
+	case s.AvailableReplicas < s.UpdatedReplicas:
+		cond.Reason = "ReplicasUnavailable"
+		cond.Message = fmt.Sprintf("%d of %d updated replicas available", s.AvailableReplicas, s.UpdatedReplicas)
+	default:
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = "Available"
+		cond.Message = fmt.Sprintf("%d replicas available", s.AvailableReplicas)
+	}
+	return cond
+}
+
+// deploymentConditions converts the Deployment's conditions so they can be
+// searched with the meta helpers.
+func deploymentConditions(deployment *appsv1.Deployment) []metav1.Condition {
+	conditions := make([]metav1.Condition, 0, len(deployment.Status.Conditions))
+	for _, c := range deployment.Status.Conditions {
+		conditions = append(conditions, metav1.Condition{
+			Type:    string(c.Type),
+			Status:  metav1.ConditionStatus(c.Status),
+			Reason:  c.Reason,
+			Message: c.Message,
+		})
+	}
+	return conditions
+}
+
+// routeCondition reports whether the policy's gateway has accepted the given
+// generation of a route and resolved all of its backend references, along
+// with any conditions the gateway reported as not met.
+func routeCondition(
+	policy *esv1alpha1.EndpointPolicy,
+	routeNamespace string,
+	generation int64,
+	parents []gatewayv1.RouteParentStatus,
+) (metav1.Condition, []esv1alpha1.RouteRejection) {
+	cond := metav1.Condition{
+		Type:               EndpointConditionRouteAccepted,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+		Reason:             "Pending",
+	}
+
+	parent := findGatewayParent(policy, routeNamespace, parents)
+	if parent == nil {
+		cond.Message = fmt.Sprintf("waiting for gateway %s to report route status", policy.Spec.GatewayRef.Name)
+		return cond, nil
+	}
+
+	var rejections []esv1alpha1.RouteRejection
+	pending := false
+	for _, condType := range []gatewayv1.RouteConditionType{gatewayv1.RouteConditionAccepted, gatewayv1.RouteConditionResolvedRefs} {
+		c := meta.FindStatusCondition(parent.Conditions, string(condType))
+		switch {
+		case c == nil || c.ObservedGeneration < generation:
+			pending = true
+		case c.Status != metav1.ConditionTrue:
+			rejections = append(rejections, esv1alpha1.RouteRejection{
+				Type:    c.Type,
+				Reason:  c.Reason,
+				Message: c.Message,
+			})
+		}
+	}
+
+	switch {
+	case len(rejections) > 0:
+		cond.Reason = "Rejected"
+		if rejections[0].Reason != "" {
+			cond.Reason = rejections[0].Reason
+		}
+		cond.Message = fmt.Sprintf("gateway reported %s=False: %s", rejections[0].Type, rejections[0].Message)
+	case pending:
+		cond.Message = fmt.Sprintf("waiting for gateway %s to process generation %d", policy.Spec.GatewayRef.Name, generation)
+	default:
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = string(gatewayv1.RouteReasonAccepted)
+		cond.Message = "route accepted by gateway"
+	}
+	return cond, rejections
+}
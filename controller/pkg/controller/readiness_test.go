@@ -0,0 +1,210 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+)
+
+// markAvailable gives a Deployment the status of a completed rollout.
+func markAvailable(deployment *appsv1.Deployment, replicas int32) {
+	deployment.Status = appsv1.DeploymentStatus{
+		ObservedGeneration: deployment.Generation,
+		Replicas:           replicas,
+		UpdatedReplicas:    replicas,
+		ReadyReplicas:      replicas,
+		AvailableReplicas:  replicas,
+		Conditions: []appsv1.DeploymentCondition{{
+			Type:   appsv1.DeploymentAvailable,
+			Status: corev1.ConditionTrue,
+			Reason: "MinimumReplicasAvailable",
+		}},
+	}
+}
+
+// markDeploymentAvailable reports every replica of the named Deployment as
+// available.
+func markDeploymentAvailable(t *testing.T, r *EndpointPolicyReconciler, name string) {
+	t.Helper()
+	dep := &appsv1.Deployment{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: name, Namespace: "default"}, dep); err != nil {
+		t.Fatalf("expected deployment %s: %v", name, err)
+	}
+	markAvailable(dep, ptr.Deref(dep.Spec.Replicas, 1))
+	if err := r.Status().Update(context.Background(), dep); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// gatewayParent is a route status entry for testGateway with the given
+// Accepted and ResolvedRefs conditions.
+func gatewayParent(generation int64, accepted, resolvedRefs metav1.ConditionStatus) gatewayv1.RouteParentStatus {
+	gatewayNS := gatewayv1.Namespace("gateway-ns")
+	return gatewayv1.RouteParentStatus{
+		ParentRef:      gatewayv1.ParentReference{Name: "my-gateway", Namespace: &gatewayNS},
+		ControllerName: "example.com/gateway",
+		Conditions: []metav1.Condition{
+			{
+				Type:               string(gatewayv1.RouteConditionAccepted),
+				Status:             accepted,
+				ObservedGeneration: generation,
+				Reason:             "NotAllowedByListeners",
+				Message:            "no listener allows this route",
+				LastTransitionTime: metav1.Now(),
+			},
+			{
+				Type:               string(gatewayv1.RouteConditionResolvedRefs),
+				Status:             resolvedRefs,
+				ObservedGeneration: generation,
+				Reason:             "BackendNotFound",
+				Message:            "service not found",
+				LastTransitionTime: metav1.Now(),
+			},
+		},
+	}
+}
+
+func TestDeploymentCondition(t *testing.T) {
+	replicas := int32(3)
+	tests := []struct {
+		name   string
+		mutate func(*appsv1.Deployment)
+		status metav1.ConditionStatus
+		reason string
+	}{
+		{"available", func(*appsv1.Deployment) {}, metav1.ConditionTrue, "Available"},
+		{"generation not observed", func(d *appsv1.Deployment) { d.Generation = 2 }, metav1.ConditionFalse, "RolloutPending"},
+		{"no pods", func(d *appsv1.Deployment) { d.Status = appsv1.DeploymentStatus{ObservedGeneration: 1} }, metav1.ConditionFalse, "Unavailable"},
+		{"rolling out", func(d *appsv1.Deployment) { d.Status.UpdatedReplicas = 1 }, metav1.ConditionFalse, "RolloutInProgress"},
+		{"old replicas", func(d *appsv1.Deployment) { d.Status.Replicas = 4 }, metav1.ConditionFalse, "RolloutInProgress"},
+		{"crash looping", func(d *appsv1.Deployment) { d.Status.AvailableReplicas = 2 }, metav1.ConditionFalse, "ReplicasUnavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployment := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Generation: 1},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			}
+			markAvailable(deployment, replicas)
+			tt.mutate(deployment)
+
+			cond := deploymentCondition(deployment)
+			if cond.Status != tt.status || cond.Reason != tt.reason {
+				t.Errorf("expected %s/%s, got %s/%s (%s)", tt.status, tt.reason, cond.Status, cond.Reason, cond.Message)
+			}
+		})
+	}
+}
+
+func TestRouteCondition(t *testing.T) {
+	policy := testEndpointPolicy()
+
+	cond, rejections := routeCondition(policy, "default", 1, nil)
+	if cond.Status != metav1.ConditionFalse || cond.Reason != "Pending" || rejections != nil {
+		t.Errorf("expected pending without gateway status, got %+v %v", cond, rejections)
+	}
+
+	parents := []gatewayv1.RouteParentStatus{gatewayParent(1, metav1.ConditionTrue, metav1.ConditionTrue)}
+	if cond, _ := routeCondition(policy, "default", 2, parents); cond.Reason != "Pending" {
+		t.Errorf("expected pending for a stale generation, got %+v", cond)
+	}
+	if cond, _ := routeCondition(policy, "default", 1, parents); cond.Status != metav1.ConditionTrue {
+		t.Errorf("expected accepted, got %+v", cond)
+	}
+
+	parents = []gatewayv1.RouteParentStatus{gatewayParent(1, metav1.ConditionFalse, metav1.ConditionFalse)}
+	cond, rejections = routeCondition(policy, "default", 1, parents)
+	if cond.Status != metav1.ConditionFalse || cond.Reason != "NotAllowedByListeners" {
+		t.Errorf("expected rejection reason from gateway, got %+v", cond)
+	}
+	if len(rejections) != 2 || rejections[1].Type != "ResolvedRefs" || rejections[1].Reason != "BackendNotFound" {
+		t.Errorf("expected Accepted and ResolvedRefs rejections, got %+v", rejections)
+	}
+}
+
+func TestReconcile_ReadinessFollowsDeploymentAndRoute(t *testing.T) {
+	policy := testEndpointPolicy()
+	policy.Spec.Endpoints = policy.Spec.Endpoints[1:]
+	r := newFakeReconciler(t, withRouteTargets(policy)...)
+	ctx := context.Background()
+	req := reconcileRequest(policy)
+
+	endpointStatus := func() esv1alpha1.EndpointStatus {
+		t.Helper()
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		updated := &esv1alpha1.EndpointPolicy{}
+		if err := r.Get(ctx, req.NamespacedName, updated); err != nil {
+			t.Fatal(err)
+		}
+		return updated.Status.EndpointStatuses[0]
+	}
+
+	status := endpointStatus()
+	if status.Ready || status.Reason != ReasonDeploymentUnavailable {
+		t.Errorf("expected endpoint waiting for its deployment, got %+v", status)
+	}
+
+	key := types.NamespacedName{Name: "my-app-fallback-endpoint", Namespace: "default"}
+	deployment := &appsv1.Deployment{}
+	if err := r.Get(ctx, key, deployment); err != nil {
+		t.Fatal(err)
+	}
+	markAvailable(deployment, 1)
+	if err := r.Status().Update(ctx, deployment); err != nil {
+		t.Fatal(err)
+	}
+
+	status = endpointStatus()
+	if status.Ready || status.Reason != ReasonRouteNotAccepted || status.AvailableReplicas != 1 {
+		t.Errorf("expected endpoint waiting for its route, got %+v", status)
+	}
+
+	// Route status is observed directly: the fake client's server-side apply
+	// does not preserve the status subresource the way the API server does.
+	route := &gatewayv1.HTTPRoute{}
+	if err := r.Get(ctx, key, route); err != nil {
+		t.Fatal(err)
+	}
+	observe := func(accepted metav1.ConditionStatus) esv1alpha1.EndpointStatus {
+		t.Helper()
+		route.Status.Parents = []gatewayv1.RouteParentStatus{gatewayParent(route.Generation, accepted, metav1.ConditionTrue)}
+		if err := r.Status().Update(ctx, route); err != nil {
+			t.Fatal(err)
+		}
+		observed := esv1alpha1.EndpointStatus{ID: status.ID, DeploymentName: status.DeploymentName, RouteName: status.RouteName}
+		if err := r.observeEndpoint(ctx, policy, &policy.Spec.Endpoints[0], &observed); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return observed
+	}
+
+	status = observe(metav1.ConditionFalse)
+	if status.Ready || len(status.RouteRejections) != 1 || status.RouteRejections[0].Reason != "NotAllowedByListeners" {
+		t.Errorf("expected gateway rejection, got %+v", status)
+	}
+	if !strings.Contains(status.Message, "no listener allows this route") {
+		t.Errorf("expected gateway message, got %q", status.Message)
+	}
+
+	status = observe(metav1.ConditionTrue)
+	if !status.Ready || status.Reason != "" || len(status.RouteRejections) != 0 {
+		t.Errorf("expected endpoint ready, got %+v", status)
+	}
+	for _, condType := range []string{EndpointConditionDeploymentAvailable, EndpointConditionRouteAccepted} {
+		if !meta.IsStatusConditionTrue(status.Conditions, condType) {
+			t.Errorf("expected %s condition true, got %+v", condType, status.Conditions)
+		}
+	}
+}
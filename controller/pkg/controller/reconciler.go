@@ -3,6 +3,7 @@ package controller
 import (
 	"context"
 	"fmt"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	autoscalingv2 "k8s.io/api/autoscaling/v2"
@@ -10,21 +11,47 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
 )
 
-const finalizerName = "endpointscaler.io/finalizer"
+const (
+	finalizerName = "endpointscaler.io/finalizer"
+
+	// fieldManager is the server-side apply field manager for every object
+	// the controller generates. Fields owned by other managers (for example
+	// spec.replicas under an HPA) are left untouched.
+	fieldManager = "endpoint-scaler"
+
+	// deploymentRefIndex indexes policies by the "namespace/name" of the main
+	// Deployment they inherit their pod template from.
+	deploymentRefIndex = "spec.appRef.deploymentRef"
+)
 
 // EndpointPolicyReconciler reconciles EndpointPolicy resources
 type EndpointPolicyReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// Clock is used for time-based behavior; defaults to the real clock
+	Clock clock.PassiveClock
+
+	// Recorder emits events on the policy; events are dropped when nil
+	Recorder record.EventRecorder
 }
 
 // +kubebuilder:rbac:groups=endpointscaler.io,resources=endpointpolicies,verbs=get;list;watch;create;update;patch;delete
@@ -33,7 +60,12 @@ type EndpointPolicyReconciler struct {
 // +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes;grpcroutes,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=keda.sh,resources=scaledobjects,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes;grpcroutes;referencegrants,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gateways,verbs=get;list;watch
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gatewayclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 func (r *EndpointPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
@@ -47,6 +79,10 @@ func (r *EndpointPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		return ctrl.Result{}, err
 	}
 
+	if !policy.DeletionTimestamp.IsZero() {
+		return r.finalize(ctx, policy)
+	}
+
 	if err := policy.Spec.Validate(); err != nil {
 		logger.Error(err, "spec validation failed")
 		meta.SetStatusCondition(&policy.Status.Conditions, metav1.Condition{
@@ -64,52 +100,159 @@ func (r *EndpointPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		return ctrl.Result{}, nil
 	}
 
-	logger.Info("Reconciling EndpointPolicy",
-		"name", policy.Name,
-		"endpoints", len(policy.Spec.Endpoints))
+	if !controllerutil.ContainsFinalizer(policy, finalizerName) {
+		controllerutil.AddFinalizer(policy, finalizerName)
+		if err := r.Update(ctx, policy); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
 
-	endpointStatuses := make([]esv1alpha1.EndpointStatus, 0, len(policy.Spec.Endpoints))
+	denial, err := r.appNamespaceDenial(ctx, policy)
+	if err != nil {
+		logger.Error(err, "failed to check app namespace")
+		return ctrl.Result{}, err
+	}
+	if denial != "" {
+		logger.Info("App namespace not allowed", "reason", denial)
+		meta.SetStatusCondition(&policy.Status.Conditions, metav1.Condition{
+			Type:               "Ready",
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: policy.Generation,
+			LastTransitionTime: metav1.Now(),
+			Reason:             ReasonAppNamespaceNotAllowed,
+			Message:            denial,
+		})
+		if statusErr := r.Status().Update(ctx, policy); statusErr != nil {
+			logger.Error(statusErr, "failed to update status after app namespace check")
+			return ctrl.Result{}, statusErr
+		}
+		return ctrl.Result{}, nil
+	}
 
-	labels := client.MatchingLabels{
-		"endpointscaler.io/policy":     policy.Name,
-		"app.kubernetes.io/managed-by": "endpoint-scaler",
+	if err := r.reconcileReferenceGrant(ctx, policy); err != nil {
+		logger.Error(err, "failed to reconcile ReferenceGrant")
+		return ctrl.Result{}, err
 	}
 
-	listofdeployments := &appsv1.DeploymentList{}
-	listofservices := &corev1.ServiceList{}
-	listofroutes := &gatewayv1.HTTPRouteList{}
-	listofgrpcroutes := &gatewayv1.GRPCRouteList{}
-	listofhpas := &autoscalingv2.HorizontalPodAutoscalerList{}
+	conflicts, err := r.lostRouteConflicts(ctx, policy)
+	if err != nil {
+		logger.Error(err, "failed to check route conflicts")
+		return ctrl.Result{}, err
+	}
 
-	r.List(ctx, listofdeployments, client.InNamespace(policy.Namespace), labels)
-	r.List(ctx, listofservices, client.InNamespace(policy.Namespace), labels)
-	r.List(ctx, listofroutes, client.InNamespace(policy.Namespace), labels)
-	r.List(ctx, listofgrpcroutes, client.InNamespace(policy.Namespace), labels)
-	r.List(ctx, listofhpas, client.InNamespace(policy.Namespace), labels)
+	logger.Info("Reconciling EndpointPolicy",
+		"name", policy.Name,
+		"endpoints", len(policy.Spec.Endpoints))
 
+	endpointStatuses := make([]esv1alpha1.EndpointStatus, 0, len(policy.Spec.Endpoints))
+
+	var requeueAfter time.Duration
 	desired := map[string]bool{}
 	for _, endpoint := range policy.Spec.Endpoints {
 		status := esv1alpha1.EndpointStatus{ID: endpoint.ID}
+		if prev := findEndpointStatus(policy, endpoint.ID); prev != nil {
+			status.Progressive = prev.Progressive
+			status.BlueGreen = prev.BlueGreen
+			status.Conditions = prev.Conditions
+		}
 
-		deploymentName, err := r.reconcileDeployment(ctx, policy, &endpoint)
-		if err != nil {
-			logger.Error(err, "failed to reconcile Deployment", "endpoint", endpoint.ID)
-			status.Message = fmt.Sprintf("Deployment error: %v", err)
+		// An endpoint losing a route conflict only loses its route, so that
+		// pruneStale removes it; its workloads are kept as they are until
+		// the conflict is resolved.
+		if conflict, ok := conflicts[endpoint.ID]; ok {
+			status.Reason = ReasonRouteConflict
+			status.Message = fmt.Sprintf("Route conflict: %s", conflict)
 			endpointStatuses = append(endpointStatuses, status)
 			desired[endpoint.ID] = true
 			continue
 		}
-		status.DeploymentName = deploymentName
 
-		serviceName, err := r.reconcileService(ctx, policy, &endpoint)
+		// While a schedule window is active every resource below is built
+		// from its replica overrides
+		scheduled, window, requeue, err := r.applySchedule(&endpoint)
 		if err != nil {
-			logger.Error(err, "failed to reconcile Service", "endpoint", endpoint.ID)
-			status.Message = fmt.Sprintf("Service error: %v", err)
+			logger.Error(err, "failed to evaluate schedule", "endpoint", endpoint.ID)
+			status.Message = fmt.Sprintf("Schedule error: %v", err)
+			endpointStatuses = append(endpointStatuses, status)
+			desired[endpoint.ID] = true
+			continue
+		}
+		endpoint = *scheduled
+		r.observeSchedule(policy, &endpoint, &status, window)
+		requeueAfter = shortestRequeue(requeueAfter, requeue)
+
+		if endpoint.BlueGreen != nil {
+			release, requeue, err := r.reconcileBlueGreen(ctx, policy, &endpoint)
+			if err != nil {
+				logger.Error(err, "failed to reconcile blue/green release", "endpoint", endpoint.ID)
+				status.Message = fmt.Sprintf("Blue/green error: %v", err)
+				endpointStatuses = append(endpointStatuses, status)
+				desired[endpoint.ID] = true
+				continue
+			}
+			status.BlueGreen = release
+			setBlueGreenStatus(policy, endpoint.ID, release)
+			status.DeploymentName = endpointWorkloadName(policy, &endpoint)
+			status.ServiceName = endpointBackendName(policy, &endpoint)
+			requeueAfter = shortestRequeue(requeueAfter, requeue)
+		} else {
+			deploymentName, err := r.reconcileDeployment(ctx, policy, &endpoint)
+			if err != nil {
+				logger.Error(err, "failed to reconcile Deployment", "endpoint", endpoint.ID)
+				status.Message = fmt.Sprintf("Deployment error: %v", err)
+				endpointStatuses = append(endpointStatuses, status)
+				desired[endpoint.ID] = true
+				continue
+			}
+			status.DeploymentName = deploymentName
+
+			serviceName, err := r.reconcileService(ctx, policy, &endpoint)
+			if err != nil {
+				logger.Error(err, "failed to reconcile Service", "endpoint", endpoint.ID)
+				status.Message = fmt.Sprintf("Service error: %v", err)
+				endpointStatuses = append(endpointStatuses, status)
+				desired[endpoint.ID] = true
+				continue
+			}
+			status.ServiceName = serviceName
+
+			// Turning blue/green off keeps the active color serving until
+			// the single Deployment is available
+			release, err := r.leaveBlueGreen(ctx, policy, &endpoint)
+			if err != nil {
+				logger.Error(err, "failed to reconcile blue/green release", "endpoint", endpoint.ID)
+				status.Message = fmt.Sprintf("Blue/green error: %v", err)
+				endpointStatuses = append(endpointStatuses, status)
+				desired[endpoint.ID] = true
+				continue
+			}
+			status.BlueGreen = release
+			setBlueGreenStatus(policy, endpoint.ID, release)
+			status.DeploymentName = endpointWorkloadName(policy, &endpoint)
+			status.ServiceName = endpointBackendName(policy, &endpoint)
+		}
+
+		if endpoint.Strategy == StrategyProgressive {
+			progress, requeue, err := r.reconcileProgressive(ctx, policy, &endpoint)
+			if err != nil {
+				logger.Error(err, "failed to reconcile progressive rollout", "endpoint", endpoint.ID)
+				status.Message = fmt.Sprintf("Progressive rollout error: %v", err)
+				endpointStatuses = append(endpointStatuses, status)
+				desired[endpoint.ID] = true
+				continue
+			}
+			status.Progressive = progress
+			setProgressiveStatus(policy, endpoint.ID, progress)
+			requeueAfter = shortestRequeue(requeueAfter, requeue)
+		}
+
+		if err := r.observeScaleToZero(ctx, policy, &endpoint, &status); err != nil {
+			logger.Error(err, "failed to observe endpoint replicas", "endpoint", endpoint.ID)
+			status.Message = fmt.Sprintf("Status error: %v", err)
 			endpointStatuses = append(endpointStatuses, status)
 			desired[endpoint.ID] = true
 			continue
 		}
-		status.ServiceName = serviceName
 
 		routeName, err := r.reconcileRoute(ctx, policy, &endpoint)
 		if err != nil {
@@ -121,62 +264,59 @@ func (r *EndpointPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		}
 		status.RouteName = routeName
 
-		if endpoint.HPA != nil {
-			if err := r.reconcileHPA(ctx, policy, &endpoint); err != nil {
-				logger.Error(err, "failed to reconcile HPA", "endpoint", endpoint.ID)
-				status.Message = fmt.Sprintf("HPA error: %v", err)
-				endpointStatuses = append(endpointStatuses, status)
-				desired[endpoint.ID] = true
-				continue
-			}
-
+		if err := r.reconcileHPA(ctx, policy, &endpoint); err != nil {
+			logger.Error(err, "failed to reconcile HPA", "endpoint", endpoint.ID)
+			status.Message = fmt.Sprintf("HPA error: %v", err)
+			endpointStatuses = append(endpointStatuses, status)
+			desired[endpoint.ID] = true
+			continue
 		}
 
-		status.Ready = true
-		RecordEndpointInfo(policy.Namespace, policy.Name, endpoint.ID, endpoint.Type, endpoint.Strategy)
-		endpointStatuses = append(endpointStatuses, status)
-		desired[endpoint.ID] = true
-	}
-
-	for i := range listofdeployments.Items {
-		dep := &listofdeployments.Items[i]
-		eid := dep.Labels["endpointscaler.io/endpoint"]
-		if !desired[eid] {
-			_ = r.Delete(ctx, dep)
+		if err := r.reconcileScaledObject(ctx, policy, &endpoint); err != nil {
+			logger.Error(err, "failed to reconcile ScaledObject", "endpoint", endpoint.ID)
+			status.Message = fmt.Sprintf("ScaledObject error: %v", err)
+			endpointStatuses = append(endpointStatuses, status)
+			desired[endpoint.ID] = true
+			continue
 		}
-	}
 
-	for i := range listofservices.Items {
-		dep := &listofservices.Items[i]
-		eid := dep.Labels["endpointscaler.io/endpoint"]
-		if !desired[eid] {
-			_ = r.Delete(ctx, dep)
+		// Only once the route and autoscaler target the serving Deployment
+		if err := r.pruneColors(ctx, policy, &endpoint); err != nil {
+			logger.Error(err, "failed to remove replaced Deployments", "endpoint", endpoint.ID)
+			status.Message = fmt.Sprintf("Deployment error: %v", err)
+			endpointStatuses = append(endpointStatuses, status)
+			desired[endpoint.ID] = true
+			continue
 		}
-	}
 
-	for i := range listofroutes.Items {
-		dep := &listofroutes.Items[i]
-		eid := dep.Labels["endpointscaler.io/endpoint"]
-		if !desired[eid] {
-			_ = r.Delete(ctx, dep)
+		if err := r.observeEndpoint(ctx, policy, &endpoint, &status); err != nil {
+			logger.Error(err, "failed to observe endpoint readiness", "endpoint", endpoint.ID)
+			status.Message = fmt.Sprintf("Status error: %v", err)
+			endpointStatuses = append(endpointStatuses, status)
+			desired[endpoint.ID] = true
+			continue
 		}
-	}
 
-	for i := range listofgrpcroutes.Items {
-		dep := &listofgrpcroutes.Items[i]
-		eid := dep.Labels["endpointscaler.io/endpoint"]
-		if !desired[eid] {
-			_ = r.Delete(ctx, dep)
+		// Unsupported timeouts or retries only warn: the gateway still
+		// routes the endpoint, without them
+		if err := r.observeRouteFeatures(ctx, policy, &endpoint, &status); err != nil {
+			logger.Error(err, "failed to observe gateway features", "endpoint", endpoint.ID)
 		}
+
+		RecordEndpointInfo(policy.Namespace, policy.Name, endpoint.ID, endpoint.Type, endpoint.Strategy)
+		endpointStatuses = append(endpointStatuses, status)
+		desired[endpoint.ID] = true
 	}
 
-	for i := range listofhpas.Items {
-		dep := &listofhpas.Items[i]
-		eid := dep.Labels["endpointscaler.io/endpoint"]
-		if !desired[eid] {
-			_ = r.Delete(ctx, dep)
+	routed := map[string]bool{}
+	for eid := range desired {
+		if _, ok := conflicts[eid]; !ok {
+			routed[eid] = true
 		}
 	}
+	if err := r.pruneStale(ctx, policy, desired, routed); err != nil {
+		logger.Error(err, "failed to remove stale resources")
+	}
 
 	policy.Status.EndpointCount = len(policy.Spec.Endpoints)
 	policy.Status.EndpointStatuses = endpointStatuses
@@ -212,18 +352,223 @@ func (r *EndpointPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// shortestRequeue combines requeue intervals, where zero means none.
+func shortestRequeue(current, next time.Duration) time.Duration {
+	if next > 0 && (current == 0 || next < current) {
+		return next
+	}
+	return current
+}
+
+func (r *EndpointPolicyReconciler) now() time.Time {
+	if r.Clock == nil {
+		return time.Now()
+	}
+	return r.Clock.Now()
+}
+
+// event records an event on the policy.
+func (r *EndpointPolicyReconciler) event(policy *esv1alpha1.EndpointPolicy, eventType, reason, messageFmt string, args ...interface{}) {
+	if r.Recorder == nil {
+		return
+	}
+	r.Recorder.Eventf(policy, eventType, reason, messageFmt, args...)
+}
+
+// pruneStale deletes objects labelled for the policy whose endpoint is no
+// longer desired or that sit outside the namespace they belong in (e.g. after
+// appRef.namespace changed). Routes are also deleted for desired endpoints
+// that are not routed. Lists are cluster-wide since endpoint resources may
+// live outside the policy namespace.
+func (r *EndpointPolicyReconciler) pruneStale(
+	ctx context.Context,
+	policy *esv1alpha1.EndpointPolicy,
+	desired, routed map[string]bool,
+) error {
+	labels := client.MatchingLabels{
+		"endpointscaler.io/policy":           policy.Name,
+		"endpointscaler.io/policy-namespace": policy.Namespace,
+		"app.kubernetes.io/managed-by":       "endpoint-scaler",
+	}
+	appNS := appNamespace(policy)
+
+	prune := func(list client.ObjectList, namespace string, keep map[string]bool) error {
+		if err := r.List(ctx, list, labels); err != nil {
+			return err
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			obj := item.(client.Object)
+			eid := obj.GetLabels()["endpointscaler.io/endpoint"]
+			if keep[eid] && obj.GetNamespace() == namespace {
+				continue
+			}
+			if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := prune(&appsv1.DeploymentList{}, appNS, desired); err != nil {
+		return err
+	}
+	if err := prune(&corev1.ServiceList{}, appNS, desired); err != nil {
+		return err
+	}
+	if err := prune(&autoscalingv2.HorizontalPodAutoscalerList{}, appNS, desired); err != nil {
+		return err
+	}
+	installed, err := kedaInstalled(r.RESTMapper())
+	if err != nil {
+		return err
+	}
+	if installed {
+		scaledObjects := &unstructured.UnstructuredList{}
+		scaledObjects.SetGroupVersionKind(scaledObjectGVK.GroupVersion().WithKind(scaledObjectGVK.Kind + "List"))
+		if err := prune(scaledObjects, appNS, desired); err != nil {
+			return err
+		}
+	}
+	if err := prune(&gatewayv1.HTTPRouteList{}, policy.Namespace, routed); err != nil {
+		return err
+	}
+	if err := prune(&gatewayv1.GRPCRouteList{}, policy.Namespace, routed); err != nil {
+		return err
+	}
+
+	// The ReferenceGrant is per policy rather than per endpoint
+	grants := &gatewayv1beta1.ReferenceGrantList{}
+	if err := r.List(ctx, grants, labels); err != nil {
+		return err
+	}
+	for i := range grants.Items {
+		grant := &grants.Items[i]
+		if policy.DeletionTimestamp.IsZero() && crossNamespace(policy) && grant.Namespace == appNS {
+			continue
+		}
+		if err := r.Delete(ctx, grant); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (r *EndpointPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &esv1alpha1.EndpointPolicy{},
+		deploymentRefIndex, indexDeploymentRef); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &esv1alpha1.EndpointPolicy{},
+		RouteClaimIndex, IndexRouteClaims); err != nil {
+		return err
+	}
+
+	// Deployments, Services and HPAs may live in appRef.namespace, where owner
+	// references cannot point back at the policy, so they are mapped by label.
+	// Deployment and route watches deliberately have no generation predicate:
+	// endpoint readiness follows their status.
+	byLabel := handler.EnqueueRequestsFromMapFunc(policyForObject)
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&esv1alpha1.EndpointPolicy{}).
-		Owns(&appsv1.Deployment{}).
-		Owns(&corev1.Service{}).
-		Owns(&autoscalingv2.HorizontalPodAutoscaler{}).
+		Watches(&esv1alpha1.EndpointPolicy{}, handler.EnqueueRequestsFromMapFunc(r.policiesSharingClaims)).
+		Watches(&appsv1.Deployment{}, handler.EnqueueRequestsFromMapFunc(r.policiesForDeployment)).
+		Watches(&corev1.Service{}, byLabel).
+		Watches(&autoscalingv2.HorizontalPodAutoscaler{}, byLabel).
+		Watches(&gatewayv1beta1.ReferenceGrant{}, byLabel).
+		Watches(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(r.policiesForNamespace)).
 		Owns(&gatewayv1.HTTPRoute{}).
-		Owns(&gatewayv1.GRPCRoute{}).
-		Complete(r)
+		Owns(&gatewayv1.GRPCRoute{})
+
+	// ScaledObjects are only watched when KEDA is installed at startup. KEDA
+	// endpoints still reconcile if it is installed later, but changes made to
+	// their ScaledObjects are only corrected on the next policy event.
+	installed, err := kedaInstalled(mgr.GetRESTMapper())
+	if err != nil {
+		return err
+	}
+	if installed {
+		scaledObject := &unstructured.Unstructured{}
+		scaledObject.SetGroupVersionKind(scaledObjectGVK)
+		b = b.Watches(scaledObject, byLabel)
+	}
+
+	return b.Complete(r)
+}
+
+// policyForObject maps an object created by the controller back to the
+// EndpointPolicy that owns it.
+func policyForObject(_ context.Context, obj client.Object) []reconcile.Request {
+	labels := obj.GetLabels()
+	if labels["app.kubernetes.io/managed-by"] != "endpoint-scaler" {
+		return nil
+	}
+	name := labels["endpointscaler.io/policy"]
+	if name == "" {
+		return nil
+	}
+	namespace := labels["endpointscaler.io/policy-namespace"]
+	if namespace == "" {
+		namespace = obj.GetNamespace()
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}}
+}
+
+// policiesForDeployment maps an endpoint Deployment to its policy, and a main
+// application Deployment to every policy inheriting its pod template.
+func (r *EndpointPolicyReconciler) policiesForDeployment(ctx context.Context, obj client.Object) []reconcile.Request {
+	if requests := policyForObject(ctx, obj); requests != nil {
+		return requests
+	}
+
+	policies := &esv1alpha1.EndpointPolicyList{}
+	key := obj.GetNamespace() + "/" + obj.GetName()
+	if err := r.List(ctx, policies, client.MatchingFields{deploymentRefIndex: key}); err != nil {
+		log.FromContext(ctx).Error(err, "failed to list policies for deployment", "deployment", key)
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(policies.Items))
+	for _, policy := range policies.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&policy)})
+	}
+	return requests
+}
+
+func indexDeploymentRef(obj client.Object) []string {
+	policy := obj.(*esv1alpha1.EndpointPolicy)
+	if policy.Spec.AppRef.DeploymentRef == nil {
+		return nil
+	}
+	return []string{appNamespace(policy) + "/" + policy.Spec.AppRef.DeploymentRef.Name}
+}
+
+// setOwner makes the policy the controller of obj when both share a namespace.
+// Objects elsewhere are tracked through their labels and the finalizer.
+func (r *EndpointPolicyReconciler) setOwner(policy *esv1alpha1.EndpointPolicy, obj client.Object) error {
+	if obj.GetNamespace() != policy.Namespace {
+		return nil
+	}
+	return ctrl.SetControllerReference(policy, obj, r.Scheme)
+}
+
+// apply server-side applies obj as the controller's field manager. Only the
+// fields set on obj are owned; anything else on the live object is preserved.
+func (r *EndpointPolicyReconciler) apply(ctx context.Context, obj client.Object) error {
+	gvk, err := apiutil.GVKForObject(obj, r.Scheme)
+	if err != nil {
+		return err
+	}
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	obj.SetManagedFields(nil)
+	obj.SetResourceVersion("")
+	return r.Patch(ctx, obj, client.Apply, client.FieldOwner(fieldManager), client.ForceOwnership)
 }
 
 func endpointResourceName(policy *esv1alpha1.EndpointPolicy, endpoint *esv1alpha1.EndpointSpec) string {
@@ -238,6 +583,19 @@ func mainServiceName(policy *esv1alpha1.EndpointPolicy) string {
 	return fmt.Sprintf("%s-svc", policy.Spec.AppRef.Name)
 }
 
+// appNamespace is where the main application, and therefore the endpoint
+// Deployments, Services and HPAs, live. Routes stay in the policy namespace.
+func appNamespace(policy *esv1alpha1.EndpointPolicy) string {
+	if policy.Spec.AppRef.Namespace != "" {
+		return policy.Spec.AppRef.Namespace
+	}
+	return policy.Namespace
+}
+
+func crossNamespace(policy *esv1alpha1.EndpointPolicy) bool {
+	return appNamespace(policy) != policy.Namespace
+}
+
 func generateLabels(policy *esv1alpha1.EndpointPolicy, endpoint *esv1alpha1.EndpointSpec) map[string]string {
 	return map[string]string{
 		"app.kubernetes.io/name":       policy.Spec.AppRef.Name,
@@ -247,3 +605,12 @@ func generateLabels(policy *esv1alpha1.EndpointPolicy, endpoint *esv1alpha1.Endp
 		"endpointscaler.io/endpoint":   endpoint.ID,
 	}
 }
+
+// objectLabels are the metadata labels for generated objects. They extend
+// generateLabels with the policy namespace, which is kept out of selectors
+// because Deployment selectors are immutable.
+func objectLabels(policy *esv1alpha1.EndpointPolicy, endpoint *esv1alpha1.EndpointSpec) map[string]string {
+	labels := generateLabels(policy, endpoint)
+	labels["endpointscaler.io/policy-namespace"] = policy.Namespace
+	return labels
+}
@@ -0,0 +1,218 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+)
+
+func newFakeReconciler(t *testing.T, objs ...client.Object) *EndpointPolicyReconciler {
+	t.Helper()
+	return newFakeReconcilerWithMapper(t, meta.NewDefaultRESTMapper(nil), objs...)
+}
+
+// newFakeReconcilerWithMapper lets tests decide which optional APIs, such as
+// KEDA's, the fake cluster serves.
+func newFakeReconcilerWithMapper(t *testing.T, mapper meta.RESTMapper, objs ...client.Object) *EndpointPolicyReconciler {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{
+		clientgoscheme.AddToScheme,
+		gatewayv1.Install,
+		gatewayv1beta1.Install,
+		esv1alpha1.AddToScheme,
+	} {
+		if err := add(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithRESTMapper(mapper).
+		WithObjects(objs...).
+		WithStatusSubresource(&esv1alpha1.EndpointPolicy{}, &gatewayv1.HTTPRoute{}, &gatewayv1.GRPCRoute{}).
+		WithIndex(&esv1alpha1.EndpointPolicy{}, deploymentRefIndex, indexDeploymentRef).
+		WithIndex(&esv1alpha1.EndpointPolicy{}, RouteClaimIndex, IndexRouteClaims).
+		Build()
+	return &EndpointPolicyReconciler{Client: c, Scheme: scheme}
+}
+
+func crossNamespacePolicy() *esv1alpha1.EndpointPolicy {
+	return &esv1alpha1.EndpointPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-policy",
+			Namespace: "platform",
+			UID:       "policy-uid",
+		},
+		Spec: esv1alpha1.EndpointPolicySpec{
+			AppRef: esv1alpha1.AppReference{
+				Name:      "my-app",
+				Namespace: "apps",
+				Image:     "my-app:v1",
+			},
+			GatewayRef: esv1alpha1.GatewayReference{
+				Name: "my-gateway",
+			},
+			Endpoints: []esv1alpha1.EndpointSpec{
+				{
+					ID:    "lookup",
+					Type:  "http",
+					Match: esv1alpha1.MatchSpec{Path: "/api/lookup"},
+				},
+			},
+		},
+	}
+}
+
+// appNamespaceObject returns the "apps" namespace, allowing policies from the
+// given namespaces.
+func appNamespaceObject(allowed string) *corev1.Namespace {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps"}}
+	if allowed != "" {
+		ns.Annotations = map[string]string{AllowedPolicyNamespacesAnnotation: allowed}
+	}
+	return ns
+}
+
+func TestReconcile_CrossNamespace(t *testing.T) {
+	policy := crossNamespacePolicy()
+	r := newFakeReconciler(t, policy, appNamespaceObject("platform"))
+	ctx := context.Background()
+	req := reconcileRequest(policy)
+
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Endpoint workloads live next to the app, without an owner reference
+	dep := &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "my-app-lookup", Namespace: "apps"}, dep); err != nil {
+		t.Fatalf("expected deployment in app namespace: %v", err)
+	}
+	if len(dep.OwnerReferences) != 0 {
+		t.Errorf("expected no owner references across namespaces, got %v", dep.OwnerReferences)
+	}
+	if dep.Labels["endpointscaler.io/policy-namespace"] != "platform" {
+		t.Errorf("expected policy-namespace label 'platform', got %q", dep.Labels["endpointscaler.io/policy-namespace"])
+	}
+
+	svc := &corev1.Service{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "my-app-lookup-svc", Namespace: "apps"}, svc); err != nil {
+		t.Fatalf("expected service in app namespace: %v", err)
+	}
+
+	// Routes stay with the policy and reference the app namespace
+	route := &gatewayv1.HTTPRoute{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "my-app-lookup", Namespace: "platform"}, route); err != nil {
+		t.Fatalf("expected route in policy namespace: %v", err)
+	}
+
+	grant := &gatewayv1beta1.ReferenceGrant{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "endpointscaler-platform-test-policy", Namespace: "apps"}, grant); err != nil {
+		t.Fatalf("expected ReferenceGrant in app namespace: %v", err)
+	}
+
+	latest := &esv1alpha1.EndpointPolicy{}
+	if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+		t.Fatal(err)
+	}
+	if len(latest.Finalizers) != 1 || latest.Finalizers[0] != finalizerName {
+		t.Errorf("expected finalizer %q, got %v", finalizerName, latest.Finalizers)
+	}
+
+	// Deleting the policy removes objects owner references cannot reach
+	if err := r.Delete(ctx, latest); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("unexpected error during cleanup: %v", err)
+	}
+
+	if err := r.Get(ctx, types.NamespacedName{Name: "my-app-lookup", Namespace: "apps"}, dep); err == nil {
+		t.Error("expected deployment in app namespace to be deleted")
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: "endpointscaler-platform-test-policy", Namespace: "apps"}, grant); err == nil {
+		t.Error("expected ReferenceGrant to be deleted")
+	}
+}
+
+func TestPolicyForObject(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   []types.NamespacedName
+	}{
+		{
+			name: "cross namespace",
+			labels: map[string]string{
+				"app.kubernetes.io/managed-by":       "endpoint-scaler",
+				"endpointscaler.io/policy":           "test-policy",
+				"endpointscaler.io/policy-namespace": "platform",
+			},
+			want: []types.NamespacedName{{Name: "test-policy", Namespace: "platform"}},
+		},
+		{
+			name: "legacy labels fall back to object namespace",
+			labels: map[string]string{
+				"app.kubernetes.io/managed-by": "endpoint-scaler",
+				"endpointscaler.io/policy":     "test-policy",
+			},
+			want: []types.NamespacedName{{Name: "test-policy", Namespace: "apps"}},
+		},
+		{
+			name:   "not managed",
+			labels: map[string]string{"endpointscaler.io/policy": "test-policy"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Labels: tt.labels}}
+			reqs := policyForObject(context.Background(), obj)
+			if len(reqs) != len(tt.want) {
+				t.Fatalf("expected %d requests, got %d", len(tt.want), len(reqs))
+			}
+			for i := range reqs {
+				if reqs[i].NamespacedName != tt.want[i] {
+					t.Errorf("expected %v, got %v", tt.want[i], reqs[i].NamespacedName)
+				}
+			}
+		})
+	}
+}
+
+func reconcileRequest(policy *esv1alpha1.EndpointPolicy) ctrl.Request {
+	return ctrl.Request{NamespacedName: types.NamespacedName{Name: policy.Name, Namespace: policy.Namespace}}
+}
+
+func TestPoliciesForDeployment(t *testing.T) {
+	referencing := deploymentRefPolicy()
+	other := testEndpointPolicy()
+	other.Name = "other-policy"
+	r := newFakeReconciler(t, referencing, other)
+	ctx := context.Background()
+
+	reqs := r.policiesForDeployment(ctx, mainDeployment("my-app:v1"))
+	if len(reqs) != 1 || reqs[0].NamespacedName != (types.NamespacedName{Name: "test-policy", Namespace: "default"}) {
+		t.Errorf("expected request for test-policy, got %v", reqs)
+	}
+
+	unrelated := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "default"}}
+	if reqs := r.policiesForDeployment(ctx, unrelated); len(reqs) != 0 {
+		t.Errorf("expected no requests for unrelated deployment, got %v", reqs)
+	}
+}
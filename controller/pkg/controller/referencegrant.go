@@ -0,0 +1,186 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+)
+
+// AllowedPolicyNamespacesAnnotation is set on an application namespace to
+// consent to EndpointPolicies from other namespaces managing endpoints in it
+// through appRef.namespace. Its value is a comma-separated list of policy
+// namespaces, or "*" for every namespace.
+const AllowedPolicyNamespacesAnnotation = "endpointscaler.io/allowed-policy-namespaces"
+
+// ReasonAppNamespaceNotAllowed marks a policy whose appRef.namespace has not
+// consented to it.
+const ReasonAppNamespaceNotAllowed = "AppNamespaceNotAllowed"
+
+// appNamespaceDenial returns why the policy may not manage endpoints in its
+// app namespace, or "" when it is its own namespace or lists the policy
+// namespace in its AllowedPolicyNamespacesAnnotation. Without that consent, a
+// policy could have the controller create workloads, with any service
+// account, and grant route access in namespaces its author cannot write to.
+func (r *EndpointPolicyReconciler) appNamespaceDenial(
+	ctx context.Context,
+	policy *esv1alpha1.EndpointPolicy,
+) (string, error) {
+	if !crossNamespace(policy) {
+		return "", nil
+	}
+	appNS := appNamespace(policy)
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: appNS}, ns); err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Sprintf("namespace %s not found", appNS), nil
+		}
+		return "", fmt.Errorf("namespace %s: %w", appNS, err)
+	}
+	for _, allowed := range strings.Split(ns.Annotations[AllowedPolicyNamespacesAnnotation], ",") {
+		allowed = strings.TrimSpace(allowed)
+		if allowed == "*" || allowed == policy.Namespace {
+			return "", nil
+		}
+	}
+	return fmt.Sprintf("namespace %s does not allow EndpointPolicies from namespace %q; add it to the %s annotation",
+		appNS, policy.Namespace, AllowedPolicyNamespacesAnnotation), nil
+}
+
+// policiesForNamespace maps a namespace to the policies whose app namespace
+// it is, so that they are reconciled when it grants or revokes consent.
+func (r *EndpointPolicyReconciler) policiesForNamespace(ctx context.Context, obj client.Object) []reconcile.Request {
+	policies := &esv1alpha1.EndpointPolicyList{}
+	if err := r.List(ctx, policies); err != nil {
+		log.FromContext(ctx).Error(err, "failed to list policies for namespace", "namespace", obj.GetName())
+		return nil
+	}
+	var reqs []reconcile.Request
+	for i := range policies.Items {
+		policy := &policies.Items[i]
+		if crossNamespace(policy) && appNamespace(policy) == obj.GetName() {
+			reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Name: policy.Name, Namespace: policy.Namespace}})
+		}
+	}
+	return reqs
+}
+
+// reconcileReferenceGrant allows routes in the policy namespace to reference
+// Services in appRef.namespace. Nothing is created when both are the same;
+// a grant left over from a previous cross-namespace spec is pruned.
+func (r *EndpointPolicyReconciler) reconcileReferenceGrant(
+	ctx context.Context,
+	policy *esv1alpha1.EndpointPolicy,
+) error {
+	if !crossNamespace(policy) {
+		return nil
+	}
+
+	logger := log.FromContext(ctx)
+	desired := r.buildReferenceGrant(policy)
+	logger.Info("Applying ReferenceGrant", "name", desired.Name, "namespace", desired.Namespace)
+	return r.apply(ctx, desired)
+}
+
+func (r *EndpointPolicyReconciler) buildReferenceGrant(
+	policy *esv1alpha1.EndpointPolicy,
+) *gatewayv1beta1.ReferenceGrant {
+	routeNS := gatewayv1.Namespace(policy.Namespace)
+
+	return &gatewayv1beta1.ReferenceGrant{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      referenceGrantName(policy),
+			Namespace: appNamespace(policy),
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by":       "endpoint-scaler",
+				"endpointscaler.io/policy":           policy.Name,
+				"endpointscaler.io/policy-namespace": policy.Namespace,
+			},
+		},
+		Spec: gatewayv1beta1.ReferenceGrantSpec{
+			From: []gatewayv1beta1.ReferenceGrantFrom{
+				{Group: gatewayv1.GroupName, Kind: "HTTPRoute", Namespace: routeNS},
+				{Group: gatewayv1.GroupName, Kind: "GRPCRoute", Namespace: routeNS},
+			},
+			To: []gatewayv1beta1.ReferenceGrantTo{
+				{Group: "", Kind: "Service"},
+			},
+		},
+	}
+}
+
+func referenceGrantName(policy *esv1alpha1.EndpointPolicy) string {
+	return fmt.Sprintf("endpointscaler-%s-%s", policy.Namespace, policy.Name)
+}
+
+// validateGatewayAllowsRoutes checks that a Gateway in another namespace has
+// at least one listener accepting routes from the policy namespace. Route
+// attachment is governed by the listener's allowedRoutes, not by a
+// ReferenceGrant, so this can only be checked, not granted.
+func (r *EndpointPolicyReconciler) validateGatewayAllowsRoutes(
+	ctx context.Context,
+	policy *esv1alpha1.EndpointPolicy,
+) error {
+	gatewayNS := policy.Spec.GatewayRef.Namespace
+	if gatewayNS == "" || gatewayNS == policy.Namespace {
+		return nil
+	}
+
+	gw := &gatewayv1.Gateway{}
+	err := r.Get(ctx, types.NamespacedName{
+		Name:      policy.Spec.GatewayRef.Name,
+		Namespace: gatewayNS,
+	}, gw)
+	if err != nil {
+		return fmt.Errorf("gateway %s/%s: %w", gatewayNS, policy.Spec.GatewayRef.Name, err)
+	}
+
+	var routeNS *corev1.Namespace
+	for _, listener := range gw.Spec.Listeners {
+		from := gatewayv1.NamespacesFromSame
+		var selector *metav1.LabelSelector
+		if listener.AllowedRoutes != nil && listener.AllowedRoutes.Namespaces != nil {
+			if listener.AllowedRoutes.Namespaces.From != nil {
+				from = *listener.AllowedRoutes.Namespaces.From
+			}
+			selector = listener.AllowedRoutes.Namespaces.Selector
+		}
+
+		switch from {
+		case gatewayv1.NamespacesFromAll:
+			return nil
+		case gatewayv1.NamespacesFromSelector:
+			if selector == nil {
+				continue
+			}
+			if routeNS == nil {
+				routeNS = &corev1.Namespace{}
+				if err := r.Get(ctx, types.NamespacedName{Name: policy.Namespace}, routeNS); err != nil {
+					return fmt.Errorf("namespace %s: %w", policy.Namespace, err)
+				}
+			}
+			sel, err := metav1.LabelSelectorAsSelector(selector)
+			if err != nil {
+				continue
+			}
+			if sel.Matches(labels.Set(routeNS.Labels)) {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("gateway %s/%s has no listener allowing routes from namespace %q",
+		gatewayNS, policy.Spec.GatewayRef.Name, policy.Namespace)
+}
@@ -0,0 +1,206 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+)
+
+func TestBuildReferenceGrant(t *testing.T) {
+	r := &EndpointPolicyReconciler{}
+	policy := crossNamespacePolicy()
+
+	grant := r.buildReferenceGrant(policy)
+
+	if grant.Namespace != "apps" {
+		t.Errorf("expected grant in app namespace 'apps', got %q", grant.Namespace)
+	}
+	if len(grant.Spec.From) != 2 {
+		t.Fatalf("expected 2 from entries, got %d", len(grant.Spec.From))
+	}
+	for _, from := range grant.Spec.From {
+		if from.Namespace != "platform" {
+			t.Errorf("expected from namespace 'platform', got %q", from.Namespace)
+		}
+		if from.Kind != "HTTPRoute" && from.Kind != "GRPCRoute" {
+			t.Errorf("unexpected from kind %q", from.Kind)
+		}
+	}
+	if len(grant.Spec.To) != 1 || grant.Spec.To[0].Kind != "Service" {
+		t.Errorf("expected grant to Service, got %v", grant.Spec.To)
+	}
+}
+
+func TestBuildHTTPRoute_CrossNamespaceBackends(t *testing.T) {
+	r := &EndpointPolicyReconciler{}
+	policy := crossNamespacePolicy()
+	policy.Spec.Endpoints[0].Strategy = "canary"
+
+	route := r.buildHTTPRoute(policy, &policy.Spec.Endpoints[0])
+
+	if route.Namespace != "platform" {
+		t.Errorf("expected route in policy namespace 'platform', got %q", route.Namespace)
+	}
+	for _, ref := range route.Spec.Rules[0].BackendRefs {
+		if ref.Namespace == nil || *ref.Namespace != "apps" {
+			t.Errorf("expected backend %q in namespace 'apps', got %v", ref.Name, ref.Namespace)
+		}
+	}
+}
+
+func TestValidateGatewayAllowsRoutes(t *testing.T) {
+	from := func(f gatewayv1.FromNamespaces, sel *metav1.LabelSelector) *gatewayv1.AllowedRoutes {
+		return &gatewayv1.AllowedRoutes{Namespaces: &gatewayv1.RouteNamespaces{From: &f, Selector: sel}}
+	}
+
+	tests := []struct {
+		name    string
+		allowed *gatewayv1.AllowedRoutes
+		wantErr string
+	}{
+		{
+			name:    "default is same namespace",
+			allowed: nil,
+			wantErr: "no listener allowing routes",
+		},
+		{
+			name:    "all namespaces",
+			allowed: from(gatewayv1.NamespacesFromAll, nil),
+		},
+		{
+			name: "selector matches",
+			allowed: from(gatewayv1.NamespacesFromSelector, &metav1.LabelSelector{
+				MatchLabels: map[string]string{"gateway-access": "true"},
+			}),
+		},
+		{
+			name: "selector does not match",
+			allowed: from(gatewayv1.NamespacesFromSelector, &metav1.LabelSelector{
+				MatchLabels: map[string]string{"team": "other"},
+			}),
+			wantErr: "no listener allowing routes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gw := &gatewayv1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-gateway", Namespace: "gateway-ns"},
+				Spec: gatewayv1.GatewaySpec{
+					GatewayClassName: "example",
+					Listeners: []gatewayv1.Listener{{
+						Name:          "http",
+						Port:          80,
+						Protocol:      gatewayv1.HTTPProtocolType,
+						AllowedRoutes: tt.allowed,
+					}},
+				},
+			}
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:   "platform",
+				Labels: map[string]string{"gateway-access": "true"},
+			}}
+			r := newFakeReconciler(t, gw, ns)
+			policy := crossNamespacePolicy()
+			policy.Spec.GatewayRef.Namespace = "gateway-ns"
+
+			err := r.validateGatewayAllowsRoutes(context.Background(), policy)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateGatewayAllowsRoutes_MissingGateway(t *testing.T) {
+	r := newFakeReconciler(t)
+	policy := crossNamespacePolicy()
+	policy.Spec.GatewayRef.Namespace = "gateway-ns"
+
+	if err := r.validateGatewayAllowsRoutes(context.Background(), policy); err == nil {
+		t.Error("expected error for missing gateway")
+	}
+}
+
+func TestAppNamespaceDenial(t *testing.T) {
+	tests := []struct {
+		name    string
+		ns      *corev1.Namespace
+		allowed bool
+	}{
+		{"listed", appNamespaceObject("team-a, platform"), true},
+		{"wildcard", appNamespaceObject("*"), true},
+		{"other namespaces", appNamespaceObject("team-a"), false},
+		{"no annotation", appNamespaceObject(""), false},
+		{"missing namespace", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var objs []client.Object
+			if tt.ns != nil {
+				objs = append(objs, tt.ns)
+			}
+			r := newFakeReconciler(t, objs...)
+			denial, err := r.appNamespaceDenial(context.Background(), crossNamespacePolicy())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (denial == "") != tt.allowed {
+				t.Errorf("expected allowed=%v, got denial %q", tt.allowed, denial)
+			}
+		})
+	}
+}
+
+func TestReconcile_CrossNamespaceNotAllowed(t *testing.T) {
+	policy := crossNamespacePolicy()
+	r := newFakeReconciler(t, policy, appNamespaceObject("team-a"))
+	ctx := context.Background()
+
+	if _, err := r.Reconcile(ctx, reconcileRequest(policy)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Nothing is created in a namespace that has not consented
+	err := r.Get(ctx, types.NamespacedName{Name: "my-app-lookup", Namespace: "apps"}, &appsv1.Deployment{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected no deployment in the app namespace, got %v", err)
+	}
+	err = r.Get(ctx, types.NamespacedName{Name: "endpointscaler-platform-test-policy", Namespace: "apps"}, &gatewayv1beta1.ReferenceGrant{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected no ReferenceGrant in the app namespace, got %v", err)
+	}
+	latest := &esv1alpha1.EndpointPolicy{}
+	if err := r.Get(ctx, reconcileRequest(policy).NamespacedName, latest); err != nil {
+		t.Fatal(err)
+	}
+	cond := meta.FindStatusCondition(latest.Status.Conditions, "Ready")
+	if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != ReasonAppNamespaceNotAllowed ||
+		!strings.Contains(cond.Message, AllowedPolicyNamespacesAnnotation) {
+		t.Errorf("expected Ready=False with %s, got %+v", ReasonAppNamespaceNotAllowed, cond)
+	}
+
+	// Consent given later is picked up through the namespace watch
+	reqs := r.policiesForNamespace(ctx, appNamespaceObject("platform"))
+	if len(reqs) != 1 || reqs[0].NamespacedName != reconcileRequest(policy).NamespacedName {
+		t.Errorf("expected the policy to be enqueued for its app namespace, got %v", reqs)
+	}
+}
@@ -3,12 +3,12 @@ package controller
 import (
 	"context"
 	"fmt"
+	"regexp"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
 
@@ -16,8 +16,11 @@ import (
 )
 
 const (
-	StrategyCanary  = "canary"
-	StrategyPrimary = "primary"
+	StrategyCanary      = "canary"
+	StrategyPrimary     = "primary"
+	StrategyProgressive = "progressive"
+	StrategyShadow      = "shadow"
+	StrategyABTest      = "abtest"
 )
 
 func (r *EndpointPolicyReconciler) reconcileRoute(
@@ -33,12 +36,16 @@ func (r *EndpointPolicyReconciler) reconcileRoute(
 	if strategy == "" {
 		strategy = StrategyPrimary
 	}
-	if strategy == StrategyCanary {
+	if strategy == StrategyCanary || strategy == StrategyProgressive {
 		if err := r.validateMainServiceExists(ctx, policy); err != nil {
 			return "", err
 		}
 	}
 
+	if err := r.validateGatewayAllowsRoutes(ctx, policy); err != nil {
+		return "", err
+	}
+
 	endpointType := endpoint.Type
 	if endpointType == "" {
 		endpointType = "http"
@@ -60,10 +67,10 @@ func (r *EndpointPolicyReconciler) validateMainServiceExists(
 	svc := &corev1.Service{}
 	err := r.Get(ctx, types.NamespacedName{
 		Name:      mainSvc,
-		Namespace: policy.Namespace,
+		Namespace: appNamespace(policy),
 	}, svc)
 	if err != nil {
-		return fmt.Errorf("canary strategy requires main service %q to exist: %w", mainSvc, err)
+		return fmt.Errorf("weighted strategies require main service %q to exist: %w", mainSvc, err)
 	}
 	return nil
 }
@@ -81,20 +88,8 @@ func (r *EndpointPolicyReconciler) reconcileHTTPRoute(
 		return "", err
 	}
 
-	existing := &gatewayv1.HTTPRoute{}
-	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: policy.Namespace}, existing)
-	if err != nil {
-		if client.IgnoreNotFound(err) != nil {
-			return "", err
-		}
-		logger.Info("Creating HTTPRoute", "name", name)
-		return name, r.Create(ctx, desired)
-	}
-
-	existing.Spec = desired.Spec
-	existing.Labels = desired.Labels
-	logger.Info("Updating HTTPRoute", "name", name)
-	return name, r.Update(ctx, existing)
+	logger.Info("Applying HTTPRoute", "name", name)
+	return name, r.apply(ctx, desired)
 }
 
 func (r *EndpointPolicyReconciler) buildHTTPRoute(
@@ -102,7 +97,6 @@ func (r *EndpointPolicyReconciler) buildHTTPRoute(
 	endpoint *esv1alpha1.EndpointSpec,
 ) *gatewayv1.HTTPRoute {
 	name := endpointResourceName(policy, endpoint)
-	labels := generateLabels(policy, endpoint)
 
 	gatewayKind := gatewayv1.Kind("Gateway")
 	parentRef := gatewayv1.ParentReference{
@@ -114,29 +108,60 @@ func (r *EndpointPolicyReconciler) buildHTTPRoute(
 		parentRef.Namespace = &gatewayNS
 	}
 
-	pathMatch := gatewayv1.PathMatchPathPrefix
-	path := endpoint.Match.Path
-	backendRefs := r.buildHTTPBackendRefs(policy, endpoint)
+	specMatches := endpoint.HTTPMatches()
+	matches := make([]gatewayv1.HTTPRouteMatch, 0, len(specMatches))
+	for i := range specMatches {
+		matches = append(matches, buildHTTPRouteMatch(&specMatches[i]))
+	}
+	// A redirect answers the request itself, so the rule must not have
+	// backends
+	var backendRefs []gatewayv1.HTTPBackendRef
+	if !hasRedirect(endpoint) {
+		backendRefs = r.buildHTTPBackendRefs(policy, endpoint)
+	}
+
+	filters := buildHTTPFilters(policy, endpoint)
+	timeouts := buildHTTPTimeouts(endpoint)
+	retry := buildHTTPRetry(endpoint)
+	rules := []gatewayv1.HTTPRouteRule{{
+		Matches:     matches,
+		Filters:     filters,
+		BackendRefs: backendRefs,
+		Timeouts:    timeouts,
+		Retry:       retry,
+	}}
+	// Gateways prefer the matches with more header conditions, so requests
+	// selected by the A/B test reach the endpoint and the rest the main
+	// service
+	if endpoint.Strategy == StrategyABTest {
+		rules = []gatewayv1.HTTPRouteRule{
+			{
+				Matches:     abTestHTTPMatches(matches, endpoint.ABTest),
+				Filters:     filters,
+				BackendRefs: backendRefs,
+				Timeouts:    timeouts,
+				Retry:       retry,
+			},
+			{
+				Matches:     matches,
+				BackendRefs: mainHTTPBackendRefs(policy),
+				Timeouts:    timeouts,
+				Retry:       retry,
+			},
+		}
+	}
 
 	route := &gatewayv1.HTTPRoute{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
 			Namespace: policy.Namespace,
-			Labels:    labels,
+			Labels:    objectLabels(policy, endpoint),
 		},
 		Spec: gatewayv1.HTTPRouteSpec{
 			CommonRouteSpec: gatewayv1.CommonRouteSpec{
 				ParentRefs: []gatewayv1.ParentReference{parentRef},
 			},
-			Rules: []gatewayv1.HTTPRouteRule{{
-				Matches: []gatewayv1.HTTPRouteMatch{{
-					Path: &gatewayv1.HTTPPathMatch{
-						Type:  &pathMatch,
-						Value: &path,
-					},
-				}},
-				BackendRefs: backendRefs,
-			}},
+			Rules: rules,
 		},
 	}
 
@@ -149,38 +174,145 @@ func (r *EndpointPolicyReconciler) buildHTTPRoute(
 	return route
 }
 
+// buildHTTPRouteMatch converts a MatchSpec into an HTTPRouteMatch. All of its
+// conditions must hold for a request to match.
+func buildHTTPRouteMatch(match *esv1alpha1.MatchSpec) gatewayv1.HTTPRouteMatch {
+	pathType := gatewayv1.PathMatchPathPrefix
+	if match.PathType != "" {
+		pathType = gatewayv1.PathMatchType(match.PathType)
+	}
+	path := match.Path
+
+	routeMatch := gatewayv1.HTTPRouteMatch{
+		Path: &gatewayv1.HTTPPathMatch{
+			Type:  &pathType,
+			Value: &path,
+		},
+	}
+
+	if match.Method != "" {
+		method := gatewayv1.HTTPMethod(match.Method)
+		routeMatch.Method = &method
+	}
+
+	for _, h := range match.Headers {
+		headerType := gatewayv1.HeaderMatchExact
+		if h.Type != "" {
+			headerType = gatewayv1.HeaderMatchType(h.Type)
+		}
+		routeMatch.Headers = append(routeMatch.Headers, gatewayv1.HTTPHeaderMatch{
+			Type:  &headerType,
+			Name:  gatewayv1.HTTPHeaderName(h.Name),
+			Value: h.Value,
+		})
+	}
+
+	for _, q := range match.QueryParams {
+		queryType := gatewayv1.QueryParamMatchExact
+		if q.Type != "" {
+			queryType = gatewayv1.QueryParamMatchType(q.Type)
+		}
+		routeMatch.QueryParams = append(routeMatch.QueryParams, gatewayv1.HTTPQueryParamMatch{
+			Type:  &queryType,
+			Name:  gatewayv1.HTTPHeaderName(q.Name),
+			Value: q.Value,
+		})
+	}
+
+	return routeMatch
+}
+
+// abTestHTTPMatches returns every match combined with each A/B test
+// condition in turn. Cookies are matched with a regular expression on the
+// Cookie header.
+func abTestHTTPMatches(matches []gatewayv1.HTTPRouteMatch, spec *esv1alpha1.ABTestSpec) []gatewayv1.HTTPRouteMatch {
+	var conditions []gatewayv1.HTTPHeaderMatch
+	for _, h := range spec.Headers {
+		headerType := gatewayv1.HeaderMatchExact
+		if h.Type != "" {
+			headerType = gatewayv1.HeaderMatchType(h.Type)
+		}
+		conditions = append(conditions, gatewayv1.HTTPHeaderMatch{
+			Type:  &headerType,
+			Name:  gatewayv1.HTTPHeaderName(h.Name),
+			Value: h.Value,
+		})
+	}
+	for _, c := range spec.Cookies {
+		regex := gatewayv1.HeaderMatchRegularExpression
+		conditions = append(conditions, gatewayv1.HTTPHeaderMatch{
+			Type:  &regex,
+			Name:  "Cookie",
+			Value: fmt.Sprintf(`(^|;\s*)%s=%s(;|$)`, regexp.QuoteMeta(c.Name), regexp.QuoteMeta(c.Value)),
+		})
+	}
+
+	abMatches := make([]gatewayv1.HTTPRouteMatch, 0, len(matches)*len(conditions))
+	for _, match := range matches {
+		for _, condition := range conditions {
+			m := *match.DeepCopy()
+			m.Headers = append(m.Headers, condition)
+			abMatches = append(abMatches, m)
+		}
+	}
+	return abMatches
+}
+
+// mainHTTPBackendRefs sends all traffic to the main service.
+func mainHTTPBackendRefs(policy *esv1alpha1.EndpointPolicy) []gatewayv1.HTTPBackendRef {
+	kind := gatewayv1.Kind("Service")
+	port := backendPort(policy)
+	weight := int32(100)
+	return []gatewayv1.HTTPBackendRef{{
+		BackendRef: gatewayv1.BackendRef{
+			BackendObjectReference: gatewayv1.BackendObjectReference{
+				Kind:      &kind,
+				Namespace: backendNamespace(policy),
+				Name:      gatewayv1.ObjectName(mainServiceName(policy)),
+				Port:      &port,
+			},
+			Weight: &weight,
+		},
+	}}
+}
+
 func (r *EndpointPolicyReconciler) buildHTTPBackendRefs(
 	policy *esv1alpha1.EndpointPolicy,
 	endpoint *esv1alpha1.EndpointSpec,
 ) []gatewayv1.HTTPBackendRef {
 	mainSvc := mainServiceName(policy)
-	endpointSvc := endpointServiceName(policy, endpoint)
+	endpointSvc := endpointBackendName(policy, endpoint)
 	servicePort := gatewayv1.PortNumber(policy.Spec.AppRef.Port)
 	if servicePort == 0 {
-		servicePort = 80
+		servicePort = gatewayv1.PortNumber(esv1alpha1.DefaultPort)
 	}
 
 	kind := gatewayv1.Kind("Service")
+	backendNS := backendNamespace(policy)
 	strategy := endpoint.Strategy
 	if strategy == "" {
 		strategy = StrategyPrimary
 	}
 
+	// Shadow endpoints only receive the copies mirrored by the route filters
+	if mainServiceOnly(policy, endpoint) {
+		return mainHTTPBackendRefs(policy)
+	}
+	filters := buildHTTPBackendFilters(policy, endpoint)
+
 	switch strategy {
-	case StrategyCanary:
-		canaryWeight := int32(5)
-		if endpoint.CanaryWeight != nil {
-			canaryWeight = *endpoint.CanaryWeight
-		}
+	case StrategyCanary, StrategyProgressive:
+		canaryWeight := endpointWeight(policy, endpoint)
 		mainWeight := int32(100 - canaryWeight)
 
 		return []gatewayv1.HTTPBackendRef{
 			{
 				BackendRef: gatewayv1.BackendRef{
 					BackendObjectReference: gatewayv1.BackendObjectReference{
-						Kind: &kind,
-						Name: gatewayv1.ObjectName(mainSvc),
-						Port: &servicePort,
+						Kind:      &kind,
+						Namespace: backendNS,
+						Name:      gatewayv1.ObjectName(mainSvc),
+						Port:      &servicePort,
 					},
 					Weight: &mainWeight,
 				},
@@ -188,26 +320,30 @@ func (r *EndpointPolicyReconciler) buildHTTPBackendRefs(
 			{
 				BackendRef: gatewayv1.BackendRef{
 					BackendObjectReference: gatewayv1.BackendObjectReference{
-						Kind: &kind,
-						Name: gatewayv1.ObjectName(endpointSvc),
-						Port: &servicePort,
+						Kind:      &kind,
+						Namespace: backendNS,
+						Name:      gatewayv1.ObjectName(endpointSvc),
+						Port:      &servicePort,
 					},
 					Weight: &canaryWeight,
 				},
+				Filters: filters,
 			},
 		}
 
-	case StrategyPrimary:
+	case StrategyPrimary, StrategyABTest:
 		weight := int32(100)
 		return []gatewayv1.HTTPBackendRef{{
 			BackendRef: gatewayv1.BackendRef{
 				BackendObjectReference: gatewayv1.BackendObjectReference{
-					Kind: &kind,
-					Name: gatewayv1.ObjectName(endpointSvc),
-					Port: &servicePort,
+					Kind:      &kind,
+					Namespace: backendNS,
+					Name:      gatewayv1.ObjectName(endpointSvc),
+					Port:      &servicePort,
 				},
 				Weight: &weight,
 			},
+			Filters: filters,
 		}}
 
 	default:
@@ -215,16 +351,49 @@ func (r *EndpointPolicyReconciler) buildHTTPBackendRefs(
 		return []gatewayv1.HTTPBackendRef{{
 			BackendRef: gatewayv1.BackendRef{
 				BackendObjectReference: gatewayv1.BackendObjectReference{
-					Kind: &kind,
-					Name: gatewayv1.ObjectName(endpointSvc),
-					Port: &servicePort,
+					Kind:      &kind,
+					Namespace: backendNS,
+					Name:      gatewayv1.ObjectName(endpointSvc),
+					Port:      &servicePort,
 				},
 				Weight: &weight,
 			},
+			Filters: filters,
 		}}
 	}
 }
 
+// endpointWeight is the percentage of traffic sent to the endpoint Service by
+// the weighted strategies. Progressive endpoints take it from status so the
+// current step survives controller restarts.
+func endpointWeight(policy *esv1alpha1.EndpointPolicy, endpoint *esv1alpha1.EndpointSpec) int32 {
+	if endpoint.Strategy == StrategyProgressive {
+		if status := findEndpointStatus(policy, endpoint.ID); status != nil && status.Progressive != nil {
+			return status.Progressive.Weight
+		}
+		if endpoint.Progressive != nil && len(endpoint.Progressive.Steps) > 0 {
+			return endpoint.Progressive.Steps[0].Weight
+		}
+		return 0
+	}
+
+	weight := esv1alpha1.DefaultCanaryWeight
+	if endpoint.CanaryWeight != nil {
+		weight = *endpoint.CanaryWeight
+	}
+	return weight
+}
+
+// backendNamespace is set on backendRefs when the endpoint Services live
+// outside the route namespace; a ReferenceGrant permits the reference.
+func backendNamespace(policy *esv1alpha1.EndpointPolicy) *gatewayv1.Namespace {
+	if !crossNamespace(policy) {
+		return nil
+	}
+	ns := gatewayv1.Namespace(appNamespace(policy))
+	return &ns
+}
+
 func (r *EndpointPolicyReconciler) reconcileGRPCRoute(
 	ctx context.Context,
 	policy *esv1alpha1.EndpointPolicy,
@@ -238,20 +407,8 @@ func (r *EndpointPolicyReconciler) reconcileGRPCRoute(
 		return "", err
 	}
 
-	existing := &gatewayv1.GRPCRoute{}
-	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: policy.Namespace}, existing)
-	if err != nil {
-		if client.IgnoreNotFound(err) != nil {
-			return "", err
-		}
-		logger.Info("Creating GRPCRoute", "name", name)
-		return name, r.Create(ctx, desired)
-	}
-
-	existing.Spec = desired.Spec
-	existing.Labels = desired.Labels
-	logger.Info("Updating GRPCRoute", "name", name)
-	return name, r.Update(ctx, existing)
+	logger.Info("Applying GRPCRoute", "name", name)
+	return name, r.apply(ctx, desired)
 }
 
 func (r *EndpointPolicyReconciler) buildGRPCRoute(
@@ -259,7 +416,6 @@ func (r *EndpointPolicyReconciler) buildGRPCRoute(
 	endpoint *esv1alpha1.EndpointSpec,
 ) *gatewayv1.GRPCRoute {
 	name := endpointResourceName(policy, endpoint)
-	labels := generateLabels(policy, endpoint)
 
 	gatewayKind := gatewayv1.Kind("Gateway")
 	parentRef := gatewayv1.ParentReference{
@@ -282,23 +438,42 @@ func (r *EndpointPolicyReconciler) buildGRPCRoute(
 	}
 
 	backendRefs := r.buildGRPCBackendRefs(policy, endpoint)
+	matches := []gatewayv1.GRPCRouteMatch{{Method: &grpcService}}
+	filters := buildGRPCFilters(policy, endpoint)
+
+	rules := []gatewayv1.GRPCRouteRule{{
+		Matches:     matches,
+		Filters:     filters,
+		BackendRefs: backendRefs,
+	}}
+	// Calls carrying the A/B test metadata reach the endpoint, the rest the
+	// main service
+	if endpoint.Strategy == StrategyABTest {
+		rules = []gatewayv1.GRPCRouteRule{
+			{
+				Matches:     abTestGRPCMatches(&grpcService, endpoint.ABTest),
+				Filters:     filters,
+				BackendRefs: backendRefs,
+			},
+			{
+				Matches:     matches,
+				Filters:     filters,
+				BackendRefs: mainGRPCBackendRefs(policy),
+			},
+		}
+	}
 
 	route := &gatewayv1.GRPCRoute{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
 			Namespace: policy.Namespace,
-			Labels:    labels,
+			Labels:    objectLabels(policy, endpoint),
 		},
 		Spec: gatewayv1.GRPCRouteSpec{
 			CommonRouteSpec: gatewayv1.CommonRouteSpec{
 				ParentRefs: []gatewayv1.ParentReference{parentRef},
 			},
-			Rules: []gatewayv1.GRPCRouteRule{{
-				Matches: []gatewayv1.GRPCRouteMatch{{
-					Method: &grpcService,
-				}},
-				BackendRefs: backendRefs,
-			}},
+			Rules: rules,
 		},
 	}
 
@@ -311,38 +486,86 @@ func (r *EndpointPolicyReconciler) buildGRPCRoute(
 	return route
 }
 
+// grpcBackendPort is the port gRPC routes use for the main and endpoint
+// Services.
+func grpcBackendPort(policy *esv1alpha1.EndpointPolicy) gatewayv1.PortNumber {
+	if policy.Spec.AppRef.Port == 0 {
+		return gatewayv1.PortNumber(esv1alpha1.DefaultGRPCPort)
+	}
+	return gatewayv1.PortNumber(policy.Spec.AppRef.Port)
+}
+
+// abTestGRPCMatches returns the method match combined with each A/B test
+// metadata header in turn.
+func abTestGRPCMatches(method *gatewayv1.GRPCMethodMatch, spec *esv1alpha1.ABTestSpec) []gatewayv1.GRPCRouteMatch {
+	matches := make([]gatewayv1.GRPCRouteMatch, 0, len(spec.Headers))
+	for _, h := range spec.Headers {
+		headerType := gatewayv1.GRPCHeaderMatchExact
+		if h.Type != "" {
+			headerType = gatewayv1.GRPCHeaderMatchType(h.Type)
+		}
+		matches = append(matches, gatewayv1.GRPCRouteMatch{
+			Method: method.DeepCopy(),
+			Headers: []gatewayv1.GRPCHeaderMatch{{
+				Type:  &headerType,
+				Name:  gatewayv1.GRPCHeaderName(h.Name),
+				Value: h.Value,
+			}},
+		})
+	}
+	return matches
+}
+
+// mainGRPCBackendRefs sends all traffic to the main service.
+func mainGRPCBackendRefs(policy *esv1alpha1.EndpointPolicy) []gatewayv1.GRPCBackendRef {
+	kind := gatewayv1.Kind("Service")
+	port := grpcBackendPort(policy)
+	weight := int32(100)
+	return []gatewayv1.GRPCBackendRef{{
+		BackendRef: gatewayv1.BackendRef{
+			BackendObjectReference: gatewayv1.BackendObjectReference{
+				Kind:      &kind,
+				Namespace: backendNamespace(policy),
+				Name:      gatewayv1.ObjectName(mainServiceName(policy)),
+				Port:      &port,
+			},
+			Weight: &weight,
+		},
+	}}
+}
+
 func (r *EndpointPolicyReconciler) buildGRPCBackendRefs(
 	policy *esv1alpha1.EndpointPolicy,
 	endpoint *esv1alpha1.EndpointSpec,
 ) []gatewayv1.GRPCBackendRef {
 	mainSvc := mainServiceName(policy)
-	endpointSvc := endpointServiceName(policy, endpoint)
-	servicePort := gatewayv1.PortNumber(policy.Spec.AppRef.Port)
-	if servicePort == 0 {
-		servicePort = 9090
-	}
+	endpointSvc := endpointBackendName(policy, endpoint)
+	servicePort := grpcBackendPort(policy)
 
 	kind := gatewayv1.Kind("Service")
+	backendNS := backendNamespace(policy)
 	strategy := endpoint.Strategy
 	if strategy == "" {
 		strategy = StrategyPrimary
 	}
 
+	if mainServiceOnly(policy, endpoint) {
+		return mainGRPCBackendRefs(policy)
+	}
+
 	switch strategy {
-	case StrategyCanary:
-		canaryWeight := int32(5)
-		if endpoint.CanaryWeight != nil {
-			canaryWeight = *endpoint.CanaryWeight
-		}
+	case StrategyCanary, StrategyProgressive:
+		canaryWeight := endpointWeight(policy, endpoint)
 		mainWeight := int32(100 - canaryWeight)
 
 		return []gatewayv1.GRPCBackendRef{
 			{
 				BackendRef: gatewayv1.BackendRef{
 					BackendObjectReference: gatewayv1.BackendObjectReference{
-						Kind: &kind,
-						Name: gatewayv1.ObjectName(mainSvc),
-						Port: &servicePort,
+						Kind:      &kind,
+						Namespace: backendNS,
+						Name:      gatewayv1.ObjectName(mainSvc),
+						Port:      &servicePort,
 					},
 					Weight: &mainWeight,
 				},
@@ -350,23 +573,25 @@ func (r *EndpointPolicyReconciler) buildGRPCBackendRefs(
 			{
 				BackendRef: gatewayv1.BackendRef{
 					BackendObjectReference: gatewayv1.BackendObjectReference{
-						Kind: &kind,
-						Name: gatewayv1.ObjectName(endpointSvc),
-						Port: &servicePort,
+						Kind:      &kind,
+						Namespace: backendNS,
+						Name:      gatewayv1.ObjectName(endpointSvc),
+						Port:      &servicePort,
 					},
 					Weight: &canaryWeight,
 				},
 			},
 		}
 
-	case StrategyPrimary:
+	case StrategyPrimary, StrategyABTest:
 		weight := int32(100)
 		return []gatewayv1.GRPCBackendRef{{
 			BackendRef: gatewayv1.BackendRef{
 				BackendObjectReference: gatewayv1.BackendObjectReference{
-					Kind: &kind,
-					Name: gatewayv1.ObjectName(endpointSvc),
-					Port: &servicePort,
+					Kind:      &kind,
+					Namespace: backendNS,
+					Name:      gatewayv1.ObjectName(endpointSvc),
+					Port:      &servicePort,
 				},
 				Weight: &weight,
 			},
@@ -377,9 +602,10 @@ func (r *EndpointPolicyReconciler) buildGRPCBackendRefs(
 		return []gatewayv1.GRPCBackendRef{{
 			BackendRef: gatewayv1.BackendRef{
 				BackendObjectReference: gatewayv1.BackendObjectReference{
-					Kind: &kind,
-					Name: gatewayv1.ObjectName(endpointSvc),
-					Port: &servicePort,
+					Kind:      &kind,
+					Namespace: backendNS,
+					Name:      gatewayv1.ObjectName(endpointSvc),
+					Port:      &servicePort,
 				},
 				Weight: &weight,
 			},
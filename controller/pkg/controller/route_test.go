@@ -332,6 +332,89 @@ func TestBuildHTTPRoute_Primary(t *testing.T) {
 	}
 }
 
+func TestBuildHTTPRoute_Matches(t *testing.T) {
+	r := &EndpointPolicyReconciler{}
+	policy := testEndpointPolicy()
+	endpoint := &esv1alpha1.EndpointSpec{
+		ID:       "orders",
+		Type:     "http",
+		Strategy: "primary",
+		Matches: []esv1alpha1.MatchSpec{
+			{
+				Path:     "/api/v1/orders",
+				PathType: "Exact",
+				Method:   "POST",
+				Headers: []esv1alpha1.HeaderMatch{
+					{Name: "X-Tenant", Value: "acme"},
+				},
+			},
+			{
+				Path:     "^/api/v1/orders/[0-9]+$",
+				PathType: "RegularExpression",
+				QueryParams: []esv1alpha1.QueryParamMatch{
+					{Type: "RegularExpression", Name: "version", Value: "^v2"},
+				},
+			},
+		},
+	}
+
+	route := r.buildHTTPRoute(policy, endpoint)
+
+	if len(route.Spec.Rules) != 1 {
+		t.Fatalf("expected 1 rule, got %d", len(route.Spec.Rules))
+	}
+	matches := route.Spec.Rules[0].Matches
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 matches, got %d", len(matches))
+	}
+
+	first := matches[0]
+	if *first.Path.Type != gatewayv1.PathMatchExact || *first.Path.Value != "/api/v1/orders" {
+		t.Errorf("unexpected path match %v %q", *first.Path.Type, *first.Path.Value)
+	}
+	if first.Method == nil || *first.Method != gatewayv1.HTTPMethodPost {
+		t.Errorf("expected method POST, got %v", first.Method)
+	}
+	if len(first.Headers) != 1 {
+		t.Fatalf("expected 1 header match, got %d", len(first.Headers))
+	}
+	if *first.Headers[0].Type != gatewayv1.HeaderMatchExact || first.Headers[0].Name != "X-Tenant" || first.Headers[0].Value != "acme" {
+		t.Errorf("unexpected header match %+v", first.Headers[0])
+	}
+	if first.QueryParams != nil {
+		t.Errorf("expected no query param matches, got %+v", first.QueryParams)
+	}
+
+	second := matches[1]
+	if *second.Path.Type != gatewayv1.PathMatchRegularExpression {
+		t.Errorf("expected RegularExpression path match, got %v", *second.Path.Type)
+	}
+	if second.Method != nil {
+		t.Errorf("expected no method, got %v", *second.Method)
+	}
+	if len(second.QueryParams) != 1 || *second.QueryParams[0].Type != gatewayv1.QueryParamMatchRegularExpression {
+		t.Errorf("unexpected query param matches %+v", second.QueryParams)
+	}
+}
+
+func TestBuildHTTPRoute_DefaultPathPrefix(t *testing.T) {
+	r := &EndpointPolicyReconciler{}
+	policy := testEndpointPolicy()
+
+	route := r.buildHTTPRoute(policy, &policy.Spec.Endpoints[0])
+
+	matches := route.Spec.Rules[0].Matches
+	if len(matches) != 1 {
+		t.Fatalf("expected 1 match, got %d", len(matches))
+	}
+	if *matches[0].Path.Type != gatewayv1.PathMatchPathPrefix || *matches[0].Path.Value != "/api/lookup" {
+		t.Errorf("expected PathPrefix /api/lookup, got %v %q", *matches[0].Path.Type, *matches[0].Path.Value)
+	}
+	if matches[0].Method != nil || matches[0].Headers != nil {
+		t.Errorf("expected path-only match, got %+v", matches[0])
+	}
+}
+
 func TestEndpointResourceName(t *testing.T) {
 	policy := testEndpointPolicy()
 	endpoint := &policy.Spec.Endpoints[0]
@@ -366,3 +449,134 @@ func TestMainServiceName(t *testing.T) {
 		t.Errorf("expected %q, got %q", expected, name)
 	}
 }
+
+func TestBuildBackendRefs_ScaledToZero(t *testing.T) {
+	r := &EndpointPolicyReconciler{}
+
+	policy := testEndpointPolicy()
+	policy.Status.EndpointStatuses = []esv1alpha1.EndpointStatus{{ID: "lookup", ScaledToZero: true}}
+	httpRefs := r.buildHTTPBackendRefs(policy, &policy.Spec.Endpoints[0])
+	if len(httpRefs) != 1 || httpRefs[0].Name != "my-app-svc" || *httpRefs[0].Weight != 100 {
+		t.Errorf("expected canary to fall back to the main service only, got %+v", httpRefs)
+	}
+
+	grpcPolicy := testGRPCEndpointPolicy()
+	grpcEndpoint := &grpcPolicy.Spec.Endpoints[0]
+	grpcPolicy.Status.EndpointStatuses = []esv1alpha1.EndpointStatus{{ID: grpcEndpoint.ID, ScaledToZero: true}}
+	grpcRefs := r.buildGRPCBackendRefs(grpcPolicy, grpcEndpoint)
+	if len(grpcRefs) != 1 || string(grpcRefs[0].Name) != mainServiceName(grpcPolicy) || *grpcRefs[0].Weight != 100 {
+		t.Errorf("expected gRPC route to fall back to the main service, got %+v", grpcRefs)
+	}
+}
+
+func TestBuildGRPCRoute_DefaultPort(t *testing.T) {
+	r := &EndpointPolicyReconciler{}
+	policy := testGRPCEndpointPolicy()
+	policy.Spec.AppRef.Port = 0
+	endpoint := &policy.Spec.Endpoints[0]
+
+	for _, ref := range r.buildGRPCRoute(policy, endpoint).Spec.Rules[0].BackendRefs {
+		if ref.Port == nil || *ref.Port != 9090 {
+			t.Errorf("expected gRPC backends on port 9090 without appRef.port, got %s:%v", ref.Name, ref.Port)
+		}
+	}
+
+	endpoint.Strategy = StrategyShadow
+	rule := r.buildGRPCRoute(policy, endpoint).Spec.Rules[0]
+	if port := rule.BackendRefs[0].Port; port == nil || *port != 9090 {
+		t.Errorf("expected the main service on port 9090, got %v", port)
+	}
+	if port := rule.Filters[0].RequestMirror.BackendRef.Port; port == nil || *port != 9090 {
+		t.Errorf("expected the mirror on port 9090, got %v", port)
+	}
+
+	// HTTP endpoints keep the Service default
+	httpPolicy := testEndpointPolicy()
+	httpPolicy.Spec.AppRef.Port = 0
+	ref := r.buildHTTPRoute(httpPolicy, &httpPolicy.Spec.Endpoints[0]).Spec.Rules[0].BackendRefs[0]
+	if *ref.Port != 80 {
+		t.Errorf("expected HTTP backends on port 80, got %d", *ref.Port)
+	}
+}
+
+func TestBuildHTTPRoute_ABTest(t *testing.T) {
+	r := &EndpointPolicyReconciler{}
+	policy := testEndpointPolicy()
+	endpoint := &policy.Spec.Endpoints[0]
+	endpoint.Strategy = StrategyABTest
+	endpoint.Matches = []esv1alpha1.MatchSpec{
+		{Path: "/api/lookup", Headers: []esv1alpha1.HeaderMatch{{Name: "X-Tenant", Value: "acme"}}},
+		{Path: "/api/search"},
+	}
+	endpoint.ABTest = &esv1alpha1.ABTestSpec{
+		Headers: []esv1alpha1.HeaderMatch{{Name: "X-Beta", Value: "true"}},
+		Cookies: []esv1alpha1.CookieMatch{{Name: "beta", Value: "1"}},
+	}
+
+	route := r.buildHTTPRoute(policy, endpoint)
+
+	if len(route.Spec.Rules) != 2 {
+		t.Fatalf("expected an A/B rule and a main rule, got %d rules", len(route.Spec.Rules))
+	}
+	ab, main := route.Spec.Rules[0], route.Spec.Rules[1]
+
+	if len(ab.BackendRefs) != 1 || ab.BackendRefs[0].Name != "my-app-lookup-svc" || *ab.BackendRefs[0].Weight != 100 {
+		t.Errorf("expected A/B requests on the endpoint service, got %+v", ab.BackendRefs)
+	}
+	if len(main.BackendRefs) != 1 || main.BackendRefs[0].Name != "my-app-svc" {
+		t.Errorf("expected the remaining requests on the main service, got %+v", main.BackendRefs)
+	}
+	if len(main.Matches) != 2 || len(main.Matches[0].Headers) != 1 || main.Matches[1].Headers != nil {
+		t.Errorf("expected the plain matches on the main rule, got %+v", main.Matches)
+	}
+
+	if len(ab.Matches) != 4 {
+		t.Fatalf("expected each match combined with each condition, got %d matches", len(ab.Matches))
+	}
+	header := ab.Matches[0]
+	if *header.Path.Value != "/api/lookup" || len(header.Headers) != 2 {
+		t.Fatalf("expected the X-Tenant match plus the A/B header, got %+v", header)
+	}
+	if h := header.Headers[1]; *h.Type != gatewayv1.HeaderMatchExact || h.Name != "X-Beta" || h.Value != "true" {
+		t.Errorf("unexpected A/B header match %+v", h)
+	}
+	cookie := ab.Matches[3]
+	if *cookie.Path.Value != "/api/search" || len(cookie.Headers) != 1 {
+		t.Fatalf("expected the search match plus the cookie, got %+v", cookie)
+	}
+	if h := cookie.Headers[0]; *h.Type != gatewayv1.HeaderMatchRegularExpression || h.Name != "Cookie" || h.Value != `(^|;\s*)beta=1(;|$)` {
+		t.Errorf("unexpected cookie match %+v", h)
+	}
+}
+
+func TestBuildGRPCRoute_ABTest(t *testing.T) {
+	r := &EndpointPolicyReconciler{}
+	policy := testGRPCEndpointPolicy()
+	endpoint := &policy.Spec.Endpoints[0]
+	endpoint.Strategy = StrategyABTest
+	endpoint.ABTest = &esv1alpha1.ABTestSpec{
+		Headers: []esv1alpha1.HeaderMatch{{Type: "RegularExpression", Name: "x-cohort", Value: "^beta-"}},
+	}
+
+	route := r.buildGRPCRoute(policy, endpoint)
+
+	if len(route.Spec.Rules) != 2 {
+		t.Fatalf("expected an A/B rule and a main rule, got %d rules", len(route.Spec.Rules))
+	}
+	ab, main := route.Spec.Rules[0], route.Spec.Rules[1]
+	if len(ab.Matches) != 1 || len(ab.Matches[0].Headers) != 1 {
+		t.Fatalf("expected one metadata match, got %+v", ab.Matches)
+	}
+	if h := ab.Matches[0].Headers[0]; *h.Type != gatewayv1.GRPCHeaderMatchRegularExpression || h.Name != "x-cohort" || h.Value != "^beta-" {
+		t.Errorf("unexpected metadata match %+v", h)
+	}
+	if *ab.Matches[0].Method.Service != *main.Matches[0].Method.Service {
+		t.Errorf("expected both rules to match the same service")
+	}
+	if string(ab.BackendRefs[0].Name) != endpointServiceName(policy, endpoint) {
+		t.Errorf("expected A/B calls on the endpoint service, got %s", ab.BackendRefs[0].Name)
+	}
+	if len(main.BackendRefs) != 1 || string(main.BackendRefs[0].Name) != mainServiceName(policy) {
+		t.Errorf("expected the remaining calls on the main service, got %+v", main.BackendRefs)
+	}
+}
@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+)
+
+// EndpointConditionScaledToZero is True while the endpoint has no ready
+// replicas and its route sends all traffic to the main service.
+const EndpointConditionScaledToZero = "ScaledToZero"
+
+// Reasons of the ScaledToZero condition, also used as event reasons
+const (
+	ReasonNoReadyReplicas = "NoReadyReplicas"
+	ReasonReplicasReady   = "ReplicasReady"
+)
+
+// observeScaleToZero decides whether the endpoint route falls back to the
+// main service because the endpoint Deployment has no ready replicas, e.g.
+// after a KEDA scale-to-zero or a manual scale down. The decision is stored
+// on the policy status for the route built afterwards in the same reconcile,
+// and every transition is recorded as a condition and an event. Without a
+// main service there is nothing to fall back to and the route is unchanged.
+func (r *EndpointPolicyReconciler) observeScaleToZero(
+	ctx context.Context,
+	policy *esv1alpha1.EndpointPolicy,
+	endpoint *esv1alpha1.EndpointSpec,
+	status *esv1alpha1.EndpointStatus,
+) error {
+	deployment := &appsv1.Deployment{}
+	key := types.NamespacedName{Name: status.DeploymentName, Namespace: appNamespace(policy)}
+	if err := r.Get(ctx, key, deployment); err != nil {
+		return err
+	}
+
+	scaledToZero := deployment.Status.ReadyReplicas == 0
+	if scaledToZero {
+		if err := r.validateMainServiceExists(ctx, policy); apierrors.IsNotFound(err) {
+			scaledToZero = false
+		} else if err != nil {
+			return err
+		}
+	}
+
+	cond := metav1.Condition{
+		Type:               EndpointConditionScaledToZero,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: deployment.Generation,
+		LastTransitionTime: metav1.NewTime(r.now()),
+		Reason:             ReasonReplicasReady,
+		Message:            "traffic is routed to the endpoint",
+	}
+	if scaledToZero {
+		cond.Status = metav1.ConditionTrue
+		cond.Reason = ReasonNoReadyReplicas
+		cond.Message = "no ready replicas, traffic is routed to the main service"
+	}
+
+	// A new endpoint only reports the fallback, not the absence of one
+	prev := meta.FindStatusCondition(status.Conditions, EndpointConditionScaledToZero)
+	if (prev == nil && scaledToZero) || (prev != nil && prev.Status != cond.Status) {
+		log.FromContext(ctx).Info("Endpoint scale-to-zero fallback changed",
+			"endpoint", endpoint.ID, "scaledToZero", scaledToZero)
+		r.event(policy, corev1.EventTypeNormal, cond.Reason, "Endpoint %s: %s", endpoint.ID, cond.Message)
+	}
+	meta.SetStatusCondition(&status.Conditions, cond)
+
+	status.ScaledToZero = scaledToZero
+	setScaledToZero(policy, endpoint.ID, scaledToZero)
+	return nil
+}
+
+// setScaledToZero records the fallback decision on the policy so that the
+// route built afterwards in the same reconcile uses it.
+func setScaledToZero(policy *esv1alpha1.EndpointPolicy, id string, scaledToZero bool) {
+	if status := findEndpointStatus(policy, id); status != nil {
+		status.ScaledToZero = scaledToZero
+		return
+	}
+	policy.Status.EndpointStatuses = append(policy.Status.EndpointStatuses, esv1alpha1.EndpointStatus{
+		ID:           id,
+		ScaledToZero: scaledToZero,
+	})
+}
+
+// scaledToZero reports whether the endpoint route falls back to the main
+// service.
+func scaledToZero(policy *esv1alpha1.EndpointPolicy, endpoint *esv1alpha1.EndpointSpec) bool {
+	status := findEndpointStatus(policy, endpoint.ID)
+	return status != nil && status.ScaledToZero
+}
@@ -0,0 +1,167 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+)
+
+func scaleToZeroPolicy() *esv1alpha1.EndpointPolicy {
+	return &esv1alpha1.EndpointPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-policy",
+			Namespace: "default",
+			UID:       "policy-uid",
+		},
+		Spec: esv1alpha1.EndpointPolicySpec{
+			AppRef: esv1alpha1.AppReference{
+				Name:  "my-app",
+				Image: "my-app:v1",
+			},
+			GatewayRef: esv1alpha1.GatewayReference{
+				Name: "my-gateway",
+			},
+			Endpoints: []esv1alpha1.EndpointSpec{
+				{
+					ID:       "reports",
+					Type:     "http",
+					Match:    esv1alpha1.MatchSpec{Path: "/api/reports"},
+					Strategy: StrategyPrimary,
+				},
+			},
+		},
+	}
+}
+
+func mainService() *corev1.Service {
+	return &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "my-app-svc", Namespace: "default"}}
+}
+
+// routeBackends returns the backend names of the endpoint's HTTPRoute.
+func routeBackends(t *testing.T, r *EndpointPolicyReconciler) []string {
+	t.Helper()
+	route := &gatewayv1.HTTPRoute{}
+	if err := r.Get(context.Background(), types.NamespacedName{Name: "my-app-reports", Namespace: "default"}, route); err != nil {
+		t.Fatalf("expected route: %v", err)
+	}
+	var names []string
+	for _, ref := range route.Spec.Rules[0].BackendRefs {
+		names = append(names, string(ref.Name))
+	}
+	return names
+}
+
+func drainEvents(recorder *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case e := <-recorder.Events:
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func TestReconcile_ScaleToZeroFallback(t *testing.T) {
+	policy := scaleToZeroPolicy()
+	r := newFakeReconciler(t, policy, mainService())
+	recorder := record.NewFakeRecorder(10)
+	r.Recorder = recorder
+	ctx := context.Background()
+	req := reconcileRequest(policy)
+
+	// The new Deployment has no ready replicas yet
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if backends := routeBackends(t, r); len(backends) != 1 || backends[0] != "my-app-svc" {
+		t.Errorf("expected route to fall back to the main service, got %v", backends)
+	}
+	latest := &esv1alpha1.EndpointPolicy{}
+	if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+		t.Fatal(err)
+	}
+	status := latest.Status.EndpointStatuses[0]
+	if !status.ScaledToZero {
+		t.Error("expected status to report scaledToZero")
+	}
+	if !meta.IsStatusConditionTrue(status.Conditions, EndpointConditionScaledToZero) {
+		t.Errorf("expected ScaledToZero condition True, got %v", status.Conditions)
+	}
+	events := drainEvents(recorder)
+	if len(events) != 1 || !strings.Contains(events[0], ReasonNoReadyReplicas) {
+		t.Errorf("expected one %s event, got %v", ReasonNoReadyReplicas, events)
+	}
+
+	// A second pass without a change records nothing new
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if events := drainEvents(recorder); len(events) != 0 {
+		t.Errorf("expected no events without a transition, got %v", events)
+	}
+
+	// Replicas become ready and the configured route is restored
+	dep := &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "my-app-reports", Namespace: "default"}, dep); err != nil {
+		t.Fatal(err)
+	}
+	markAvailable(dep, 2)
+	if err := r.Status().Update(ctx, dep); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if backends := routeBackends(t, r); len(backends) != 1 || backends[0] != "my-app-reports-svc" {
+		t.Errorf("expected route to the endpoint service, got %v", backends)
+	}
+	if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+		t.Fatal(err)
+	}
+	status = latest.Status.EndpointStatuses[0]
+	if status.ScaledToZero {
+		t.Error("expected scaledToZero to be cleared")
+	}
+	cond := meta.FindStatusCondition(status.Conditions, EndpointConditionScaledToZero)
+	if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != ReasonReplicasReady {
+		t.Errorf("expected ScaledToZero condition False/%s, got %v", ReasonReplicasReady, cond)
+	}
+	events = drainEvents(recorder)
+	if len(events) != 1 || !strings.Contains(events[0], ReasonReplicasReady) {
+		t.Errorf("expected one %s event, got %v", ReasonReplicasReady, events)
+	}
+}
+
+func TestReconcile_ScaleToZeroWithoutMainService(t *testing.T) {
+	policy := scaleToZeroPolicy()
+	r := newFakeReconciler(t, policy)
+	recorder := record.NewFakeRecorder(10)
+	r.Recorder = recorder
+	ctx := context.Background()
+
+	if _, err := r.Reconcile(ctx, reconcileRequest(policy)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// There is nothing to fall back to, so the configured route stays
+	if backends := routeBackends(t, r); len(backends) != 1 || backends[0] != "my-app-reports-svc" {
+		t.Errorf("expected route to the endpoint service, got %v", backends)
+	}
+	if events := drainEvents(recorder); len(events) != 0 {
+		t.Errorf("expected no events, got %v", events)
+	}
+}
@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+	"github.com/example/endpoint-scaler/controller/pkg/cron"
+)
+
+// Event reasons for schedule window transitions
+const (
+	ReasonScheduleActivated   = "ScheduleActivated"
+	ReasonScheduleDeactivated = "ScheduleDeactivated"
+)
+
+// applySchedule returns a copy of endpoint with the overrides of its active
+// schedule window applied, the name of that window ("" when none is active)
+// and how long until any window next opens or closes, where zero means never.
+// A window is active when its end fires before its start does again, so
+// windows need no persisted state and a restarted controller picks up the
+// window it is in.
+func (r *EndpointPolicyReconciler) applySchedule(
+	endpoint *esv1alpha1.EndpointSpec,
+) (*esv1alpha1.EndpointSpec, string, time.Duration, error) {
+	if len(endpoint.Schedule) == 0 {
+		return endpoint, "", 0, nil
+	}
+
+	now := r.now()
+	var active *esv1alpha1.ScheduleWindow
+	var requeue time.Duration
+	for i := range endpoint.Schedule {
+		w := &endpoint.Schedule[i]
+		nextStart, nextEnd, err := windowBoundaries(w, now)
+		if err != nil {
+			return nil, "", 0, fmt.Errorf("schedule window %q: %w", w.Name, err)
+		}
+		for _, next := range []time.Time{nextStart, nextEnd} {
+			if !next.IsZero() {
+				requeue = shortestRequeue(requeue, next.Sub(now))
+			}
+		}
+		if active == nil && !nextEnd.IsZero() && (nextStart.IsZero() || nextEnd.Before(nextStart)) {
+			active = w
+		}
+	}
+	if active == nil {
+		return endpoint, "", requeue, nil
+	}
+
+	scheduled := endpoint.DeepCopy()
+	switch {
+	case scheduled.UsesKEDA():
+		if scheduled.Autoscaler.Keda == nil {
+			scheduled.Autoscaler.Keda = &esv1alpha1.KEDASpec{}
+		}
+		if active.Min != nil {
+			scheduled.Autoscaler.Keda.MinReplicaCount = active.Min
+		}
+		if active.Max != nil {
+			scheduled.Autoscaler.Keda.MaxReplicaCount = *active.Max
+		}
+	case scheduled.HPA != nil:
+		if active.Min != nil {
+			scheduled.HPA.Min = *active.Min
+		}
+		if active.Max != nil {
+			scheduled.HPA.Max = *active.Max
+		}
+	default:
+		if active.Replicas != nil {
+			scheduled.Replicas = active.Replicas
+		}
+	}
+	return scheduled, active.Name, requeue, nil
+}
+
+// windowBoundaries returns when w next opens and closes after now.
+func windowBoundaries(w *esv1alpha1.ScheduleWindow, now time.Time) (time.Time, time.Time, error) {
+	loc := time.UTC
+	if w.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(w.Timezone); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("timezone %q: %w", w.Timezone, err)
+		}
+	}
+	start, err := cron.Parse(w.Start)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("start: %w", err)
+	}
+	end, err := cron.Parse(w.End)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("end: %w", err)
+	}
+	local := now.In(loc)
+	return start.Next(local), end.Next(local), nil
+}
+
+// observeSchedule records the active window in status and emits an event
+// when it changes.
+func (r *EndpointPolicyReconciler) observeSchedule(
+	policy *esv1alpha1.EndpointPolicy,
+	endpoint *esv1alpha1.EndpointSpec,
+	status *esv1alpha1.EndpointStatus,
+	window string,
+) {
+	previous := ""
+	if prev := findEndpointStatus(policy, endpoint.ID); prev != nil {
+		previous = prev.ActiveSchedule
+	}
+	status.ActiveSchedule = window
+	if window == previous {
+		return
+	}
+	if window != "" {
+		r.event(policy, corev1.EventTypeNormal, ReasonScheduleActivated,
+			"Endpoint %q entered schedule window %q", endpoint.ID, window)
+	} else {
+		r.event(policy, corev1.EventTypeNormal, ReasonScheduleDeactivated,
+			"Endpoint %q left schedule window %q", endpoint.ID, previous)
+	}
+}
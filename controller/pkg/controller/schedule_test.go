@@ -0,0 +1,219 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+)
+
+func businessHours() esv1alpha1.ScheduleWindow {
+	return esv1alpha1.ScheduleWindow{
+		Name:     "business-hours",
+		Start:    "0 8 * * 1-5",
+		End:      "0 18 * * 1-5",
+		Timezone: "Europe/Berlin",
+		Min:      ptr.To(int32(3)),
+		Max:      ptr.To(int32(10)),
+	}
+}
+
+func TestApplySchedule_HPABounds(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoint := &esv1alpha1.EndpointSpec{
+		ID:       "reports",
+		HPA:      &esv1alpha1.HPASpec{Min: 1, Max: 3},
+		Schedule: []esv1alpha1.ScheduleWindow{businessHours()},
+	}
+
+	tests := []struct {
+		name       string
+		now        time.Time
+		wantWindow string
+		wantMin    int32
+		wantMax    int32
+		wantNext   time.Duration
+	}{
+		{
+			name:       "inside window",
+			now:        time.Date(2026, 1, 5, 9, 0, 0, 0, berlin), // Monday
+			wantWindow: "business-hours",
+			wantMin:    3,
+			wantMax:    10,
+			wantNext:   9 * time.Hour,
+		},
+		{
+			name:     "after window",
+			now:      time.Date(2026, 1, 5, 19, 0, 0, 0, berlin),
+			wantMin:  1,
+			wantMax:  3,
+			wantNext: 13 * time.Hour,
+		},
+		{
+			name:     "weekend",
+			now:      time.Date(2026, 1, 10, 12, 0, 0, 0, berlin), // Saturday
+			wantMin:  1,
+			wantMax:  3,
+			wantNext: 44 * time.Hour,
+		},
+		{
+			name:       "evaluated in the window's time zone",
+			now:        time.Date(2026, 1, 5, 7, 30, 0, 0, time.UTC), // 08:30 in Berlin
+			wantWindow: "business-hours",
+			wantMin:    3,
+			wantMax:    10,
+			wantNext:   9*time.Hour + 30*time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &EndpointPolicyReconciler{Clock: clocktesting.NewFakePassiveClock(tt.now)}
+			scheduled, window, requeue, err := r.applySchedule(endpoint)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if window != tt.wantWindow {
+				t.Errorf("expected window %q, got %q", tt.wantWindow, window)
+			}
+			if scheduled.HPA.Min != tt.wantMin || scheduled.HPA.Max != tt.wantMax {
+				t.Errorf("expected HPA %d-%d, got %d-%d", tt.wantMin, tt.wantMax, scheduled.HPA.Min, scheduled.HPA.Max)
+			}
+			if requeue != tt.wantNext {
+				t.Errorf("expected requeue after %v, got %v", tt.wantNext, requeue)
+			}
+		})
+	}
+
+	if endpoint.HPA.Min != 1 || endpoint.HPA.Max != 3 {
+		t.Errorf("expected the spec to be left unchanged, got HPA %d-%d", endpoint.HPA.Min, endpoint.HPA.Max)
+	}
+}
+
+func TestApplySchedule_FirstActiveWindowWins(t *testing.T) {
+	endpoint := &esv1alpha1.EndpointSpec{
+		ID:       "reports",
+		Replicas: ptr.To(int32(2)),
+		Schedule: []esv1alpha1.ScheduleWindow{
+			{Name: "nightly-batch", Start: "0 1 * * *", End: "0 3 * * *", Replicas: ptr.To(int32(6))},
+			{Name: "night", Start: "0 22 * * *", End: "0 6 * * *", Replicas: ptr.To(int32(0))},
+		},
+	}
+
+	tests := []struct {
+		hour         int
+		wantWindow   string
+		wantReplicas int32
+	}{
+		{hour: 12, wantReplicas: 2},
+		{hour: 23, wantWindow: "night", wantReplicas: 0},
+		{hour: 2, wantWindow: "nightly-batch", wantReplicas: 6},
+		{hour: 4, wantWindow: "night", wantReplicas: 0},
+	}
+
+	for _, tt := range tests {
+		r := &EndpointPolicyReconciler{Clock: clocktesting.NewFakePassiveClock(time.Date(2026, 1, 5, tt.hour, 0, 0, 0, time.UTC))}
+		scheduled, window, _, err := r.applySchedule(endpoint)
+		if err != nil {
+			t.Fatalf("%02d:00: unexpected error: %v", tt.hour, err)
+		}
+		if window != tt.wantWindow || *scheduled.Replicas != tt.wantReplicas {
+			t.Errorf("%02d:00: expected window %q with %d replicas, got %q with %d",
+				tt.hour, tt.wantWindow, tt.wantReplicas, window, *scheduled.Replicas)
+		}
+	}
+}
+
+func TestApplySchedule_KEDABounds(t *testing.T) {
+	policy := kedaPolicy()
+	endpoint := &policy.Spec.Endpoints[0]
+	endpoint.Schedule = []esv1alpha1.ScheduleWindow{businessHours()}
+
+	r := &EndpointPolicyReconciler{Clock: clocktesting.NewFakePassiveClock(time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC))}
+	scheduled, _, _, err := r.applySchedule(endpoint)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	keda := scheduled.Autoscaler.Keda
+	if keda.MinReplicaCount == nil || *keda.MinReplicaCount != 3 || keda.MaxReplicaCount != 10 {
+		t.Errorf("expected KEDA 3-10, got %v-%d", keda.MinReplicaCount, keda.MaxReplicaCount)
+	}
+	if endpoint.Autoscaler.Keda.MinReplicaCount != nil {
+		t.Error("expected the spec to be left unchanged")
+	}
+}
+
+func TestReconcile_ScheduleWindow(t *testing.T) {
+	policy := scaleToZeroPolicy()
+	policy.Spec.Endpoints[0].Replicas = ptr.To(int32(1))
+	policy.Spec.Endpoints[0].Schedule = []esv1alpha1.ScheduleWindow{{
+		Name:     "month-end",
+		Start:    "0 0 28 * *",
+		End:      "0 0 1 * *",
+		Replicas: ptr.To(int32(4)),
+	}}
+	clock := clocktesting.NewFakeClock(time.Date(2026, 1, 30, 12, 0, 0, 0, time.UTC))
+	r := newFakeReconciler(t, policy)
+	r.Clock = clock
+	recorder := record.NewFakeRecorder(10)
+	r.Recorder = recorder
+	ctx := context.Background()
+
+	replicas := func() int32 {
+		t.Helper()
+		dep := &appsv1.Deployment{}
+		if err := r.Get(ctx, types.NamespacedName{Name: "my-app-reports", Namespace: "default"}, dep); err != nil {
+			t.Fatalf("expected deployment: %v", err)
+		}
+		return *dep.Spec.Replicas
+	}
+	activeSchedule := func() string {
+		t.Helper()
+		updated := &esv1alpha1.EndpointPolicy{}
+		if err := r.Get(ctx, types.NamespacedName{Name: "test-policy", Namespace: "default"}, updated); err != nil {
+			t.Fatal(err)
+		}
+		return updated.Status.EndpointStatuses[0].ActiveSchedule
+	}
+
+	result, err := r.Reconcile(ctx, reconcileRequest(policy))
+	if err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if got := replicas(); got != 4 {
+		t.Errorf("expected 4 replicas inside the window, got %d", got)
+	}
+	if got := activeSchedule(); got != "month-end" {
+		t.Errorf("expected active schedule month-end, got %q", got)
+	}
+	if result.RequeueAfter != 36*time.Hour {
+		t.Errorf("expected requeue at the window end in 36h, got %v", result.RequeueAfter)
+	}
+
+	clock.Step(result.RequeueAfter)
+	if _, err := r.Reconcile(ctx, reconcileRequest(policy)); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if got := replicas(); got != 1 {
+		t.Errorf("expected 1 replica after the window, got %d", got)
+	}
+	if got := activeSchedule(); got != "" {
+		t.Errorf("expected no active schedule, got %q", got)
+	}
+
+	events := drainEvents(recorder)
+	if len(events) != 2 || !strings.Contains(events[0], ReasonScheduleActivated) || !strings.Contains(events[1], ReasonScheduleDeactivated) {
+		t.Errorf("expected activation and deactivation events, got %v", events)
+	}
+}
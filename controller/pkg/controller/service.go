@@ -5,10 +5,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
-	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
@@ -23,25 +20,12 @@ func (r *EndpointPolicyReconciler) reconcileService(
 	name := endpointServiceName(policy, endpoint)
 
 	desired := r.buildService(policy, endpoint)
-	if err := ctrl.SetControllerReference(policy, desired, r.Scheme); err != nil {
+	if err := r.setOwner(policy, desired); err != nil {
 		return "", err
 	}
 
-	existing := &corev1.Service{}
-	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: policy.Namespace}, existing)
-	if err != nil {
-		if client.IgnoreNotFound(err) != nil {
-			return "", err
-		}
-		logger.Info("Creating Service", "name", name)
-		return name, r.Create(ctx, desired)
-	}
-
-	existing.Spec.Ports = desired.Spec.Ports
-	existing.Spec.Selector = desired.Spec.Selector
-	existing.Labels = desired.Labels
-	logger.Info("Updating Service", "name", name)
-	return name, r.Update(ctx, existing)
+	logger.Info("Applying Service", "name", name)
+	return name, r.apply(ctx, desired)
 }
 
 func (r *EndpointPolicyReconciler) buildService(
@@ -53,19 +37,19 @@ func (r *EndpointPolicyReconciler) buildService(
 
 	servicePort := policy.Spec.AppRef.Port
 	if servicePort == 0 {
-		servicePort = 80
+		servicePort = esv1alpha1.DefaultPort
 	}
 
 	containerPort := policy.Spec.AppRef.ContainerPort
 	if containerPort == 0 {
-		containerPort = 8080
+		containerPort = esv1alpha1.DefaultContainerPort
 	}
 
 	return &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
-			Namespace: policy.Namespace,
-			Labels:    labels,
+			Namespace: appNamespace(policy),
+			Labels:    objectLabels(policy, endpoint),
 		},
 		Spec: corev1.ServiceSpec{
 			Type:     corev1.ServiceTypeClusterIP,
@@ -0,0 +1,204 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	"sigs.k8s.io/gateway-api/pkg/features"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+)
+
+// EndpointConditionRouteFeaturesSupported is False while the policy's
+// gateway reports that it does not support the timeouts or retries of the
+// endpoint route. It does not affect readiness.
+const EndpointConditionRouteFeaturesSupported = "RouteFeaturesSupported"
+
+// Reasons of the RouteFeaturesSupported condition, also used as event reasons
+const (
+	ReasonFeaturesSupported  = "FeaturesSupported"
+	ReasonUnsupportedFeature = "UnsupportedFeature"
+)
+
+// buildHTTPTimeouts converts the endpoint's timeouts into HTTPRoute rule
+// timeouts.
+func buildHTTPTimeouts(endpoint *esv1alpha1.EndpointSpec) *gatewayv1.HTTPRouteTimeouts {
+	t := endpoint.Timeouts
+	if t == nil {
+		return nil
+	}
+	timeouts := &gatewayv1.HTTPRouteTimeouts{}
+	if t.Request != nil {
+		timeouts.Request = gatewayDuration(t.Request.Duration)
+	}
+	if t.BackendRequest != nil {
+		timeouts.BackendRequest = gatewayDuration(t.BackendRequest.Duration)
+	}
+	return timeouts
+}
+
+// buildHTTPRetry converts the endpoint's retry policy into an HTTPRoute rule
+// retry.
+func buildHTTPRetry(endpoint *esv1alpha1.EndpointSpec) *gatewayv1.HTTPRouteRetry {
+	r := endpoint.Retry
+	if r == nil {
+		return nil
+	}
+	retry := &gatewayv1.HTTPRouteRetry{}
+	if r.Attempts != nil {
+		attempts := int(*r.Attempts)
+		retry.Attempts = &attempts
+	}
+	if r.Backoff != nil {
+		retry.Backoff = gatewayDuration(r.Backoff.Duration)
+	}
+	for _, code := range r.Codes {
+		retry.Codes = append(retry.Codes, gatewayv1.HTTPRouteRetryStatusCode(code))
+	}
+	return retry
+}
+
+// gatewayDuration formats d as a Gateway API duration, e.g. "1m30s" or
+// "1s500ms". Validation guarantees that d is a non-negative number of
+// milliseconds.
+func gatewayDuration(d time.Duration) *gatewayv1.Duration {
+	var b strings.Builder
+	for _, unit := range []struct {
+		size   time.Duration
+		suffix string
+	}{
+		{time.Hour, "h"},
+		{time.Minute, "m"},
+		{time.Second, "s"},
+		{time.Millisecond, "ms"},
+	} {
+		if n := d / unit.size; n > 0 {
+			fmt.Fprintf(&b, "%d%s", n, unit.suffix)
+			d -= n * unit.size
+		}
+	}
+	if b.Len() == 0 {
+		b.WriteString("0s")
+	}
+	duration := gatewayv1.Duration(b.String())
+	return &duration
+}
+
+// observeRouteFeatures warns, through the RouteFeaturesSupported condition
+// and an event, when the policy's gateway does not support the timeouts or
+// retries of the endpoint route: either its GatewayClass lists the features
+// it supports without the timeouts, or it rejected the route with
+// UnsupportedValue. Gateway API has no feature name for retries, so those
+// are only reported through the route status. Endpoints without timeouts or
+// retries have no condition.
+func (r *EndpointPolicyReconciler) observeRouteFeatures(
+	ctx context.Context,
+	policy *esv1alpha1.EndpointPolicy,
+	endpoint *esv1alpha1.EndpointSpec,
+	status *esv1alpha1.EndpointStatus,
+) error {
+	if endpoint.Timeouts == nil && endpoint.Retry == nil {
+		meta.RemoveStatusCondition(&status.Conditions, EndpointConditionRouteFeaturesSupported)
+		return nil
+	}
+
+	cond := metav1.Condition{
+		Type:               EndpointConditionRouteFeaturesSupported,
+		Status:             metav1.ConditionTrue,
+		LastTransitionTime: metav1.NewTime(r.now()),
+		Reason:             ReasonFeaturesSupported,
+		Message:            "gateway reported no unsupported timeout or retry",
+	}
+
+	className, missing, err := r.missingGatewayFeatures(ctx, policy, endpoint)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = ReasonUnsupportedFeature
+		cond.Message = fmt.Sprintf("gateway class %s does not support %s", className, strings.Join(missing, ", "))
+	}
+	for _, rejection := range status.RouteRejections {
+		if rejection.Reason == string(gatewayv1.RouteReasonUnsupportedValue) {
+			cond.Status = metav1.ConditionFalse
+			cond.Reason = ReasonUnsupportedFeature
+			cond.Message = fmt.Sprintf("gateway reported %s=False: %s", rejection.Type, rejection.Message)
+			break
+		}
+	}
+
+	prev := meta.FindStatusCondition(status.Conditions, EndpointConditionRouteFeaturesSupported)
+	if cond.Status == metav1.ConditionFalse && (prev == nil || prev.Status != cond.Status) {
+		log.FromContext(ctx).Info("Gateway does not support endpoint route features",
+			"endpoint", endpoint.ID, "message", cond.Message)
+		r.event(policy, corev1.EventTypeWarning, cond.Reason, "Endpoint %s: %s", endpoint.ID, cond.Message)
+	}
+	meta.SetStatusCondition(&status.Conditions, cond)
+	return nil
+}
+
+// missingGatewayFeatures returns the timeout features the endpoint uses that
+// the GatewayClass of the policy's gateway does not list as supported. A
+// class that lists no features, or a gateway or class that cannot be found,
+// gives no evidence either way.
+func (r *EndpointPolicyReconciler) missingGatewayFeatures(
+	ctx context.Context,
+	policy *esv1alpha1.EndpointPolicy,
+	endpoint *esv1alpha1.EndpointSpec,
+) (string, []string, error) {
+	var used []features.FeatureName
+	if t := endpoint.Timeouts; t != nil {
+		if t.Request != nil {
+			used = append(used, features.SupportHTTPRouteRequestTimeout)
+		}
+		if t.BackendRequest != nil {
+			used = append(used, features.SupportHTTPRouteBackendTimeout)
+		}
+	}
+	if len(used) == 0 {
+		return "", nil, nil
+	}
+
+	gatewayNS := policy.Spec.GatewayRef.Namespace
+	if gatewayNS == "" {
+		gatewayNS = policy.Namespace
+	}
+	gw := &gatewayv1.Gateway{}
+	if err := r.Get(ctx, types.NamespacedName{Name: policy.Spec.GatewayRef.Name, Namespace: gatewayNS}, gw); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil, nil
+		}
+		return "", nil, fmt.Errorf("gateway %s/%s: %w", gatewayNS, policy.Spec.GatewayRef.Name, err)
+	}
+	class := &gatewayv1.GatewayClass{}
+	if err := r.Get(ctx, types.NamespacedName{Name: string(gw.Spec.GatewayClassName)}, class); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil, nil
+		}
+		return "", nil, fmt.Errorf("gateway class %s: %w", gw.Spec.GatewayClassName, err)
+	}
+	if len(class.Status.SupportedFeatures) == 0 {
+		return class.Name, nil, nil
+	}
+
+	var missing []string
+	for _, name := range used {
+		if !slices.ContainsFunc(class.Status.SupportedFeatures, func(f gatewayv1.SupportedFeature) bool {
+			return f.Name == gatewayv1.FeatureName(name)
+		}) {
+			missing = append(missing, string(name))
+		}
+	}
+	return class.Name, missing, nil
+}
@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+)
+
+func TestGatewayDuration(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{0, "0s"},
+		{500 * time.Millisecond, "500ms"},
+		{1500 * time.Millisecond, "1s500ms"},
+		{90 * time.Second, "1m30s"},
+		{2 * time.Hour, "2h"},
+		{time.Hour + 2*time.Minute + 3*time.Second + 4*time.Millisecond, "1h2m3s4ms"},
+	}
+	for _, tt := range tests {
+		if got := gatewayDuration(tt.in); string(*got) != tt.want {
+			t.Errorf("gatewayDuration(%v) = %q, want %q", tt.in, *got, tt.want)
+		}
+	}
+}
+
+func TestBuildHTTPRoute_TimeoutsAndRetry(t *testing.T) {
+	r := &EndpointPolicyReconciler{}
+	policy := testEndpointPolicy()
+	endpoint := &policy.Spec.Endpoints[0]
+	endpoint.Timeouts = &esv1alpha1.TimeoutsSpec{
+		Request:        &metav1.Duration{Duration: 10 * time.Second},
+		BackendRequest: &metav1.Duration{Duration: 2500 * time.Millisecond},
+	}
+	endpoint.Retry = &esv1alpha1.RetrySpec{
+		Attempts: ptr.To(int32(3)),
+		Backoff:  &metav1.Duration{Duration: 100 * time.Millisecond},
+		Codes:    []int32{502, 503},
+	}
+
+	rule := r.buildHTTPRoute(policy, endpoint).Spec.Rules[0]
+
+	if rule.Timeouts == nil || *rule.Timeouts.Request != "10s" || *rule.Timeouts.BackendRequest != "2s500ms" {
+		t.Errorf("expected 10s request and 2s500ms backend request timeouts, got %+v", rule.Timeouts)
+	}
+	retry := rule.Retry
+	if retry == nil || *retry.Attempts != 3 || *retry.Backoff != "100ms" {
+		t.Fatalf("expected 3 attempts with 100ms backoff, got %+v", retry)
+	}
+	if len(retry.Codes) != 2 || retry.Codes[0] != 502 || retry.Codes[1] != 503 {
+		t.Errorf("expected codes 502 and 503, got %v", retry.Codes)
+	}
+
+	// Both rules of an A/B test get them
+	endpoint.Strategy = StrategyABTest
+	endpoint.ABTest = &esv1alpha1.ABTestSpec{Headers: []esv1alpha1.HeaderMatch{{Name: "X-Beta", Value: "true"}}}
+	for i, rule := range r.buildHTTPRoute(policy, endpoint).Spec.Rules {
+		if rule.Timeouts == nil || rule.Retry == nil {
+			t.Errorf("expected timeouts and retry on rule %d, got %+v", i, rule)
+		}
+	}
+}
+
+func TestBuildHTTPRoute_NoTimeoutsOrRetry(t *testing.T) {
+	r := &EndpointPolicyReconciler{}
+	policy := testEndpointPolicy()
+
+	rule := r.buildHTTPRoute(policy, &policy.Spec.Endpoints[0]).Spec.Rules[0]
+	if rule.Timeouts != nil || rule.Retry != nil {
+		t.Errorf("expected no timeouts or retry, got %+v and %+v", rule.Timeouts, rule.Retry)
+	}
+}
+
+func TestObserveRouteFeatures(t *testing.T) {
+	policy := testEndpointPolicy()
+	endpoint := &policy.Spec.Endpoints[0]
+	endpoint.Timeouts = &esv1alpha1.TimeoutsSpec{
+		Request:        &metav1.Duration{Duration: 10 * time.Second},
+		BackendRequest: &metav1.Duration{Duration: 2 * time.Second},
+	}
+	class := &gatewayv1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "example"},
+		Status: gatewayv1.GatewayClassStatus{
+			SupportedFeatures: []gatewayv1.SupportedFeature{{Name: "HTTPRoute"}, {Name: "HTTPRouteRequestTimeout"}},
+		},
+	}
+	r := newFakeReconciler(t, testGateway(), class)
+	recorder := record.NewFakeRecorder(10)
+	r.Recorder = recorder
+	ctx := context.Background()
+	status := &esv1alpha1.EndpointStatus{ID: endpoint.ID}
+
+	if err := r.observeRouteFeatures(ctx, policy, endpoint, status); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cond := meta.FindStatusCondition(status.Conditions, EndpointConditionRouteFeaturesSupported)
+	if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != ReasonUnsupportedFeature {
+		t.Fatalf("expected an unsupported feature warning, got %+v", cond)
+	}
+	if !strings.Contains(cond.Message, "HTTPRouteBackendTimeout") || strings.Contains(cond.Message, "HTTPRouteRequestTimeout") {
+		t.Errorf("expected only the backend timeout to be missing, got %q", cond.Message)
+	}
+	if events := drainEvents(recorder); len(events) != 1 || !strings.HasPrefix(events[0], "Warning UnsupportedFeature") {
+		t.Errorf("expected a warning event, got %v", events)
+	}
+
+	// The warning is only recorded once
+	if err := r.observeRouteFeatures(ctx, policy, endpoint, status); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if events := drainEvents(recorder); len(events) != 0 {
+		t.Errorf("expected no repeated event, got %v", events)
+	}
+
+	// Without the backend timeout every feature in use is supported
+	endpoint.Timeouts.BackendRequest = nil
+	if err := r.observeRouteFeatures(ctx, policy, endpoint, status); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !meta.IsStatusConditionTrue(status.Conditions, EndpointConditionRouteFeaturesSupported) {
+		t.Errorf("expected features supported, got %+v", status.Conditions)
+	}
+
+	// Retries have no feature name and are only reported by the route status
+	endpoint.Retry = &esv1alpha1.RetrySpec{Attempts: ptr.To(int32(2))}
+	status.RouteRejections = []esv1alpha1.RouteRejection{{
+		Type:    string(gatewayv1.RouteConditionAccepted),
+		Reason:  string(gatewayv1.RouteReasonUnsupportedValue),
+		Message: "retry is not supported",
+	}}
+	if err := r.observeRouteFeatures(ctx, policy, endpoint, status); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cond = meta.FindStatusCondition(status.Conditions, EndpointConditionRouteFeaturesSupported)
+	if cond.Status != metav1.ConditionFalse || !strings.Contains(cond.Message, "retry is not supported") {
+		t.Errorf("expected the gateway rejection as warning, got %+v", cond)
+	}
+
+	endpoint.Timeouts, endpoint.Retry = nil, nil
+	if err := r.observeRouteFeatures(ctx, policy, endpoint, status); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if meta.FindStatusCondition(status.Conditions, EndpointConditionRouteFeaturesSupported) != nil {
+		t.Errorf("expected no condition without timeouts or retries, got %+v", status.Conditions)
+	}
+}
+
+func TestObserveRouteFeatures_UnknownGatewayClass(t *testing.T) {
+	policy := testEndpointPolicy()
+	endpoint := &policy.Spec.Endpoints[0]
+	endpoint.Timeouts = &esv1alpha1.TimeoutsSpec{Request: &metav1.Duration{Duration: time.Second}}
+	r := newFakeReconciler(t, testGateway())
+	status := &esv1alpha1.EndpointStatus{ID: endpoint.ID}
+
+	if err := r.observeRouteFeatures(context.Background(), policy, endpoint, status); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !meta.IsStatusConditionTrue(status.Conditions, EndpointConditionRouteFeaturesSupported) {
+		t.Errorf("expected no warning without evidence, got %+v", status.Conditions)
+	}
+}
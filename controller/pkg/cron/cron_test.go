@@ -0,0 +1,65 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("expected %q to be rejected", expr)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("no time zone database: %v", err)
+	}
+	// Friday
+	from := time.Date(2026, 3, 6, 17, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"*/15 * * * *", from, time.Date(2026, 3, 6, 17, 45, 0, 0, time.UTC)},
+		{"0 8 * * *", from, time.Date(2026, 3, 7, 8, 0, 0, 0, time.UTC)},
+		{"0 8 * * 1-5", from, time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC)},
+		{"0 8 * * mon-fri", from, time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan *", from, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"30 17 * * *", from, time.Date(2026, 3, 7, 17, 30, 0, 0, time.UTC)},
+		{"0 9 * * 7", from, time.Date(2026, 3, 8, 9, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either matches
+		{"0 0 15 * 1", from, time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)},
+		// Times are evaluated in the location of from
+		{"0 18 * * *", from.In(berlin), time.Date(2026, 3, 7, 18, 0, 0, 0, berlin)},
+		{"0 0 30 2 *", from, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := s.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,348 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	caValidity   = 10 * 365 * 24 * time.Hour
+	certValidity = 365 * 24 * time.Hour
+
+	// certRenewBefore is how long before expiry the serving certificate is
+	// replaced.
+	certRenewBefore = 30 * 24 * time.Hour
+
+	defaultCertRefreshInterval = time.Hour
+
+	caCertKey = "ca.crt"
+	caKeyKey  = "ca.key"
+)
+
+// CertManager self-manages the webhook serving certificate so that no
+// cert-manager is required. The CA and certificate are kept in a Secret
+// shared by all replicas; each replica writes them to CertDir for the webhook
+// server and injects the CA into the webhook configurations.
+type CertManager struct {
+	// Client must not be cache-backed: Ensure runs before the manager starts.
+	Client client.Client
+
+	Namespace   string
+	SecretName  string
+	ServiceName string
+	CertDir     string
+
+	// WebhookConfigName is the name of both the mutating and the validating
+	// webhook configuration.
+	WebhookConfigName string
+
+	// RefreshInterval is how often certificates are checked for renewal
+	// (defaults to one hour).
+	RefreshInterval time.Duration
+}
+
+// Start periodically renews the certificate. It implements manager.Runnable.
+func (m *CertManager) Start(ctx context.Context) error {
+	interval := m.RefreshInterval
+	if interval == 0 {
+		interval = defaultCertRefreshInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := m.Ensure(ctx); err != nil {
+				log.FromContext(ctx).Error(err, "failed to refresh webhook certificates")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection is false since every replica serves webhooks from its
+// own copy of the certificate.
+func (m *CertManager) NeedLeaderElection() bool {
+	return false
+}
+
+// Ensure makes sure a valid certificate exists in the Secret, on disk and in
+// the webhook configurations' caBundle.
+func (m *CertManager) Ensure(ctx context.Context) error {
+	secret, err := m.ensureSecret(ctx)
+	if err != nil {
+		return err
+	}
+	if err := m.writeCertFiles(secret); err != nil {
+		return err
+	}
+	return m.injectCABundle(ctx, secret.Data[caCertKey])
+}
+
+func (m *CertManager) ensureSecret(ctx context.Context) (*corev1.Secret, error) {
+	key := types.NamespacedName{Name: m.SecretName, Namespace: m.Namespace}
+	for attempt := 0; attempt < 3; attempt++ {
+		secret := &corev1.Secret{}
+		err := m.Client.Get(ctx, key, secret)
+		if apierrors.IsNotFound(err) {
+			secret = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: m.SecretName, Namespace: m.Namespace},
+				Type:       corev1.SecretTypeTLS,
+			}
+			if secret.Data, err = m.generate(nil); err != nil {
+				return nil, err
+			}
+			if err := m.Client.Create(ctx, secret); apierrors.IsAlreadyExists(err) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			return secret, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if m.valid(secret.Data) {
+			return secret, nil
+		}
+		log.FromContext(ctx).Info("Renewing webhook certificate", "secret", key)
+		if secret.Data, err = m.generate(secret.Data); err != nil {
+			return nil, err
+		}
+		if err := m.Client.Update(ctx, secret); apierrors.IsConflict(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		return secret, nil
+	}
+	return nil, fmt.Errorf("secret %s changed concurrently", key)
+}
+
+func (m *CertManager) dnsNames() []string {
+	return []string{
+		m.ServiceName,
+		fmt.Sprintf("%s.%s", m.ServiceName, m.Namespace),
+		fmt.Sprintf("%s.%s.svc", m.ServiceName, m.Namespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", m.ServiceName, m.Namespace),
+	}
+}
+
+// valid reports whether data holds a serving certificate signed by its CA,
+// for the webhook Service, that is not due for renewal.
+func (m *CertManager) valid(data map[string][]byte) bool {
+	if _, err := tls.X509KeyPair(data[corev1.TLSCertKey], data[corev1.TLSPrivateKeyKey]); err != nil {
+		return false
+	}
+	cert, err := parseCert(data[corev1.TLSCertKey])
+	if err != nil {
+		return false
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data[caCertKey]) {
+		return false
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{DNSName: m.dnsNames()[2], Roots: pool}); err != nil {
+		return false
+	}
+	return time.Until(cert.NotAfter) > certRenewBefore && slices.Equal(cert.DNSNames, m.dnsNames())
+}
+
+// generate issues a new serving certificate. The existing CA is reused while
+// it is valid, so webhook clients holding the old caBundle keep working.
+func (m *CertManager) generate(existing map[string][]byte) (map[string][]byte, error) {
+	caCert, caKey, err := parseCA(existing)
+	if err != nil || time.Until(caCert.NotAfter) < certValidity {
+		if caCert, caKey, err = newCA(); err != nil {
+			return nil, err
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: newSerial(),
+		Subject:      pkix.Name{CommonName: m.dnsNames()[2]},
+		DNSNames:     m.dnsNames(),
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(certValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+	caKeyPEM, err := encodeKey(caKey)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{
+		caCertKey:               encodeCert(caCert.Raw),
+		caKeyKey:                caKeyPEM,
+		corev1.TLSCertKey:       encodeCert(der),
+		corev1.TLSPrivateKeyKey: keyPEM,
+	}, nil
+}
+
+func newCA() (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          newSerial(),
+		Subject:               pkix.Name{CommonName: "endpoint-scaler-webhook-ca"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+func parseCA(data map[string][]byte) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	cert, err := parseCert(data[caCertKey])
+	if err != nil {
+		return nil, nil, err
+	}
+	block, _ := pem.Decode(data[caKeyKey])
+	if block == nil {
+		return nil, nil, fmt.Errorf("no CA key")
+	}
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+func parseCert(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func encodeCert(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func encodeKey(key *ecdsa.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+}
+
+func newSerial() *big.Int {
+	serial, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	return serial
+}
+
+// writeCertFiles writes the serving certificate for the webhook server, which
+// reloads it when the files change.
+func (m *CertManager) writeCertFiles(secret *corev1.Secret) error {
+	if err := os.MkdirAll(m.CertDir, 0o700); err != nil {
+		return err
+	}
+	for _, name := range []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey} {
+		path := filepath.Join(m.CertDir, name)
+		if current, err := os.ReadFile(path); err == nil && bytes.Equal(current, secret.Data[name]) {
+			continue
+		}
+		if err := os.WriteFile(path, secret.Data[name], 0o600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// injectCABundle sets caBundle on every webhook of the mutating and
+// validating configurations. Missing configurations are skipped, e.g. when
+// running outside the cluster.
+func (m *CertManager) injectCABundle(ctx context.Context, caBundle []byte) error {
+	key := types.NamespacedName{Name: m.WebhookConfigName}
+
+	mutating := &admissionregistrationv1.MutatingWebhookConfiguration{}
+	if err := m.Client.Get(ctx, key, mutating); client.IgnoreNotFound(err) != nil {
+		return err
+	} else if err == nil {
+		changed := false
+		for i := range mutating.Webhooks {
+			if !bytes.Equal(mutating.Webhooks[i].ClientConfig.CABundle, caBundle) {
+				mutating.Webhooks[i].ClientConfig.CABundle = caBundle
+				changed = true
+			}
+		}
+		if changed {
+			if err := m.Client.Update(ctx, mutating); err != nil {
+				return err
+			}
+		}
+	}
+
+	validating := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+	if err := m.Client.Get(ctx, key, validating); client.IgnoreNotFound(err) != nil {
+		return err
+	} else if err == nil {
+		changed := false
+		for i := range validating.Webhooks {
+			if !bytes.Equal(validating.Webhooks[i].ClientConfig.CABundle, caBundle) {
+				validating.Webhooks[i].ClientConfig.CABundle = caBundle
+				changed = true
+			}
+		}
+		if changed {
+			if err := m.Client.Update(ctx, validating); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
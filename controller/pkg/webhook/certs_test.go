@@ -0,0 +1,143 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newCertManager(t *testing.T, objs ...client.Object) *CertManager {
+	t.Helper()
+	return &CertManager{
+		Client:            fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(objs...).Build(),
+		Namespace:         "endpoint-scaler",
+		SecretName:        "endpoint-scaler-webhook-cert",
+		ServiceName:       "endpoint-scaler-webhook",
+		CertDir:           t.TempDir(),
+		WebhookConfigName: "endpoint-scaler",
+	}
+}
+
+func webhookConfigs() (*admissionregistrationv1.MutatingWebhookConfiguration, *admissionregistrationv1.ValidatingWebhookConfiguration) {
+	meta := metav1.ObjectMeta{Name: "endpoint-scaler"}
+	return &admissionregistrationv1.MutatingWebhookConfiguration{
+		ObjectMeta: meta,
+		Webhooks:   []admissionregistrationv1.MutatingWebhook{{Name: "mendpointpolicy.endpointscaler.io"}},
+	}, &admissionregistrationv1.ValidatingWebhookConfiguration{
+		ObjectMeta: meta,
+		Webhooks:   []admissionregistrationv1.ValidatingWebhook{{Name: "vendpointpolicy.endpointscaler.io"}},
+	}
+}
+
+func TestCertManager_Ensure(t *testing.T) {
+	mutating, validating := webhookConfigs()
+	m := newCertManager(t, mutating, validating)
+	ctx := context.Background()
+
+	if err := m.Ensure(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	secret := &corev1.Secret{}
+	if err := m.Client.Get(ctx, types.NamespacedName{Name: m.SecretName, Namespace: m.Namespace}, secret); err != nil {
+		t.Fatalf("failed to get secret: %v", err)
+	}
+	if !m.valid(secret.Data) {
+		t.Error("expected a valid certificate in the secret")
+	}
+
+	cert, err := parseCert(secret.Data[corev1.TLSCertKey])
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(secret.Data[caCertKey])
+	if _, err := cert.Verify(x509.VerifyOptions{DNSName: "endpoint-scaler-webhook.endpoint-scaler.svc", Roots: pool}); err != nil {
+		t.Errorf("certificate does not verify for the webhook service: %v", err)
+	}
+
+	for _, name := range []string{"tls.crt", "tls.key"} {
+		data, err := os.ReadFile(filepath.Join(m.CertDir, name))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		if !bytes.Equal(data, secret.Data[name]) {
+			t.Errorf("%s on disk does not match the secret", name)
+		}
+	}
+
+	if err := m.Client.Get(ctx, client.ObjectKeyFromObject(mutating), mutating); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Client.Get(ctx, client.ObjectKeyFromObject(validating), validating); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(mutating.Webhooks[0].ClientConfig.CABundle, secret.Data[caCertKey]) ||
+		!bytes.Equal(validating.Webhooks[0].ClientConfig.CABundle, secret.Data[caCertKey]) {
+		t.Error("expected caBundle to be injected into both webhook configurations")
+	}
+}
+
+func TestCertManager_ReusesValidSecret(t *testing.T) {
+	m := newCertManager(t)
+	ctx := context.Background()
+	if err := m.Ensure(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	key := types.NamespacedName{Name: m.SecretName, Namespace: m.Namespace}
+	first := &corev1.Secret{}
+	if err := m.Client.Get(ctx, key, first); err != nil {
+		t.Fatal(err)
+	}
+
+	// A second replica starting up uses the same certificate
+	if err := m.Ensure(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second := &corev1.Secret{}
+	if err := m.Client.Get(ctx, key, second); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first.Data[corev1.TLSCertKey], second.Data[corev1.TLSCertKey]) {
+		t.Error("expected the existing certificate to be reused")
+	}
+}
+
+func TestCertManager_RenewsForNewService(t *testing.T) {
+	m := newCertManager(t)
+	ctx := context.Background()
+	if err := m.Ensure(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	key := types.NamespacedName{Name: m.SecretName, Namespace: m.Namespace}
+	first := &corev1.Secret{}
+	if err := m.Client.Get(ctx, key, first); err != nil {
+		t.Fatal(err)
+	}
+
+	m.ServiceName = "renamed-webhook"
+	if err := m.Ensure(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second := &corev1.Secret{}
+	if err := m.Client.Get(ctx, key, second); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(first.Data[corev1.TLSCertKey], second.Data[corev1.TLSCertKey]) {
+		t.Error("expected a new certificate for the renamed service")
+	}
+	if !bytes.Equal(first.Data[caCertKey], second.Data[caCertKey]) {
+		t.Error("expected the CA to be kept")
+	}
+}
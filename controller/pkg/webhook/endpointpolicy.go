@@ -0,0 +1,136 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+	"github.com/example/endpoint-scaler/controller/pkg/controller"
+)
+
+// +kubebuilder:webhook:path=/mutate-endpointscaler-io-v1alpha1-endpointpolicy,mutating=true,failurePolicy=fail,sideEffects=None,groups=endpointscaler.io,resources=endpointpolicies,verbs=create;update,versions=v1alpha1,name=mendpointpolicy.endpointscaler.io,admissionReviewVersions=v1
+// +kubebuilder:webhook:path=/validate-endpointscaler-io-v1alpha1-endpointpolicy,mutating=false,failurePolicy=fail,sideEffects=None,groups=endpointscaler.io,resources=endpointpolicies,verbs=create;update,versions=v1alpha1,name=vendpointpolicy.endpointscaler.io,admissionReviewVersions=v1
+
+// EndpointPolicyWebhook defaults and validates EndpointPolicies on admission,
+// so that invalid specs are rejected by the API server instead of surfacing
+// later as a ValidationFailed condition.
+type EndpointPolicyWebhook struct {
+	// Client, when set, is used to reject routes that conflict with other
+	// policies. It must have controller.RouteClaimIndex registered.
+	Client client.Reader
+}
+
+var (
+	_ admission.CustomDefaulter = &EndpointPolicyWebhook{}
+	_ admission.CustomValidator = &EndpointPolicyWebhook{}
+)
+
+// SetupWithManager registers the mutating and validating webhooks.
+func (w *EndpointPolicyWebhook) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&esv1alpha1.EndpointPolicy{}).
+		WithDefaulter(w).
+		WithValidator(w).
+		Complete()
+}
+
+func (w *EndpointPolicyWebhook) Default(_ context.Context, obj runtime.Object) error {
+	policy, ok := obj.(*esv1alpha1.EndpointPolicy)
+	if !ok {
+		return fmt.Errorf("expected an EndpointPolicy but got %T", obj)
+	}
+	policy.Spec.Default()
+	return nil
+}
+
+func (w *EndpointPolicyWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	policy, ok := obj.(*esv1alpha1.EndpointPolicy)
+	if !ok {
+		return nil, fmt.Errorf("expected an EndpointPolicy but got %T", obj)
+	}
+	return nil, w.validate(ctx, nil, policy)
+}
+
+func (w *EndpointPolicyWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	policy, ok := newObj.(*esv1alpha1.EndpointPolicy)
+	if !ok {
+		return nil, fmt.Errorf("expected an EndpointPolicy but got %T", newObj)
+	}
+	old, ok := oldObj.(*esv1alpha1.EndpointPolicy)
+	if !ok {
+		return nil, fmt.Errorf("expected an EndpointPolicy but got %T", oldObj)
+	}
+	// A policy being deleted must always be able to drop its finalizer, even
+	// if it was stored before validation was enforced.
+	if !policy.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+	return nil, w.validate(ctx, old, policy)
+}
+
+func (w *EndpointPolicyWebhook) ValidateDelete(context.Context, runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validate checks the fields of policy and, on create or when an update
+// changes the spec, the routes it newly claims. old is nil on create.
+func (w *EndpointPolicyWebhook) validate(ctx context.Context, old, policy *esv1alpha1.EndpointPolicy) error {
+	allErrs := policy.Spec.ValidateFields()
+	if len(allErrs) == 0 && (old == nil || !equality.Semantic.DeepEqual(old.Spec, policy.Spec)) {
+		conflictErrs, err := w.validateConflicts(ctx, old, policy)
+		if err != nil {
+			return apierrors.NewInternalError(err)
+		}
+		allErrs = conflictErrs
+	}
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(esv1alpha1.GroupVersion.WithKind("EndpointPolicy").GroupKind(), policy.Name, allErrs)
+}
+
+// validateConflicts rejects endpoints whose routes are already claimed, by an
+// earlier endpoint of the same policy or by an older policy. Only claims
+// that old did not make are checked: a conflict that already exists, e.g.
+// between policies created before the webhook, is left to the controller so
+// that neither policy becomes impossible to update.
+func (w *EndpointPolicyWebhook) validateConflicts(ctx context.Context, old, policy *esv1alpha1.EndpointPolicy) (field.ErrorList, error) {
+	if w.Client == nil {
+		return nil, nil
+	}
+	conflicts, err := controller.FindRouteConflicts(ctx, w.Client, policy)
+	if err != nil {
+		return nil, err
+	}
+	claimed := map[string]bool{}
+	if old != nil {
+		for _, key := range controller.IndexRouteClaims(old) {
+			claimed[key] = true
+		}
+	}
+
+	var allErrs field.ErrorList
+	reported := map[string]bool{}
+	for _, conflict := range conflicts {
+		if reported[conflict.EndpointID] || !conflict.Lost || claimed[conflict.Key] {
+			continue
+		}
+		reported[conflict.EndpointID] = true
+		for i := range policy.Spec.Endpoints {
+			if policy.Spec.Endpoints[i].ID == conflict.EndpointID {
+				path := field.NewPath("spec", "endpoints").Index(i).Child("match")
+				allErrs = append(allErrs, field.Invalid(path, conflict.Match, conflict.String()))
+				break
+			}
+		}
+	}
+	return allErrs, nil
+}
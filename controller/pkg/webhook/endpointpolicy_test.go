@@ -0,0 +1,225 @@
+package webhook
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	esv1alpha1 "github.com/example/endpoint-scaler/controller/pkg/apis/endpointscaler/v1alpha1"
+	"github.com/example/endpoint-scaler/controller/pkg/controller"
+)
+
+func testPolicy() *esv1alpha1.EndpointPolicy {
+	return &esv1alpha1.EndpointPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-policy", Namespace: "default"},
+		Spec: esv1alpha1.EndpointPolicySpec{
+			AppRef:     esv1alpha1.AppReference{Name: "my-app", Image: "my-app:v1"},
+			GatewayRef: esv1alpha1.GatewayReference{Name: "my-gateway"},
+			Endpoints: []esv1alpha1.EndpointSpec{
+				{ID: "lookup", Match: esv1alpha1.MatchSpec{Path: "/api/lookup"}},
+				{ID: "search", Match: esv1alpha1.MatchSpec{Path: "/api/search"}, Strategy: "canary"},
+			},
+		},
+	}
+}
+
+func TestDefault(t *testing.T) {
+	policy := testPolicy()
+
+	if err := (&EndpointPolicyWebhook{}).Default(context.Background(), policy); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if policy.Spec.AppRef.Port != 80 || policy.Spec.AppRef.ContainerPort != 8080 {
+		t.Errorf("expected ports 80/8080, got %d/%d", policy.Spec.AppRef.Port, policy.Spec.AppRef.ContainerPort)
+	}
+	lookup, search := policy.Spec.Endpoints[0], policy.Spec.Endpoints[1]
+	if lookup.Type != "http" || lookup.Strategy != "primary" {
+		t.Errorf("expected http/primary, got %s/%s", lookup.Type, lookup.Strategy)
+	}
+	if lookup.CanaryWeight != nil {
+		t.Errorf("expected no canary weight on primary endpoint, got %d", *lookup.CanaryWeight)
+	}
+	if search.CanaryWeight == nil || *search.CanaryWeight != 5 {
+		t.Errorf("expected canary weight 5, got %v", search.CanaryWeight)
+	}
+}
+
+func TestDefault_KeepsExplicitValues(t *testing.T) {
+	policy := testPolicy()
+	policy.Spec.AppRef.Port = 9090
+	weight := int32(20)
+	policy.Spec.Endpoints[1].CanaryWeight = &weight
+	policy.Spec.Endpoints[1].Type = "grpc"
+
+	if err := (&EndpointPolicyWebhook{}).Default(context.Background(), policy); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if policy.Spec.AppRef.Port != 9090 {
+		t.Errorf("expected port 9090, got %d", policy.Spec.AppRef.Port)
+	}
+	if *policy.Spec.Endpoints[1].CanaryWeight != 20 || policy.Spec.Endpoints[1].Type != "grpc" {
+		t.Errorf("expected explicit values to be kept, got %+v", policy.Spec.Endpoints[1])
+	}
+}
+
+func TestValidateCreate(t *testing.T) {
+	w := &EndpointPolicyWebhook{}
+
+	if _, err := w.ValidateCreate(context.Background(), testPolicy()); err != nil {
+		t.Errorf("expected valid policy, got %v", err)
+	}
+
+	invalid := testPolicy()
+	invalid.Spec.Endpoints[1].ID = "lookup"
+	invalid.Spec.Endpoints[0].Match.Path = ""
+	_, err := w.ValidateCreate(context.Background(), invalid)
+	if !apierrors.IsInvalid(err) {
+		t.Fatalf("expected Invalid error, got %v", err)
+	}
+	for _, want := range []string{"spec.endpoints[0].match.path", "spec.endpoints[1].id"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error containing %q, got %v", want, err)
+		}
+	}
+}
+
+func TestValidateUpdate_AllowsDeletingPolicy(t *testing.T) {
+	w := &EndpointPolicyWebhook{}
+	invalid := testPolicy()
+	invalid.Spec.Endpoints = nil
+
+	if _, err := w.ValidateUpdate(context.Background(), invalid, invalid); err == nil {
+		t.Error("expected error for invalid update")
+	}
+
+	now := metav1.Now()
+	invalid.DeletionTimestamp = &now
+	if _, err := w.ValidateUpdate(context.Background(), invalid, invalid); err != nil {
+		t.Errorf("expected deleting policy to be allowed, got %v", err)
+	}
+}
+
+func conflictWebhook(t *testing.T, existing ...*esv1alpha1.EndpointPolicy) *EndpointPolicyWebhook {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := esv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	builder := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithIndex(&esv1alpha1.EndpointPolicy{}, controller.RouteClaimIndex, controller.IndexRouteClaims)
+	for _, policy := range existing {
+		builder = builder.WithObjects(policy)
+	}
+	return &EndpointPolicyWebhook{Client: builder.Build()}
+}
+
+func TestValidateCreate_RejectsRouteConflict(t *testing.T) {
+	existing := testPolicy()
+	existing.Name = "existing"
+	w := conflictWebhook(t, existing)
+
+	policy := testPolicy()
+	policy.Spec.Endpoints[1].Match.Path = "/api/other"
+	_, err := w.ValidateCreate(context.Background(), policy)
+	if !apierrors.IsInvalid(err) {
+		t.Fatalf("expected Invalid error, got %v", err)
+	}
+	for _, want := range []string{"spec.endpoints[0].match", "default/existing"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error containing %q, got %v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "spec.endpoints[1]") {
+		t.Errorf("expected only the conflicting endpoint to be rejected, got %v", err)
+	}
+
+	policy.Spec.GatewayRef.Hostname = "other.example.com"
+	if _, err := w.ValidateCreate(context.Background(), policy); err != nil {
+		t.Errorf("expected a different hostname not to conflict, got %v", err)
+	}
+}
+
+func TestValidateCreate_RejectsConflictWithinPolicy(t *testing.T) {
+	w := conflictWebhook(t)
+
+	policy := testPolicy()
+	policy.Spec.Endpoints[1].Match.Path = "/api/lookup"
+	_, err := w.ValidateCreate(context.Background(), policy)
+	if !apierrors.IsInvalid(err) {
+		t.Fatalf("expected Invalid error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "spec.endpoints[1].match") || strings.Contains(err.Error(), "spec.endpoints[0]") {
+		t.Errorf("expected only the second endpoint to be rejected, got %v", err)
+	}
+}
+
+func TestValidateUpdate_IgnoresOwnClaims(t *testing.T) {
+	policy := testPolicy()
+	w := conflictWebhook(t, policy)
+
+	if _, err := w.ValidateUpdate(context.Background(), policy, policy); err != nil {
+		t.Errorf("expected a policy not to conflict with itself, got %v", err)
+	}
+}
+
+// existingConflict returns two stored policies that both route /api/lookup,
+// as after racing creates or when created before the webhook.
+func existingConflict() (winner, loser *esv1alpha1.EndpointPolicy) {
+	winner = testPolicy()
+	winner.Name = "winner"
+	winner.CreationTimestamp = metav1.NewTime(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	loser = testPolicy()
+	loser.Name = "loser"
+	loser.CreationTimestamp = metav1.NewTime(winner.CreationTimestamp.Add(time.Hour))
+	loser.Spec.Endpoints[1].Match.Path = "/api/other"
+	return winner, loser
+}
+
+func TestValidateUpdate_AllowsFinalizerOnExistingConflict(t *testing.T) {
+	winner, loser := existingConflict()
+	w := conflictWebhook(t, winner, loser)
+
+	for _, policy := range []*esv1alpha1.EndpointPolicy{winner, loser} {
+		updated := policy.DeepCopy()
+		updated.Finalizers = append(updated.Finalizers, "endpointscaler.io/finalizer")
+		if _, err := w.ValidateUpdate(context.Background(), policy, updated); err != nil {
+			t.Errorf("expected a finalizer-only update of %s to be allowed, got %v", policy.Name, err)
+		}
+	}
+
+	// Spec changes that keep the contested claim are allowed too
+	updated := loser.DeepCopy()
+	updated.Spec.Endpoints[1].Match.Path = "/api/changed"
+	if _, err := w.ValidateUpdate(context.Background(), loser, updated); err != nil {
+		t.Errorf("expected the existing conflict not to block the update, got %v", err)
+	}
+}
+
+func TestValidateUpdate_RejectsNewConflict(t *testing.T) {
+	winner, loser := existingConflict()
+	loser.Spec.Endpoints[0].Match.Path = "/api/elsewhere"
+	w := conflictWebhook(t, winner, loser)
+
+	updated := loser.DeepCopy()
+	updated.Spec.Endpoints[1].Match.Path = "/api/search"
+	_, err := w.ValidateUpdate(context.Background(), loser, updated)
+	if !apierrors.IsInvalid(err) || !strings.Contains(err.Error(), "spec.endpoints[1].match") {
+		t.Fatalf("expected the newly claimed match to be rejected, got %v", err)
+	}
+
+	// The older policy wins a claim it adds, which the controller resolves
+	updated = winner.DeepCopy()
+	updated.Spec.Endpoints[1].Match.Path = "/api/other"
+	if _, err := w.ValidateUpdate(context.Background(), winner, updated); err != nil {
+		t.Errorf("expected a claim the policy wins to be allowed, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package endpointscaler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// ForwardedHeader is set on requests proxied by WithFallbackProxy to the
+// value of ENDPOINTSCALER_FORWARD_TOKEN. A request that already carries that
+// value is never proxied again, so misconfigured routing cannot loop. Other
+// values, e.g. sent by a client, are overwritten.
+const ForwardedHeader = "X-Endpointscaler-Forwarded"
+
+// misrouted counts requests that reached a Guard whose endpoint is not active.
+var misrouted atomic.Uint64
+
+// MisroutedRequests returns the number of requests that reached an inactive
+// Guard, whether they were proxied or rejected.
+func MisroutedRequests() uint64 {
+	return misrouted.Load()
+}
+
+// GuardOption configures Guard.
+type GuardOption func(*guardConfig)
+
+type guardConfig struct {
+	fallback *fallbackProxy
+	metrics  *metrics
+}
+
+// WithFallbackProxy makes Guard reverse-proxy requests for an inactive
+// endpoint to the main application service named by ENDPOINTSCALER_FALLBACK_URL
+// instead of answering 503. This lets a route that briefly points at the
+// wrong endpoint, e.g. while routes converge or when a path prefix covers a
+// neighbouring handler, degrade to the main service rather than fail.
+//
+// Usage:
+//
+//	mux.Handle("/lookup", endpointscaler.Guard("lookup", lookupHandler, endpointscaler.WithFallbackProxy()))
+//
+// Requests still get a 503 when either variable is unset or the URL is
+// invalid, or when the request was already forwarded once.
+func WithFallbackProxy() GuardOption {
+	return func(cfg *guardConfig) {
+		cfg.fallback = &fallbackProxy{}
+	}
+}
+
+type fallbackProxy struct {
+	once  sync.Once
+	proxy *httputil.ReverseProxy
+	token string
+}
+
+// serve proxies r to the main service and reports whether it did.
+func (f *fallbackProxy) serve(w http.ResponseWriter, r *http.Request) bool {
+	f.once.Do(func() {
+		target, err := url.Parse(os.Getenv(FallbackURLEnvVar))
+		f.token = os.Getenv(ForwardTokenEnvVar)
+		if err != nil || target.Scheme == "" || target.Host == "" || f.token == "" {
+			return
+		}
+		f.proxy = httputil.NewSingleHostReverseProxy(target)
+	})
+	if f.proxy == nil {
+		return false
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get(ForwardedHeader)), []byte(f.token)) == 1 {
+		return false
+	}
+
+	r = r.Clone(r.Context())
+	r.Header.Set(ForwardedHeader, f.token)
+	f.proxy.ServeHTTP(w, r)
+	return true
+}
@@ -0,0 +1,124 @@
+package endpointscaler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// guardedRequest serves one request for /lookup through a guard for
+// "lookup" with the fallback proxy, while "search" is the active endpoint.
+func guardedRequest(t *testing.T, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+	handler := GuardFunc("lookup", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("inactive handler was called")
+	}, WithFallbackProxy())
+	req := httptest.NewRequest(http.MethodGet, "/lookup?q=1", nil)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGuard_FallbackProxy(t *testing.T) {
+	var forwarded, uri string
+	mainService := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded, uri = r.Header.Get(ForwardedHeader), r.RequestURI
+		io.WriteString(w, "main")
+	}))
+	t.Cleanup(mainService.Close)
+	t.Setenv(GuardrailEnvVar, "search")
+	t.Setenv(FallbackURLEnvVar, mainService.URL)
+	t.Setenv(ForwardTokenEnvVar, "secret")
+	before := MisroutedRequests()
+
+	rec := guardedRequest(t, nil)
+	if rec.Code != http.StatusOK || rec.Body.String() != "main" {
+		t.Errorf("expected the main service response, got %d %q", rec.Code, rec.Body.String())
+	}
+	if forwarded != "secret" || uri != "/lookup?q=1" {
+		t.Errorf("expected /lookup?q=1 forwarded with the token, got %q with %q", uri, forwarded)
+	}
+	if got := MisroutedRequests() - before; got != 1 {
+		t.Errorf("expected one misrouted request, got %d", got)
+	}
+
+	// A client cannot turn the fallback off with a header of its own
+	forwarded = ""
+	rec = guardedRequest(t, http.Header{ForwardedHeader: {"search"}})
+	if rec.Code != http.StatusOK || forwarded != "secret" {
+		t.Errorf("expected a forged header to be replaced, got %d with %q", rec.Code, forwarded)
+	}
+}
+
+func TestGuard_FallbackProxyRejectsForwardedRequest(t *testing.T) {
+	mainService := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("forwarded request was proxied again")
+	}))
+	t.Cleanup(mainService.Close)
+	t.Setenv(GuardrailEnvVar, "search")
+	t.Setenv(FallbackURLEnvVar, mainService.URL)
+	t.Setenv(ForwardTokenEnvVar, "secret")
+	before := MisroutedRequests()
+
+	rec := guardedRequest(t, http.Header{ForwardedHeader: {"secret"}})
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", rec.Code)
+	}
+	if got := MisroutedRequests() - before; got != 1 {
+		t.Errorf("expected one misrouted request, got %d", got)
+	}
+}
+
+func TestGuard_FallbackProxyInvalidURL(t *testing.T) {
+	for _, fallbackURL := range []string{"", "main-service:8080", "http://", "://bad"} {
+		t.Run(fallbackURL, func(t *testing.T) {
+			t.Setenv(GuardrailEnvVar, "search")
+			t.Setenv(FallbackURLEnvVar, fallbackURL)
+			t.Setenv(ForwardTokenEnvVar, "secret")
+			before := MisroutedRequests()
+
+			rec := guardedRequest(t, nil)
+			if rec.Code != http.StatusServiceUnavailable || rec.Body.String() != "endpoint not active" {
+				t.Errorf("expected 503, got %d %q", rec.Code, rec.Body.String())
+			}
+			if got := MisroutedRequests() - before; got != 1 {
+				t.Errorf("expected one misrouted request, got %d", got)
+			}
+		})
+	}
+}
+
+func TestGuard_FallbackProxyWithoutToken(t *testing.T) {
+	mainService := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request was proxied without a forward token")
+	}))
+	t.Cleanup(mainService.Close)
+	t.Setenv(GuardrailEnvVar, "search")
+	t.Setenv(FallbackURLEnvVar, mainService.URL)
+	t.Setenv(ForwardTokenEnvVar, "")
+
+	if rec := guardedRequest(t, nil); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", rec.Code)
+	}
+}
+
+func TestGuard_ActiveEndpointIsNotMisrouted(t *testing.T) {
+	t.Setenv(GuardrailEnvVar, "lookup")
+	before := MisroutedRequests()
+
+	handler := GuardFunc("lookup", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "lookup")
+	}, WithFallbackProxy())
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/lookup", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "lookup" {
+		t.Errorf("expected the handler response, got %d %q", rec.Code, rec.Body.String())
+	}
+	if got := MisroutedRequests() - before; got != 0 {
+		t.Errorf("expected no misrouted request, got %d", got)
+	}
+}
@@ -0,0 +1,125 @@
+// Package grpcguard provides gRPC server interceptors for endpoint-scaler
+// controlled routing. It is a separate module so that the core SDK has no
+// dependency on gRPC.
+package grpcguard
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	endpointscaler "github.com/endpoint-scaler/sdk/go"
+)
+
+// Endpoint registers the gRPC methods served by an endpoint. Service and
+// Method mirror the endpoint's match.service and match.method in the
+// EndpointPolicy.
+type Endpoint struct {
+	// ID is the endpoint ID
+	ID string
+
+	// Service is the fully-qualified gRPC service name
+	// (e.g., "com.example.UserService")
+	Service string
+
+	// Method is the gRPC method name (e.g., "GetUser"). When empty, every
+	// method of Service belongs to the endpoint.
+	Method string
+}
+
+// FromRegistry returns the gRPC endpoints registered in r, so that the
+// interceptors and the generated EndpointPolicy share one source of truth.
+func FromRegistry(r *endpointscaler.Registry) []Endpoint {
+	var endpoints []Endpoint
+	for _, e := range r.Endpoints() {
+		if e.Type == "grpc" {
+			endpoints = append(endpoints, Endpoint{ID: e.ID, Service: e.Service, Method: e.Method})
+		}
+	}
+	return endpoints
+}
+
+// UnaryServerInterceptor returns a grpc.UnaryServerInterceptor that only
+// serves a registered method when its endpoint is active under
+// ENDPOINTSCALER_GUARDRAIL, and fails it with codes.Unavailable otherwise.
+// Methods that belong to no registered endpoint are always served.
+//
+// Usage:
+//
+//	endpoints := []grpcguard.Endpoint{
+//	    {ID: "user-service", Service: "com.example.UserService", Method: "GetUser"},
+//	}
+//	server := grpc.NewServer(
+//	    grpc.UnaryInterceptor(grpcguard.UnaryServerInterceptor(endpoints...)),
+//	    grpc.StreamInterceptor(grpcguard.StreamServerInterceptor(endpoints...)),
+//	)
+func UnaryServerInterceptor(endpoints ...Endpoint) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if err := check(endpoints, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of
+// UnaryServerInterceptor.
+func StreamServerInterceptor(endpoints ...Endpoint) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if err := check(endpoints, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// check returns an Unavailable error when fullMethod belongs to an endpoint
+// that is not active.
+func check(endpoints []Endpoint, fullMethod string) error {
+	endpoint, ok := match(endpoints, fullMethod)
+	if !ok || endpointscaler.IsActiveEndpoint(endpoint.ID) {
+		return nil
+	}
+	return status.Errorf(codes.Unavailable, "endpoint %q not active", endpoint.ID)
+}
+
+// match returns the endpoint registered for fullMethod, which gRPC formats
+// as "/package.Service/Method". An endpoint naming the exact method wins over
+// one registered for the whole service.
+func match(endpoints []Endpoint, fullMethod string) (Endpoint, bool) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return Endpoint{}, false
+	}
+
+	var serviceMatch *Endpoint
+	for i := range endpoints {
+		e := &endpoints[i]
+		if e.Service != service {
+			continue
+		}
+		if e.Method == method {
+			return *e, true
+		}
+		if e.Method == "" && serviceMatch == nil {
+			serviceMatch = e
+		}
+	}
+	if serviceMatch != nil {
+		return *serviceMatch, true
+	}
+	return Endpoint{}, false
+}
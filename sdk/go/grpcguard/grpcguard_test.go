@@ -0,0 +1,90 @@
+package grpcguard
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	endpointscaler "github.com/endpoint-scaler/sdk/go"
+)
+
+func TestMatch(t *testing.T) {
+	endpoints := []Endpoint{
+		{ID: "users", Service: "com.example.UserService"},
+		{ID: "get-user", Service: "com.example.UserService", Method: "GetUser"},
+	}
+
+	tests := []struct {
+		fullMethod string
+		want       string
+		ok         bool
+	}{
+		// The exact method wins over the whole service, whatever the order
+		{"/com.example.UserService/GetUser", "get-user", true},
+		{"/com.example.UserService/ListUsers", "users", true},
+		{"/com.example.OrderService/GetOrder", "", false},
+		{"malformed", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := match(endpoints, tt.fullMethod)
+		if ok != tt.ok || got.ID != tt.want {
+			t.Errorf("match(%q) = %q, %v, want %q, %v", tt.fullMethod, got.ID, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	t.Setenv(endpointscaler.GuardrailEnvVar, "get-user")
+	interceptor := UnaryServerInterceptor(
+		Endpoint{ID: "get-user", Service: "com.example.UserService", Method: "GetUser"},
+		Endpoint{ID: "users", Service: "com.example.UserService"},
+	)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	tests := []struct {
+		fullMethod string
+		want       codes.Code
+	}{
+		{"/com.example.UserService/GetUser", codes.OK},
+		// Methods of no registered endpoint are always served
+		{"/com.example.OrderService/GetOrder", codes.OK},
+		{"/com.example.UserService/ListUsers", codes.Unavailable},
+	}
+	for _, tt := range tests {
+		_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: tt.fullMethod}, handler)
+		if got := status.Code(err); got != tt.want {
+			t.Errorf("%s: got code %v, want %v", tt.fullMethod, got, tt.want)
+		}
+	}
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	t.Setenv(endpointscaler.GuardrailEnvVar, "other")
+	interceptor := StreamServerInterceptor(Endpoint{ID: "users", Service: "com.example.UserService"})
+	called := false
+	handler := func(srv interface{}, ss grpc.ServerStream) error {
+		called = true
+		return nil
+	}
+
+	err := interceptor(nil, nil, &grpc.StreamServerInfo{FullMethod: "/com.example.UserService/WatchUsers"}, handler)
+	if status.Code(err) != codes.Unavailable || called {
+		t.Errorf("expected Unavailable without calling the handler, got %v", err)
+	}
+}
+
+func TestFromRegistry(t *testing.T) {
+	r := endpointscaler.NewRegistry()
+	r.Register(endpointscaler.Endpoint{ID: "get-user", Type: "grpc", Service: "com.example.UserService", Method: "GetUser"})
+	r.Register(endpointscaler.Endpoint{ID: "lookup", Path: "/api/lookup"})
+
+	endpoints := FromRegistry(r)
+	if len(endpoints) != 1 || endpoints[0] != (Endpoint{ID: "get-user", Service: "com.example.UserService", Method: "GetUser"}) {
+		t.Errorf("expected only the gRPC endpoint, got %+v", endpoints)
+	}
+}
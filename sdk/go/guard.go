@@ -1,4 +1,8 @@
 // Package endpointscaler provides middleware for endpoint-scaler controlled routing.
+//
+// gRPC server interceptors live in the separate
+// github.com/endpoint-scaler/sdk/go/grpcguard module so that this package has
+// no dependencies.
 package endpointscaler
 
 import (
@@ -11,6 +15,16 @@ const (
 	// handler should be active. When set, only handlers with matching endpoint IDs
 	// will process requests.
 	GuardrailEnvVar = "ENDPOINTSCALER_GUARDRAIL"
+
+	// FallbackURLEnvVar is the environment variable name holding the URL of the
+	// main application service, set by the controller alongside
+	// ENDPOINTSCALER_GUARDRAIL. It is used by the WithFallbackProxy option.
+	FallbackURLEnvVar = "ENDPOINTSCALER_FALLBACK_URL"
+
+	// ForwardTokenEnvVar is the environment variable name holding the value
+	// WithFallbackProxy sets ForwardedHeader to. The controller shares it
+	// between the endpoint pods of a policy; clients do not know it.
+	ForwardTokenEnvVar = "ENDPOINTSCALER_FORWARD_TOKEN"
 )
 
 // Guard wraps an HTTP handler and only executes it if the ENDPOINTSCALER_GUARDRAIL
@@ -28,23 +42,50 @@ const (
 //
 // When ENDPOINTSCALER_GUARDRAIL is not set (e.g., in development), all handlers are active.
 // When ENDPOINTSCALER_GUARDRAIL is set to "lookup", only the lookup handler processes requests.
-func Guard(endpointID string, handler http.Handler) http.Handler {
+//
+// Requests for an inactive handler are answered with 503, or proxied to the
+// main service when the WithFallbackProxy option is given. WithMetrics records
+// request metrics.
+func Guard(endpointID string, handler http.Handler, opts ...GuardOption) http.Handler {
+	cfg := &guardConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	serve := handler.ServeHTTP
+	if cfg.metrics != nil {
+		serve = func(w http.ResponseWriter, r *http.Request) {
+			cfg.metrics.serve(endpointID, handler, w, r)
+		}
+	}
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		guardrail := os.Getenv(GuardrailEnvVar)
 
 		// If no guardrail is set, allow all handlers (development mode)
 		if guardrail == "" {
-			handler.ServeHTTP(w, r)
+			serve(w, r)
 			return
 		}
 
 		// Only execute if this handler's endpoint ID matches the guardrail
 		if guardrail == endpointID {
-			handler.ServeHTTP(w, r)
+			serve(w, r)
 			return
 		}
 
 		// This handler is not active for the current guardrail
+		misrouted.Add(1)
+		if cfg.fallback != nil && cfg.fallback.serve(w, r) {
+			if cfg.metrics != nil {
+				cfg.metrics.recordMisrouted(endpointID, "proxied")
+			}
+			return
+		}
+		if cfg.metrics != nil {
+			cfg.metrics.recordMisrouted(endpointID, "rejected")
+		}
+
 		// Return 503 to indicate the service is not available at this endpoint
 		w.WriteHeader(http.StatusServiceUnavailable)
 		w.Write([]byte("endpoint not active"))
@@ -52,8 +93,8 @@ func Guard(endpointID string, handler http.Handler) http.Handler {
 }
 
 // GuardFunc is a convenience wrapper for Guard that accepts an http.HandlerFunc.
-func GuardFunc(endpointID string, handler http.HandlerFunc) http.Handler {
-	return Guard(endpointID, handler)
+func GuardFunc(endpointID string, handler http.HandlerFunc, opts ...GuardOption) http.Handler {
+	return Guard(endpointID, handler, opts...)
 }
 
 // IsActiveEndpoint returns true if the current process should handle requests
@@ -74,21 +115,3 @@ func IsActiveEndpoint(endpointID string) bool {
 func ActiveEndpoint() string {
 	return os.Getenv(GuardrailEnvVar)
 }
-
-// GRPCUnaryInterceptor returns a gRPC unary interceptor that only processes
-// requests when the ENDPOINTSCALER_GUARDRAIL matches the endpoint ID.
-//
-// Usage with gRPC:
-//
-//	server := grpc.NewServer(
-//	    grpc.UnaryInterceptor(endpointscaler.GRPCUnaryInterceptor("lookup")),
-//	)
-//
-// Note: This requires importing google.golang.org/grpc. The interceptor
-// signature is provided here as a reference implementation pattern.
-type UnaryServerInterceptor func(
-	ctx interface{},
-	req interface{},
-	info interface{},
-	handler interface{},
-) (interface{}, error)
@@ -0,0 +1,173 @@
+package endpointscaler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Paths served by Lifecycle.Handler. The controller points endpoint probes at
+// them when the EndpointPolicy sets sdkProbes.
+const (
+	HealthzPath = "/endpointscaler/healthz"
+	ReadyzPath  = "/endpointscaler/readyz"
+)
+
+// Lifecycle runs endpoint-scoped initialization and readiness checks. Hooks
+// registered for an endpoint ID only run when that endpoint is active; hooks
+// registered with an empty ID are shared and always run. In development,
+// when ENDPOINTSCALER_GUARDRAIL is not set, every hook runs.
+//
+// Usage:
+//
+//	endpointscaler.OnInit("", openDatabase)
+//	endpointscaler.OnInit("search", loadSearchIndex)
+//	endpointscaler.AddReadinessCheck("search", "index", searchIndexLoaded)
+//
+//	mux.Handle("/endpointscaler/", endpointscaler.DefaultLifecycle.Handler())
+//	if err := endpointscaler.Init(ctx); err != nil {
+//	    log.Fatal(err)
+//	}
+type Lifecycle struct {
+	mu     sync.Mutex
+	inits  []initHook
+	checks []readinessCheck
+	ready  bool
+}
+
+type initHook struct {
+	endpointID string
+	fn         func(context.Context) error
+}
+
+type readinessCheck struct {
+	endpointID string
+	name       string
+	fn         func(context.Context) error
+}
+
+// DefaultLifecycle is the lifecycle used by the package-level functions.
+var DefaultLifecycle = &Lifecycle{}
+
+// OnInit registers fn on DefaultLifecycle.
+func OnInit(endpointID string, fn func(context.Context) error) {
+	DefaultLifecycle.OnInit(endpointID, fn)
+}
+
+// AddReadinessCheck registers a check on DefaultLifecycle.
+func AddReadinessCheck(endpointID, name string, check func(context.Context) error) {
+	DefaultLifecycle.AddReadinessCheck(endpointID, name, check)
+}
+
+// Init runs the init hooks of DefaultLifecycle.
+func Init(ctx context.Context) error {
+	return DefaultLifecycle.Init(ctx)
+}
+
+// OnInit registers an init function for endpointID, or for every endpoint
+// when endpointID is empty.
+func (l *Lifecycle) OnInit(endpointID string, fn func(context.Context) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inits = append(l.inits, initHook{endpointID: endpointID, fn: fn})
+}
+
+// AddReadinessCheck registers a named readiness check for endpointID, or for
+// every endpoint when endpointID is empty.
+func (l *Lifecycle) AddReadinessCheck(endpointID, name string, check func(context.Context) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.checks = append(l.checks, readinessCheck{endpointID: endpointID, name: name, fn: check})
+}
+
+// Init runs the active init functions in registration order and stops at the
+// first error. Readiness is reported only once Init has succeeded.
+func (l *Lifecycle) Init(ctx context.Context) error {
+	l.mu.Lock()
+	inits := append([]initHook{}, l.inits...)
+	l.mu.Unlock()
+
+	for _, hook := range inits {
+		if !hookActive(hook.endpointID) {
+			continue
+		}
+		if err := hook.fn(ctx); err != nil {
+			if hook.endpointID == "" {
+				return fmt.Errorf("shared init: %w", err)
+			}
+			return fmt.Errorf("endpoint %q init: %w", hook.endpointID, err)
+		}
+	}
+
+	l.mu.Lock()
+	l.ready = true
+	l.mu.Unlock()
+	return nil
+}
+
+// Ready runs the active readiness checks and returns the failures by check
+// name. It reports "init" as failing until Init has succeeded.
+func (l *Lifecycle) Ready(ctx context.Context) map[string]string {
+	l.mu.Lock()
+	ready := l.ready
+	checks := append([]readinessCheck{}, l.checks...)
+	l.mu.Unlock()
+
+	failures := map[string]string{}
+	if !ready {
+		failures["init"] = "not initialized"
+	}
+	for _, check := range checks {
+		if !hookActive(check.endpointID) {
+			continue
+		}
+		if err := check.fn(ctx); err != nil {
+			failures[check.name] = err.Error()
+		}
+	}
+	return failures
+}
+
+// healthResponse is the body of the health handlers.
+type healthResponse struct {
+	Endpoint string            `json:"endpoint"`
+	Status   string            `json:"status"`
+	Failures map[string]string `json:"failures,omitempty"`
+}
+
+// Handler serves HealthzPath, which reports the process alive, and
+// ReadyzPath, which runs the readiness checks and answers 503 when any of
+// them fails. Both report the active endpoint.
+func (l *Lifecycle) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(HealthzPath, func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, http.StatusOK, healthResponse{Endpoint: ActiveEndpoint(), Status: "ok"})
+	})
+	mux.HandleFunc(ReadyzPath, func(w http.ResponseWriter, r *http.Request) {
+		failures := l.Ready(r.Context())
+		if len(failures) > 0 {
+			writeHealth(w, http.StatusServiceUnavailable, healthResponse{
+				Endpoint: ActiveEndpoint(),
+				Status:   "unavailable",
+				Failures: failures,
+			})
+			return
+		}
+		writeHealth(w, http.StatusOK, healthResponse{Endpoint: ActiveEndpoint(), Status: "ok"})
+	})
+	return mux
+}
+
+func writeHealth(w http.ResponseWriter, code int, body healthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
+
+// hookActive reports whether a hook registered for endpointID runs in this
+// process.
+func hookActive(endpointID string) bool {
+	return endpointID == "" || IsActiveEndpoint(endpointID)
+}
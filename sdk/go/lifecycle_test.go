@@ -0,0 +1,122 @@
+package endpointscaler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLifecycle_InitRunsActiveHooks(t *testing.T) {
+	t.Setenv(GuardrailEnvVar, "search")
+	l := &Lifecycle{}
+	var ran []string
+	for _, id := range []string{"", "search", "lookup"} {
+		id := id
+		l.OnInit(id, func(context.Context) error {
+			ran = append(ran, id)
+			return nil
+		})
+	}
+
+	if err := l.Init(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"", "search"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("expected hooks %q to run, got %q", want, ran)
+	}
+}
+
+func TestLifecycle_InitRunsEveryHookInDevelopment(t *testing.T) {
+	t.Setenv(GuardrailEnvVar, "")
+	l := &Lifecycle{}
+	runs := 0
+	for _, id := range []string{"", "search", "lookup"} {
+		l.OnInit(id, func(context.Context) error {
+			runs++
+			return nil
+		})
+	}
+
+	if err := l.Init(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if runs != 3 {
+		t.Errorf("expected every hook to run, got %d", runs)
+	}
+}
+
+func TestLifecycle_InitStopsAtFirstError(t *testing.T) {
+	t.Setenv(GuardrailEnvVar, "search")
+	l := &Lifecycle{}
+	l.OnInit("search", func(context.Context) error { return errors.New("index missing") })
+	l.OnInit("", func(context.Context) error {
+		t.Error("hook after the failure was run")
+		return nil
+	})
+
+	err := l.Init(context.Background())
+	if err == nil || err.Error() != `endpoint "search" init: index missing` {
+		t.Errorf("expected the search init error, got %v", err)
+	}
+	if failures := l.Ready(context.Background()); failures["init"] == "" {
+		t.Errorf("expected init to be reported as failing, got %v", failures)
+	}
+}
+
+// readyz serves one request for ReadyzPath.
+func readyz(t *testing.T, l *Lifecycle) (int, healthResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	l.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, ReadyzPath, nil))
+	var body healthResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid body %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, body
+}
+
+func TestLifecycle_Readyz(t *testing.T) {
+	t.Setenv(GuardrailEnvVar, "search")
+	l := &Lifecycle{}
+	var indexErr error
+	l.AddReadinessCheck("search", "index", func(context.Context) error { return indexErr })
+	l.AddReadinessCheck("lookup", "cache", func(context.Context) error { return errors.New("cold") })
+
+	code, body := readyz(t, l)
+	if code != http.StatusServiceUnavailable || body.Failures["init"] != "not initialized" {
+		t.Errorf("expected 503 before Init, got %d %+v", code, body)
+	}
+
+	if err := l.Init(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The failing check of the inactive lookup endpoint is not run
+	code, body = readyz(t, l)
+	if code != http.StatusOK || body.Status != "ok" || body.Endpoint != "search" {
+		t.Errorf("expected 200 for search, got %d %+v", code, body)
+	}
+
+	indexErr = errors.New("index not loaded")
+	code, body = readyz(t, l)
+	if code != http.StatusServiceUnavailable || body.Status != "unavailable" ||
+		!reflect.DeepEqual(body.Failures, map[string]string{"index": "index not loaded"}) {
+		t.Errorf("expected 503 with the index failure, got %d %+v", code, body)
+	}
+}
+
+func TestLifecycle_Healthz(t *testing.T) {
+	t.Setenv(GuardrailEnvVar, "search")
+	l := &Lifecycle{}
+	l.AddReadinessCheck("", "db", func(context.Context) error { return errors.New("down") })
+
+	rec := httptest.NewRecorder()
+	l.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, HealthzPath, nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"status":"ok"`) {
+		t.Errorf("expected healthz to ignore readiness, got %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,225 @@
+package endpointscaler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metric names exported by MetricsHandler. Every series carries the
+// "endpoint" label (the Guard's endpoint ID) and the "mode" label ("guarded"
+// when ENDPOINTSCALER_GUARDRAIL is set, "development" otherwise).
+const (
+	// MetricRequestsTotal counts requests served by a Guard's handler, with
+	// an additional "code" label holding the response status code.
+	MetricRequestsTotal = "endpointscaler_sdk_requests_total"
+
+	// MetricRequestDuration is a histogram of the handler latency in seconds.
+	MetricRequestDuration = "endpointscaler_sdk_request_duration_seconds"
+
+	// MetricRequestsInFlight is the number of requests being served.
+	MetricRequestsInFlight = "endpointscaler_sdk_requests_in_flight"
+
+	// MetricGuardMisroutedTotal counts requests that reached a Guard whose
+	// endpoint is not active, with an "outcome" label of "rejected" (503) or
+	// "proxied" (see WithFallbackProxy).
+	MetricGuardMisroutedTotal = "endpointscaler_sdk_guard_misrouted_total"
+)
+
+// durationBuckets are the upper bounds of the latency histogram, in seconds.
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// WithMetrics makes Guard record the request metrics exported by
+// MetricsHandler.
+//
+// Usage:
+//
+//	mux.Handle("/lookup", endpointscaler.Guard("lookup", lookupHandler, endpointscaler.WithMetrics()))
+//	mux.Handle("/metrics", endpointscaler.MetricsHandler())
+func WithMetrics() GuardOption {
+	return func(cfg *guardConfig) {
+		cfg.metrics = defaultMetrics
+	}
+}
+
+// MetricsHandler serves the metrics recorded by guards with WithMetrics in the
+// Prometheus text exposition format.
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		defaultMetrics.write(w)
+	})
+}
+
+var defaultMetrics = newMetrics()
+
+type endpointKey struct {
+	endpoint string
+	mode     string
+}
+
+type requestKey struct {
+	endpointKey
+	code string
+}
+
+type misroutedKey struct {
+	endpointKey
+	outcome string
+}
+
+type histogram struct {
+	buckets []uint64
+	sum     float64
+	count   uint64
+}
+
+type metrics struct {
+	mu        sync.Mutex
+	requests  map[requestKey]uint64
+	durations map[endpointKey]*histogram
+	inFlight  map[endpointKey]int64
+	misrouted map[misroutedKey]uint64
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		requests:  map[requestKey]uint64{},
+		durations: map[endpointKey]*histogram{},
+		inFlight:  map[endpointKey]int64{},
+		misrouted: map[misroutedKey]uint64{},
+	}
+}
+
+func currentMode() string {
+	if os.Getenv(GuardrailEnvVar) == "" {
+		return "development"
+	}
+	return "guarded"
+}
+
+// serve runs handler and records its metrics.
+func (m *metrics) serve(endpointID string, handler http.Handler, w http.ResponseWriter, r *http.Request) {
+	key := endpointKey{endpoint: endpointID, mode: currentMode()}
+	m.mu.Lock()
+	m.inFlight[key]++
+	m.mu.Unlock()
+
+	rec := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+	start := time.Now()
+	defer func() {
+		elapsed := time.Since(start).Seconds()
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.inFlight[key]--
+		m.requests[requestKey{endpointKey: key, code: strconv.Itoa(rec.code)}]++
+		h := m.durations[key]
+		if h == nil {
+			h = &histogram{buckets: make([]uint64, len(durationBuckets))}
+			m.durations[key] = h
+		}
+		for i, bound := range durationBuckets {
+			if elapsed <= bound {
+				h.buckets[i]++
+			}
+		}
+		h.sum += elapsed
+		h.count++
+	}()
+	handler.ServeHTTP(rec, r)
+}
+
+func (m *metrics) recordMisrouted(endpointID, outcome string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.misrouted[misroutedKey{endpointKey: endpointKey{endpoint: endpointID, mode: currentMode()}, outcome: outcome}]++
+}
+
+func (m *metrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var b strings.Builder
+
+	writeHeader(&b, MetricRequestsTotal, "counter", "Requests served by guarded handlers.")
+	for _, key := range sortedKeys(m.requests, func(k requestKey) string { return k.endpoint + "\x00" + k.mode + "\x00" + k.code }) {
+		fmt.Fprintf(&b, "%s{%s,code=%s} %d\n", MetricRequestsTotal, key.labels(), quoteLabel(key.code), m.requests[key])
+	}
+
+	writeHeader(&b, MetricRequestDuration, "histogram", "Latency of guarded handlers in seconds.")
+	for _, key := range sortedKeys(m.durations, endpointKey.sortKey) {
+		h := m.durations[key]
+		for i, bound := range durationBuckets {
+			fmt.Fprintf(&b, "%s_bucket{%s,le=%s} %d\n", MetricRequestDuration, key.labels(),
+				quoteLabel(strconv.FormatFloat(bound, 'g', -1, 64)), h.buckets[i])
+		}
+		fmt.Fprintf(&b, "%s_bucket{%s,le=\"+Inf\"} %d\n", MetricRequestDuration, key.labels(), h.count)
+		fmt.Fprintf(&b, "%s_sum{%s} %s\n", MetricRequestDuration, key.labels(), strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "%s_count{%s} %d\n", MetricRequestDuration, key.labels(), h.count)
+	}
+
+	writeHeader(&b, MetricRequestsInFlight, "gauge", "Requests currently being served by guarded handlers.")
+	for _, key := range sortedKeys(m.inFlight, endpointKey.sortKey) {
+		fmt.Fprintf(&b, "%s{%s} %d\n", MetricRequestsInFlight, key.labels(), m.inFlight[key])
+	}
+
+	writeHeader(&b, MetricGuardMisroutedTotal, "counter", "Requests that reached a guard whose endpoint is not active.")
+	for _, key := range sortedKeys(m.misrouted, func(k misroutedKey) string { return k.endpoint + "\x00" + k.mode + "\x00" + k.outcome }) {
+		fmt.Fprintf(&b, "%s{%s,outcome=%s} %d\n", MetricGuardMisroutedTotal, key.labels(), quoteLabel(key.outcome), m.misrouted[key])
+	}
+
+	io.WriteString(w, b.String())
+}
+
+func (k endpointKey) labels() string {
+	return fmt.Sprintf("endpoint=%s,mode=%s", quoteLabel(k.endpoint), quoteLabel(k.mode))
+}
+
+func (k endpointKey) sortKey() string {
+	return k.endpoint + "\x00" + k.mode
+}
+
+func writeHeader(b *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// quoteLabel quotes a label value as the text format requires.
+func quoteLabel(v string) string {
+	v = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+	return `"` + v + `"`
+}
+
+// sortedKeys returns the keys of m ordered by sortKey, for stable output.
+func sortedKeys[K comparable, V any](m map[K]V, sortKey func(K) string) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return sortKey(keys[i]) < sortKey(keys[j]) })
+	return keys
+}
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	code        int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.code = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,118 @@
+package endpointscaler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scrapeMetrics returns the lines served by MetricsHandler.
+func scrapeMetrics(t *testing.T) []string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if got := rec.Header().Get("Content-Type"); got != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Errorf("unexpected content type %q", got)
+	}
+	return strings.Split(strings.TrimSuffix(rec.Body.String(), "\n"), "\n")
+}
+
+func TestMetricsHandler(t *testing.T) {
+	prev := defaultMetrics
+	defaultMetrics = newMetrics()
+	t.Cleanup(func() { defaultMetrics = prev })
+	t.Setenv(GuardrailEnvVar, "lookup")
+
+	lookup := GuardFunc("lookup", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("slow") != "" {
+			time.Sleep(20 * time.Millisecond)
+		}
+		if r.URL.Query().Get("missing") != "" {
+			http.NotFound(w, r)
+		}
+	}, WithMetrics())
+	// An ID that needs escaping in a label value
+	quoted := GuardFunc("say \"hi\"\\\n", func(w http.ResponseWriter, r *http.Request) {}, WithMetrics())
+
+	for _, target := range []string{"/lookup", "/lookup?slow=1", "/lookup?missing=1"} {
+		lookup.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+	quoted.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	lines := scrapeMetrics(t)
+	has := func(line string) bool {
+		for _, l := range lines {
+			if l == line {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, want := range []string{
+		"# HELP endpointscaler_sdk_requests_total Requests served by guarded handlers.",
+		"# TYPE endpointscaler_sdk_requests_total counter",
+		`endpointscaler_sdk_requests_total{endpoint="lookup",mode="guarded",code="200"} 2`,
+		`endpointscaler_sdk_requests_total{endpoint="lookup",mode="guarded",code="404"} 1`,
+		"# TYPE endpointscaler_sdk_request_duration_seconds histogram",
+		`endpointscaler_sdk_request_duration_seconds_bucket{endpoint="lookup",mode="guarded",le="+Inf"} 3`,
+		`endpointscaler_sdk_request_duration_seconds_count{endpoint="lookup",mode="guarded"} 3`,
+		"# TYPE endpointscaler_sdk_requests_in_flight gauge",
+		`endpointscaler_sdk_requests_in_flight{endpoint="lookup",mode="guarded"} 0`,
+		"# TYPE endpointscaler_sdk_guard_misrouted_total counter",
+		`endpointscaler_sdk_guard_misrouted_total{endpoint="say \"hi\"\\\n",mode="guarded",outcome="rejected"} 1`,
+	} {
+		if !has(want) {
+			t.Errorf("missing line %s", want)
+		}
+	}
+
+	// Buckets are cumulative and cover every request by the last bound
+	prefix := `endpointscaler_sdk_request_duration_seconds_bucket{endpoint="lookup",mode="guarded",le=`
+	var counts []uint64
+	for _, bound := range durationBuckets {
+		le := strconv.Quote(strconv.FormatFloat(bound, 'g', -1, 64))
+		var count uint64
+		found := false
+		for _, l := range lines {
+			if value, ok := strings.CutPrefix(l, prefix+le+"} "); ok {
+				count, _ = strconv.ParseUint(value, 10, 64)
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("missing bucket le=%s in:\n%s", le, strings.Join(lines, "\n"))
+		}
+		counts = append(counts, count)
+	}
+	for i := 1; i < len(counts); i++ {
+		if counts[i] < counts[i-1] {
+			t.Errorf("expected cumulative buckets, got %v", counts)
+		}
+	}
+	if counts[0] > 2 || counts[len(counts)-1] != 3 {
+		t.Errorf("expected the slow request above the first bound and every request within the last, got %v", counts)
+	}
+}
+
+func TestMetricsHandler_Development(t *testing.T) {
+	prev := defaultMetrics
+	defaultMetrics = newMetrics()
+	t.Cleanup(func() { defaultMetrics = prev })
+	t.Setenv(GuardrailEnvVar, "")
+
+	handler := GuardFunc("lookup", func(w http.ResponseWriter, r *http.Request) {}, WithMetrics())
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/lookup", nil))
+
+	want := fmt.Sprintf(`%s{endpoint="lookup",mode="development",code="200"} 1`, MetricRequestsTotal)
+	for _, l := range scrapeMetrics(t) {
+		if l == want {
+			return
+		}
+	}
+	t.Errorf("missing line %s", want)
+}
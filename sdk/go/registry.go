@@ -0,0 +1,322 @@
+package endpointscaler
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PrintFlagName is the name of the flag defined by PrintFlag.
+const PrintFlagName = "print-endpoint-policy"
+
+// Endpoint describes a handler the way its entry in an EndpointPolicy's
+// spec.endpoints would. Path, PathType and Method mirror an HTTP match;
+// Service and Method mirror a gRPC match.
+type Endpoint struct {
+	// ID is the endpoint ID
+	ID string
+
+	// Type is "http" (default) or "grpc"
+	Type string
+
+	// Path is the HTTP path of the handler
+	Path string
+
+	// PathType is "PathPrefix" (default), "Exact" or "RegularExpression"
+	PathType string
+
+	// Method is the HTTP method, or the gRPC method name
+	Method string
+
+	// Service is the fully-qualified gRPC service name
+	Service string
+
+	// Strategy is the suggested routing strategy (e.g., "primary", "canary")
+	Strategy string
+
+	// Resources are the suggested container resources
+	Resources *Resources
+}
+
+// Resources mirror an endpoint's resources in an EndpointPolicy.
+type Resources struct {
+	CPULimit   string `json:"cpuLimit,omitempty"`
+	CPURequest string `json:"cpuRequest,omitempty"`
+	MemLimit   string `json:"memLimit,omitempty"`
+	MemRequest string `json:"memRequest,omitempty"`
+}
+
+// Registry collects the endpoints an application serves so that the
+// EndpointPolicy endpoints can be generated from the code instead of being
+// written by hand.
+//
+// Usage:
+//
+//	registry := endpointscaler.NewRegistry()
+//	mux.Handle("/api/lookup", registry.Guard(endpointscaler.Endpoint{
+//	    ID:        "lookup",
+//	    Path:      "/api/lookup",
+//	    Resources: &endpointscaler.Resources{CPULimit: "2", MemLimit: "1Gi"},
+//	}, lookupHandler))
+//
+//	registry.WriteYAML(os.Stdout)
+type Registry struct {
+	mu        sync.Mutex
+	endpoints []*registered
+}
+
+// registered is an endpoint ID with every match registered for it.
+type registered struct {
+	endpoint Endpoint
+	matches  []specMatch
+}
+
+// DefaultRegistry is the registry used by the package-level Register.
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds an endpoint to DefaultRegistry.
+func Register(e Endpoint) {
+	DefaultRegistry.Register(e)
+}
+
+// Register adds an endpoint. Registering an HTTP endpoint ID again adds
+// another match, rendered under "matches"; its Strategy and Resources must
+// then be empty or equal to the first registration. Register panics on
+// invalid or conflicting registrations, like http.ServeMux.Handle.
+func (r *Registry) Register(e Endpoint) {
+	if e.ID == "" {
+		panic("endpointscaler: endpoint ID is required")
+	}
+	if e.Type == "" {
+		e.Type = "http"
+	}
+	match := specMatch{PathType: e.PathType, Path: e.Path, Method: e.Method, Service: e.Service}
+	switch e.Type {
+	case "http":
+		if e.Path == "" {
+			panic(fmt.Sprintf("endpointscaler: endpoint %q: path is required for HTTP endpoints", e.ID))
+		}
+	case "grpc":
+		if e.Service == "" || e.Method == "" {
+			panic(fmt.Sprintf("endpointscaler: endpoint %q: service and method are required for gRPC endpoints", e.ID))
+		}
+	default:
+		panic(fmt.Sprintf("endpointscaler: endpoint %q: unknown type %q", e.ID, e.Type))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.endpoints {
+		if existing.endpoint.ID != e.ID {
+			continue
+		}
+		first := existing.endpoint
+		switch {
+		case first.Type != e.Type || e.Type == "grpc":
+			panic(fmt.Sprintf("endpointscaler: endpoint %q registered twice", e.ID))
+		case e.Strategy != "" && e.Strategy != first.Strategy:
+			panic(fmt.Sprintf("endpointscaler: endpoint %q registered with strategies %q and %q", e.ID, first.Strategy, e.Strategy))
+		case e.Resources != nil && (first.Resources == nil || *e.Resources != *first.Resources):
+			panic(fmt.Sprintf("endpointscaler: endpoint %q registered with different resources", e.ID))
+		}
+		existing.matches = append(existing.matches, match)
+		return
+	}
+	r.endpoints = append(r.endpoints, &registered{endpoint: e, matches: []specMatch{match}})
+}
+
+// Guard registers e and returns handler wrapped in Guard for e.ID.
+func (r *Registry) Guard(e Endpoint, handler http.Handler, opts ...GuardOption) http.Handler {
+	r.Register(e)
+	return Guard(e.ID, handler, opts...)
+}
+
+// Endpoints returns every registration in order, one per registered match.
+func (r *Registry) Endpoints() []Endpoint {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var endpoints []Endpoint
+	for _, reg := range r.endpoints {
+		for _, m := range reg.matches {
+			e := reg.endpoint
+			e.PathType, e.Path, e.Method, e.Service = m.PathType, m.Path, m.Method, m.Service
+			endpoints = append(endpoints, e)
+		}
+	}
+	return endpoints
+}
+
+// specEndpoints mirrors the endpoints of an EndpointPolicySpec.
+type specEndpoints struct {
+	Endpoints []specEndpoint `json:"endpoints"`
+}
+
+type specEndpoint struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	Match     *specMatch  `json:"match,omitempty"`
+	Matches   []specMatch `json:"matches,omitempty"`
+	Strategy  string      `json:"strategy,omitempty"`
+	Resources *Resources  `json:"resources,omitempty"`
+}
+
+type specMatch struct {
+	PathType string `json:"pathType,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Service  string `json:"service,omitempty"`
+	Method   string `json:"method,omitempty"`
+}
+
+func (r *Registry) spec() specEndpoints {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	spec := specEndpoints{Endpoints: []specEndpoint{}}
+	for _, reg := range r.endpoints {
+		e := specEndpoint{
+			ID:        reg.endpoint.ID,
+			Type:      reg.endpoint.Type,
+			Strategy:  reg.endpoint.Strategy,
+			Resources: reg.endpoint.Resources,
+		}
+		if len(reg.matches) == 1 {
+			match := reg.matches[0]
+			e.Match = &match
+		} else {
+			e.Matches = append([]specMatch{}, reg.matches...)
+		}
+		spec.Endpoints = append(spec.Endpoints, e)
+	}
+	return spec
+}
+
+// WriteJSON writes the registered endpoints as the "endpoints" field of an
+// EndpointPolicySpec in JSON.
+func (r *Registry) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r.spec())
+}
+
+// WriteYAML writes the registered endpoints as the "endpoints" field of an
+// EndpointPolicySpec in YAML, ready to paste under spec or to diff in CI.
+func (r *Registry) WriteYAML(w io.Writer) error {
+	spec := r.spec()
+	var b strings.Builder
+	if len(spec.Endpoints) == 0 {
+		b.WriteString("endpoints: []\n")
+	} else {
+		b.WriteString("endpoints:\n")
+	}
+	for _, e := range spec.Endpoints {
+		fmt.Fprintf(&b, "  - id: %s\n", yamlString(e.ID))
+		fmt.Fprintf(&b, "    type: %s\n", yamlString(e.Type))
+		if e.Match != nil {
+			b.WriteString("    match:\n")
+			writeYAMLMatch(&b, "      ", "      ", e.Match)
+		}
+		if len(e.Matches) > 0 {
+			b.WriteString("    matches:\n")
+			for i := range e.Matches {
+				writeYAMLMatch(&b, "      - ", "        ", &e.Matches[i])
+			}
+		}
+		if e.Strategy != "" {
+			fmt.Fprintf(&b, "    strategy: %s\n", yamlString(e.Strategy))
+		}
+		if res := e.Resources; res != nil {
+			b.WriteString("    resources:\n")
+			for _, field := range [][2]string{
+				{"cpuLimit", res.CPULimit},
+				{"cpuRequest", res.CPURequest},
+				{"memLimit", res.MemLimit},
+				{"memRequest", res.MemRequest},
+			} {
+				if field[1] != "" {
+					fmt.Fprintf(&b, "      %s: %s\n", field[0], yamlString(field[1]))
+				}
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeYAMLMatch writes the fields of m, prefixing the first line with first
+// and the others with rest.
+func writeYAMLMatch(b *strings.Builder, first, rest string, m *specMatch) {
+	prefix := first
+	for _, field := range [][2]string{
+		{"pathType", m.PathType},
+		{"path", m.Path},
+		{"service", m.Service},
+		{"method", m.Method},
+	} {
+		if field[1] == "" {
+			continue
+		}
+		fmt.Fprintf(b, "%s%s: %s\n", prefix, field[0], yamlString(field[1]))
+		prefix = rest
+	}
+}
+
+var yamlPlainRegexp = regexp.MustCompile(`^[A-Za-z_/][A-Za-z0-9_./-]*$`)
+
+// yamlString returns s as a YAML scalar, quoted unless it is unambiguously a
+// plain string.
+func yamlString(s string) string {
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null", "~":
+		return strconv.Quote(s)
+	}
+	if yamlPlainRegexp.MatchString(s) {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+// PrintFlag defines the -print-endpoint-policy flag on fs, whose value is
+// passed to WriteAndExit once every handler is registered.
+//
+// Usage:
+//
+//	printPolicy := endpointscaler.PrintFlag(flag.CommandLine)
+//	flag.Parse()
+//	// ... register handlers ...
+//	endpointscaler.DefaultRegistry.WriteAndExit(*printPolicy)
+func PrintFlag(fs *flag.FlagSet) *string {
+	return fs.String(PrintFlagName, "", `print the EndpointPolicy endpoints ("yaml" or "json") and exit`)
+}
+
+// WriteAndExit writes the registered endpoints to stdout in format ("yaml"
+// or "json") and exits. It returns immediately when format is empty.
+func (r *Registry) WriteAndExit(format string) {
+	if format == "" {
+		return
+	}
+	var err error
+	switch format {
+	case "yaml":
+		err = r.WriteYAML(os.Stdout)
+	case "json":
+		err = r.WriteJSON(os.Stdout)
+	default:
+		err = fmt.Errorf("unknown format %q, expected \"yaml\" or \"json\"", format)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "-%s: %v\n", PrintFlagName, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,235 @@
+package endpointscaler
+
+import (
+	"strings"
+	"testing"
+)
+
+func testRegistry() *Registry {
+	r := NewRegistry()
+	r.Register(Endpoint{
+		ID:        "lookup",
+		Path:      "/api/lookup",
+		Strategy:  "canary",
+		Resources: &Resources{CPULimit: "2", MemLimit: "1Gi"},
+	})
+	r.Register(Endpoint{ID: "reports", Path: "/api/reports", Method: "GET"})
+	r.Register(Endpoint{ID: "reports", PathType: "Exact", Path: "/api/reports/export"})
+	r.Register(Endpoint{ID: "users", Type: "grpc", Service: "com.example.UserService", Method: "GetUser"})
+	return r
+}
+
+func TestRegistry_WriteYAML(t *testing.T) {
+	var b strings.Builder
+	if err := testRegistry().WriteYAML(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `endpoints:
+  - id: lookup
+    type: http
+    match:
+      path: /api/lookup
+    strategy: canary
+    resources:
+      cpuLimit: "2"
+      memLimit: "1Gi"
+  - id: reports
+    type: http
+    matches:
+      - path: /api/reports
+        method: GET
+      - pathType: Exact
+        path: /api/reports/export
+  - id: users
+    type: grpc
+    match:
+      service: com.example.UserService
+      method: GetUser
+`
+	if b.String() != want {
+		t.Errorf("unexpected YAML:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestRegistry_WriteJSON(t *testing.T) {
+	var b strings.Builder
+	if err := testRegistry().WriteJSON(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{
+  "endpoints": [
+    {
+      "id": "lookup",
+      "type": "http",
+      "match": {
+        "path": "/api/lookup"
+      },
+      "strategy": "canary",
+      "resources": {
+        "cpuLimit": "2",
+        "memLimit": "1Gi"
+      }
+    },
+    {
+      "id": "reports",
+      "type": "http",
+      "matches": [
+        {
+          "path": "/api/reports",
+          "method": "GET"
+        },
+        {
+          "pathType": "Exact",
+          "path": "/api/reports/export"
+        }
+      ]
+    },
+    {
+      "id": "users",
+      "type": "grpc",
+      "match": {
+        "service": "com.example.UserService",
+        "method": "GetUser"
+      }
+    }
+  ]
+}
+`
+	if b.String() != want {
+		t.Errorf("unexpected JSON:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestRegistry_WriteEmpty(t *testing.T) {
+	var yaml, json strings.Builder
+	r := NewRegistry()
+	if err := r.WriteYAML(&yaml); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.WriteJSON(&json); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if yaml.String() != "endpoints: []\n" || json.String() != "{\n  \"endpoints\": []\n}\n" {
+		t.Errorf("expected empty endpoint lists, got %q and %q", yaml.String(), json.String())
+	}
+}
+
+func TestYAMLString(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"lookup", "lookup"},
+		{"/api/lookup", "/api/lookup"},
+		{"com.example.UserService", "com.example.UserService"},
+		{"on", `"on"`},
+		{"Off", `"Off"`},
+		{"yes", `"yes"`},
+		{"null", `"null"`},
+		{"~", `"~"`},
+		{"123", `"123"`},
+		{"500m", `"500m"`},
+		{"1.5", `"1.5"`},
+		{"", `""`},
+		{"/api/{id}", `"/api/{id}"`},
+		{"a: b", `"a: b"`},
+		{"#comment", `"#comment"`},
+	}
+	for _, tt := range tests {
+		if got := yamlString(tt.in); got != tt.want {
+			t.Errorf("yamlString(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRegistry_RegisterPanics(t *testing.T) {
+	tests := []struct {
+		name      string
+		endpoints []Endpoint
+		wantPanic string
+	}{
+		{
+			name:      "missing ID",
+			endpoints: []Endpoint{{Path: "/api"}},
+			wantPanic: "endpoint ID is required",
+		},
+		{
+			name:      "HTTP without path",
+			endpoints: []Endpoint{{ID: "lookup"}},
+			wantPanic: "path is required",
+		},
+		{
+			name:      "gRPC without method",
+			endpoints: []Endpoint{{ID: "users", Type: "grpc", Service: "com.example.UserService"}},
+			wantPanic: "service and method are required",
+		},
+		{
+			name:      "unknown type",
+			endpoints: []Endpoint{{ID: "lookup", Type: "tcp", Path: "/api"}},
+			wantPanic: `unknown type "tcp"`,
+		},
+		{
+			name: "gRPC registered twice",
+			endpoints: []Endpoint{
+				{ID: "users", Type: "grpc", Service: "com.example.UserService", Method: "GetUser"},
+				{ID: "users", Type: "grpc", Service: "com.example.UserService", Method: "ListUsers"},
+			},
+			wantPanic: "registered twice",
+		},
+		{
+			name: "different types",
+			endpoints: []Endpoint{
+				{ID: "users", Path: "/api/users"},
+				{ID: "users", Type: "grpc", Service: "com.example.UserService", Method: "GetUser"},
+			},
+			wantPanic: "registered twice",
+		},
+		{
+			name: "different strategies",
+			endpoints: []Endpoint{
+				{ID: "lookup", Path: "/api/lookup", Strategy: "canary"},
+				{ID: "lookup", Path: "/api/search", Strategy: "primary"},
+			},
+			wantPanic: `strategies "canary" and "primary"`,
+		},
+		{
+			name: "different resources",
+			endpoints: []Endpoint{
+				{ID: "lookup", Path: "/api/lookup", Resources: &Resources{CPULimit: "1"}},
+				{ID: "lookup", Path: "/api/search", Resources: &Resources{CPULimit: "2"}},
+			},
+			wantPanic: "different resources",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				msg, _ := recover().(string)
+				if !strings.Contains(msg, tt.wantPanic) {
+					t.Errorf("expected panic containing %q, got %q", tt.wantPanic, msg)
+				}
+			}()
+			r := NewRegistry()
+			for _, e := range tt.endpoints {
+				r.Register(e)
+			}
+		})
+	}
+}
+
+func TestRegistry_RegisterSameSettingsAgain(t *testing.T) {
+	r := NewRegistry()
+	r.Register(Endpoint{ID: "lookup", Path: "/api/lookup", Strategy: "canary", Resources: &Resources{CPULimit: "1"}})
+	// Later matches may leave the settings empty or repeat them
+	r.Register(Endpoint{ID: "lookup", Path: "/api/search"})
+	r.Register(Endpoint{ID: "lookup", Path: "/api/find", Strategy: "canary", Resources: &Resources{CPULimit: "1"}})
+
+	endpoints := r.Endpoints()
+	if len(endpoints) != 3 {
+		t.Fatalf("expected three registrations, got %+v", endpoints)
+	}
+	for _, e := range endpoints {
+		if e.Strategy != "canary" || e.Resources == nil || e.Resources.CPULimit != "1" {
+			t.Errorf("expected the first registration's settings, got %+v", e)
+		}
+	}
+}